@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+	"project-api/internal/infra/repository"
+	"project-api/internal/infra/storage"
+	"project-api/internal/task"
+
+	"github.com/RichardKnop/machinery/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// reconcile checks storage against the database once and prints the report.
+// It is a dry run unless -repair is given.
+func main() {
+	configType := flag.String("config", "yaml", "Configuration type (env or yaml)")
+	repair := flag.Bool("repair", false, "Delete orphaned objects and clear missing thumbnails instead of only reporting them")
+	prefix := flag.String("prefix", "", "Only check objects whose key starts with this prefix (default: reconcile.prefix)")
+	grace := flag.Duration("grace", 0, "Leave objects younger than this alone (default: reconcile.grace_period_seconds)")
+	reportPath := flag.String("report", "", "Write the JSON report to this file instead of stdout")
+	flag.Parse()
+
+	var err error
+	if *configType == "env" {
+		config.IsYaml = false
+		err = config.LoadConfig("")
+	} else {
+		err = config.LoadConfig("conf/app.yaml")
+	}
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db := &config.GormDB{Config: &gorm.Config{}}
+	if err := db.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	s3Repo, err := storage.New()
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	// Without the broker, cleared thumbnails wait for the next upload
+	var server *machinery.Server
+	if *repair {
+		if server, err = task.NewMachineryServer(); err != nil {
+			logger.Warn("Failed to connect to the task broker, thumbnails won't be queued", zap.Error(err))
+			server = nil
+		}
+	}
+
+	options := InS.ReconcileOptions{
+		Repair:      *repair,
+		Prefix:      config.Config.Reconcile.Prefix,
+		GracePeriod: config.Config.GetReconcileGracePeriod(),
+	}
+	if *prefix != "" {
+		options.Prefix = *prefix
+	}
+	if *grace > 0 {
+		options.GracePeriod = *grace
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	reconciler := service.NewReconcileService(repository.NewStorageRefRepository(db.DB), repository.NewFileRepository(db.DB), s3Repo, server)
+	report, err := reconciler.Reconcile(ctx, options)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	out := os.Stdout
+	if *reportPath != "" {
+		if out, err = os.Create(*reportPath); err != nil {
+			log.Fatalf("Failed to create report: %v", err)
+		}
+		defer out.Close()
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
@@ -11,15 +11,18 @@ import (
 	"time"
 
 	"project-api/internal/controller"
+	portRepository "project-api/internal/core/port/repository"
 	"project-api/internal/core/service"
-	"project-api/internal/infra/aws"
 	"project-api/internal/infra/config"
+	"project-api/internal/infra/events"
+	"project-api/internal/infra/keys"
 	"project-api/internal/infra/logger"
+	"project-api/internal/infra/redis"
 	"project-api/internal/infra/repository"
+	"project-api/internal/infra/storage"
 	"project-api/internal/task"
 
 	"github.com/RichardKnop/machinery/v2"
-	"github.com/gofiber/storage/s3/v2"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -30,6 +33,7 @@ type Application struct {
 	db       *config.GormDB
 	router   *controller.Router
 	services *controller.Services
+	events   portRepository.IEventBus
 }
 
 // Config holds runtime configuration
@@ -98,9 +102,19 @@ func initializeApp() (*Application, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Machinery server: %w", err)
 	}
-	// redisClient := redis.NewRedisClient()
+	eventBus := newEventBus()
+	s3Repo, err := storage.New()
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	keyProvider, err := keys.New()
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to initialize encryption keys: %w", err)
+	}
 	// Initialize services
-	services := initializeServices(db, machineryServer)
+	services := initializeServices(db, machineryServer, eventBus, s3Repo, keyProvider)
 
 	// Create router
 	router, err := controller.New(services)
@@ -114,30 +128,50 @@ func initializeApp() (*Application, error) {
 		db:       db,
 		router:   router,
 		services: services,
+		events:   eventBus,
 	}, nil
 }
 
-func initializeServices(db *config.GormDB, machineryServer *machinery.Server) *controller.Services {
-	s3Config := config.Config.GetS3Config()
-	credential := config.Config.GetCredentials()
-
-	awsConfig := s3.Config{
-		Bucket:      s3Config.Bucket,
-		Region:      s3Config.Region,
-		Endpoint:    s3Config.Endpoint,
-		Credentials: credential,
+// newEventBus fans real-time events out through Redis when it is configured,
+// otherwise only within this process
+func newEventBus() portRepository.IEventBus {
+	if config.Config.Redis.Endpoint == "" {
+		logger.Warn("Redis is not configured, worker events won't reach connected clients")
+		return events.NewLocalBus()
 	}
+	return events.NewRedisBus(redis.NewRedisClient())
+}
 
+func initializeServices(db *config.GormDB, machineryServer *machinery.Server, eventBus portRepository.IEventBus, s3Repo portRepository.IS3Repository, keyProvider portRepository.IKeyProvider) *controller.Services {
 	fileRepo := repository.NewFileRepository(db.DB)
 	userRepo := repository.NewUserRepository(db.DB)
 	userService := service.NewUserService(userRepo)
-	s3Repo := aws.New(awsConfig)
-	fileService := service.NewS3Service(fileRepo, s3Repo)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	preferenceRepo := repository.NewNotificationPreferenceRepository(db.DB)
+	notificationService := service.NewNotificationService(notificationRepo, preferenceRepo, userRepo, machineryServer, eventBus)
+	uploadRepo := repository.NewUploadRepository(db.DB)
+	blobRepo := repository.NewBlobRepository(db.DB)
+	quotaService := service.NewQuotaService(repository.NewQuotaRepository(db.DB))
+	permissionRepo := repository.NewFilePermissionRepository(db.DB)
+	versionRepo := repository.NewFileVersionRepository(db.DB)
+	encryptionService := service.NewEncryptionService(keyProvider, s3Repo)
+	fileService := service.NewS3Service(fileRepo, uploadRepo, blobRepo, permissionRepo, userRepo, versionRepo, s3Repo, notificationService, quotaService, encryptionService, machineryServer)
+	tusService := service.NewTusService(fileRepo, uploadRepo, blobRepo, s3Repo, notificationService, quotaService, encryptionService, machineryServer)
+	folderService := service.NewFolderService(repository.NewFolderRepository(db.DB), fileRepo)
+	shareService := service.NewShareService(fileRepo, repository.NewShareLinkRepository(db.DB), s3Repo, encryptionService)
+	importJobRepo := repository.NewImportJobRepository(db.DB)
+	userImportService := service.NewUserImportService(userRepo, importJobRepo, machineryServer, notificationService, eventBus)
 
 	return &controller.Services{
-		UserService: userService,
-		FileService: fileService,
-		Server:      machineryServer,
+		UserService:         userService,
+		FileService:         fileService,
+		UserImportService:   userImportService,
+		NotificationService: notificationService,
+		TusService:          tusService,
+		QuotaService:        quotaService,
+		ShareService:        shareService,
+		FolderService:       folderService,
+		Events:              eventBus,
 	}
 }
 
@@ -173,6 +207,10 @@ func runServer(app *Application, port string) error {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Close event streams first so their connections don't hold up the shutdown
+	if err := app.events.Close(); err != nil {
+		logger.Error("Failed to close event bus", zap.Error(err))
+	}
 	if err := app.router.ShutdownWithContext(shutdownCtx); err != nil {
 		logger.Error("Failed to shutdown server gracefully", zap.Error(err))
 	}
@@ -3,8 +3,18 @@ package main
 import (
 	"flag"
 	"log"
+	"project-api/internal/core/service"
+	"project-api/internal/infra/clamd"
 	"project-api/internal/infra/config"
+	"project-api/internal/infra/events"
+	"project-api/internal/infra/keys"
+	"project-api/internal/infra/redis"
+	"project-api/internal/infra/repository"
+	"project-api/internal/infra/storage"
 	"project-api/internal/task"
+
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -28,18 +38,133 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to start Machinery server: %v", err)
 	}
+
+	// เชื่อมต่อฐานข้อมูลสำหรับ task ที่ต้องใช้ repository
+	db := &config.GormDB{Config: &gorm.Config{}}
+	if err := db.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	userRepo := repository.NewUserRepository(db.DB)
+	importJobRepo := repository.NewImportJobRepository(db.DB)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	preferenceRepo := repository.NewNotificationPreferenceRepository(db.DB)
+	// events published here reach the API instances through Redis
+	eventBus := events.NewLocalBus()
+	if config.Config.Redis.Endpoint != "" {
+		eventBus = events.NewRedisBus(redis.NewRedisClient())
+	}
+	notificationService := service.NewNotificationService(notificationRepo, preferenceRepo, userRepo, server, eventBus)
+	userImportTask := task.NewUserImportTask(service.NewUserImportService(userRepo, importJobRepo, server, notificationService, eventBus))
+	quotaService := service.NewQuotaService(repository.NewQuotaRepository(db.DB))
+	quotaTask := task.NewQuotaTask(quotaService)
+
+	// งาน prune version, ล้างถังขยะ และลบไฟล์หมดอายุต้องลบ object ใน S3 ด้วย
+	s3Repo, err := storage.New()
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	// thumbnail และ scan ต้องอ่านไฟล์ที่เข้ารหัสไว้ได้
+	keyProvider, err := keys.New()
+	if err != nil {
+		log.Fatalf("Failed to initialize encryption keys: %v", err)
+	}
+	encryptionService := service.NewEncryptionService(keyProvider, s3Repo)
+	fileRepo := repository.NewFileRepository(db.DB)
+	blobRepo := repository.NewBlobRepository(db.DB)
+	versionRepo := repository.NewFileVersionRepository(db.DB)
+	uploadRepo := repository.NewUploadRepository(db.DB)
+	fileService := service.NewS3Service(
+		fileRepo,
+		uploadRepo,
+		blobRepo,
+		repository.NewFilePermissionRepository(db.DB),
+		userRepo,
+		versionRepo,
+		s3Repo, notificationService, quotaService, encryptionService, server)
+	versionTask := task.NewVersionTask(fileService)
+	trashTask := task.NewTrashTask(fileService)
+	expiryTask := task.NewExpiryTask(fileService)
+	uploadTask := task.NewUploadTask(service.NewTusService(fileRepo, uploadRepo, blobRepo, s3Repo, notificationService, quotaService, encryptionService, server))
+	thumbnailTask := task.NewThumbnailTask(fileService)
+	scanner := clamd.New(config.Config.Scan.ClamdAddress, config.Config.GetScanTimeout())
+	reconcileTask := task.NewReconcileTask(service.NewReconcileService(repository.NewStorageRefRepository(db.DB), fileRepo, s3Repo, server))
+	scanTask := task.NewScanTask(service.NewScanService(fileRepo, versionRepo, blobRepo, s3Repo, scanner, notificationService, quotaService, encryptionService))
+
 	err = server.RegisterTasks(map[string]interface{}{
-		"send_confirmation_email": func(toEmail, token, name string, host string) error {
-			return task.TaskSendConfirmationEmail(toEmail, token, name, host)
+		"send_confirmation_email": func(toEmail, token, name string, host string, tenant string) error {
+			return task.TaskSendConfirmationEmail(toEmail, token, name, host, tenant)
+		},
+		"send_reset_password_email": func(toEmail, token, name string, host string, tenant string) error {
+			return task.TaskSendResetPasswordEmail(toEmail, token, name, host, tenant)
 		},
-		"send_reset_password_email": func(toEmail, token, name string, host string) error {
-			return task.TaskSendResetPasswordEmail(toEmail, token, name, host)
+		"send_invitation_email": func(toEmail, token, name string, host string, tenant string) error {
+			return task.TaskSendInvitationEmail(toEmail, token, name, host, tenant)
 		},
+		"send_notification_email": func(toEmail, title, name string, body string) error {
+			return task.TaskSendNotificationEmail(toEmail, title, name, body)
+		},
+		"import_users":               userImportTask.ImportUsers,
+		task.ReconcileStorageUsage:   quotaTask.ReconcileUsage,
+		task.PruneFileVersions:       versionTask.PruneVersions,
+		task.PurgeTrash:              trashTask.PurgeTrash,
+		task.DeleteExpiredFiles:      expiryTask.DeleteExpired,
+		task.PurgeExpiredUploads:     uploadTask.PurgeExpired,
+		task.ScanUploads:             scanTask.ScanUploads,
+		task.GenerateThumbnails:      thumbnailTask.GenerateThumbnails,
+		task.ReconcileStorageObjects: reconcileTask.ReconcileObjects,
 	})
 	if err != nil {
 		log.Fatalf("Failed to register tasks: %v", err)
 	}
 
+	// รันงาน reconcile ตามรอบเวลาเพื่อแก้ usage ที่คลาดเคลื่อน
+	err = server.RegisterPeriodicTask(config.Config.GetQuotaReconcileSchedule(), task.ReconcileStorageUsage, &tasks.Signature{
+		Name: task.ReconcileStorageUsage,
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule usage reconciliation: %v", err)
+	}
+
+	err = server.RegisterPeriodicTask(config.Config.GetVersionPruneSchedule(), task.PruneFileVersions, &tasks.Signature{
+		Name: task.PruneFileVersions,
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule version pruning: %v", err)
+	}
+	err = server.RegisterPeriodicTask(config.Config.GetTrashPurgeSchedule(), task.PurgeTrash, &tasks.Signature{
+		Name: task.PurgeTrash,
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule trash purge: %v", err)
+	}
+	err = server.RegisterPeriodicTask(config.Config.GetFileExpirySchedule(), task.DeleteExpiredFiles, &tasks.Signature{
+		Name: task.DeleteExpiredFiles,
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule expired file cleanup: %v", err)
+	}
+	err = server.RegisterPeriodicTask(config.Config.GetTusCleanupSchedule(), task.PurgeExpiredUploads, &tasks.Signature{
+		Name: task.PurgeExpiredUploads,
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule expired upload cleanup: %v", err)
+	}
+	err = server.RegisterPeriodicTask(config.Config.GetReconcileSchedule(), task.ReconcileStorageObjects, &tasks.Signature{
+		Name: task.ReconcileStorageObjects,
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule storage reconciliation: %v", err)
+	}
+	// Uploads are only quarantined while a scanner is configured
+	if config.Config.GetScanEnabled() {
+		err = server.RegisterPeriodicTask(config.Config.GetScanSchedule(), task.ScanUploads, &tasks.Signature{
+			Name: task.ScanUploads,
+		})
+		if err != nil {
+			log.Fatalf("Failed to schedule upload scanning: %v", err)
+		}
+	}
+
 	// เริ่ม worker
 	worker := server.NewWorker("email_worker", 10) // 10 concurrent workers
 	if err := worker.Launch(); err != nil {
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/model/response"
+	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	importService In.IUserImportService
+}
+
+func NewAdminHandler(importService In.IUserImportService) *AdminHandler {
+	return &AdminHandler{importService: importService}
+}
+
+// ImportUsers accepts a CSV or JSON-lines file in the "file" field and queues it for import
+func (h *AdminHandler) ImportUsers(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Requires an import file in 'file' field",
+			Data: err.Error(),
+		})
+	}
+	if fileHeader.Size > service.MaxImportPayload {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusRequestEntityTooLarge,
+			Msg:  service.ErrImportTooLarge.Error(),
+		})
+	}
+
+	sendInvites := false
+	if value := c.FormValue("send_invites"); value != "" {
+		if sendInvites, err = strconv.ParseBool(value); err != nil {
+			return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+				Code: fiber.StatusBadRequest,
+				Msg:  "send_invites must be a boolean",
+			})
+		}
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Failed to open import file",
+			Data: err.Error(),
+		})
+	}
+	defer src.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(src, service.MaxImportPayload+1))
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Failed to read import file",
+			Data: err.Error(),
+		})
+	}
+
+	job, err := h.importService.CreateImportJob(c.UserContext(), claims.UserID, fileHeader.Filename, c.FormValue("format"), payload, sendInvites)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, service.ErrImportFormat) || errors.Is(err, service.ErrImportEmpty) || errors.Is(err, service.ErrImportTooLarge) {
+			code = fiber.StatusBadRequest
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Fail to queue user import",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "User import queued",
+		Data: job,
+	})
+}
+
+// GetImportJob reports the progress and per-row errors of an import
+func (h *AdminHandler) GetImportJob(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: import job id is required",
+		})
+	}
+
+	job, err := h.importService.GetImportJob(c.UserContext(), uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Import job found",
+		Data: job,
+	})
+}
+
+// ExportUsers streams every user of the tenant as CSV
+func (h *AdminHandler) ExportUsers(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", "attachment; filename=\"users.csv\"")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.importService.ExportUsers(ctx, w); err != nil {
+			logger.Error("User export aborted", zap.Error(err))
+		}
+		if err := w.Flush(); err != nil {
+			logger.Warn("Failed to flush user export", zap.Error(err))
+		}
+	})
+	return nil
+}
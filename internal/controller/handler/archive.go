@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"project-api/internal/core/model/request"
+	"project-api/internal/core/model/response"
+	"project-api/internal/core/service"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const defaultArchiveName = "files"
+
+// DownloadArchive streams the files picked in the body as one ZIP archive.
+// Every file is checked before the response starts; a failure after that
+// cuts the archive short, which clients see as a broken download.
+func (f *FileHeader) DownloadArchive(c *fiber.Ctx) error {
+	var req request.DownloadArchiveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid archive request",
+			Data: err.Error(),
+		})
+	}
+
+	files, err := f.S3service.ArchiveFiles(c, req.FileIDs)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrFileNotFound):
+			code = fiber.StatusNotFound
+		case errors.Is(err, service.ErrFileQuarantined):
+			code = fiber.StatusLocked
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Error: Fail to download files.",
+			Data: err.Error(),
+		})
+	}
+
+	// The archive is written after the handler returns, so it takes the
+	// request's context rather than c
+	ctx := c.UserContext()
+	reader, writer := io.Pipe()
+	go func() {
+		err := f.S3service.WriteArchive(ctx, writer, files)
+		if err != nil {
+			logger.Warn("Archive download cut short", zap.Int("files", len(files)), zap.Error(err))
+		}
+		writer.CloseWithError(err)
+	}()
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	name := strings.TrimSuffix(strings.TrimSpace(req.Name), ".zip")
+	if name == "" {
+		name = defaultArchiveName
+	}
+	c.Set(fiber.HeaderContentDisposition, attachment(name+".zip"))
+	// Closing the reader once the response is done, or the client is gone,
+	// stops the goroutine
+	return c.SendStream(reader)
+}
@@ -1,18 +1,15 @@
 package controller
 
 import (
-	"fmt"
 	"net/http"
 
 	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
 	"project-api/internal/core/model/request"
 	"project-api/internal/core/model/response"
 	In "project-api/internal/core/port/service"
-	"project-api/internal/infra/config"
 	"project-api/internal/infra/logger"
 
-	"github.com/RichardKnop/machinery/v2"
-	"github.com/RichardKnop/machinery/v2/tasks"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -20,14 +17,14 @@ import (
 )
 
 type AuthHandler struct {
-	service In.IUserService
-	server  *machinery.Server
+	service  In.IUserService
+	notifier In.INotificationService
 }
 
-func NewAuthHandler(service In.IUserService, machineryServer *machinery.Server) *AuthHandler {
+func NewAuthHandler(service In.IUserService, notifier In.INotificationService) *AuthHandler {
 	return &AuthHandler{
-		service: service,
-		server:  machineryServer,
+		service:  service,
+		notifier: notifier,
 	}
 }
 
@@ -43,7 +40,7 @@ func (l *AuthHandler) LoginHandle(c *fiber.Ctx) error {
 			Data: err.Error(),
 		})
 	}
-	user, err := l.service.GetUserByName(c.Context(), req.UserName)
+	user, err := l.service.GetUserByName(c.UserContext(), req.UserName)
 	if err != nil {
 		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
 	}
@@ -108,27 +105,21 @@ func (l *AuthHandler) RegisterHandler(c *fiber.Ctx) error {
 			Msg:  "Error to convert to entity",
 		})
 	}
-	if err := l.service.Create(c.Context(), userEntity); err != nil {
+	if err := l.service.Create(c.UserContext(), userEntity); err != nil {
 		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
 			Code: fiber.StatusInternalServerError,
 			Msg:  "Error to create user",
 		})
 	}
-	host := fmt.Sprintf("http://%s:%s", config.Config.Server.Host, config.Config.Server.Port)
-	signature := &tasks.Signature{
-		Name: "send_confirmation_email",
-		Args: []tasks.Arg{
-			{Type: "string", Value: user.Email},
-			{Type: "string", Value: token},
-			{Type: "string", Value: user.FirstName},
-			{Type: "string", Value: host},
-		},
-	}
-	_, err = l.server.SendTask(signature)
+	err = l.notifier.Notify(c.UserContext(), In.Notification{
+		User:     userEntity,
+		Category: entity.NotificationCategoryAccount,
+		Title:    "Welcome! Please confirm your email address",
+		Body:     "Your account has been created. Check your inbox for the confirmation link.",
+		Email:    &In.EmailNotification{Task: "send_confirmation_email", Token: token},
+	})
 	if err != nil {
-		logger.Error("Failed to queue confirmation email task", zap.Error(err))
-	} else {
-		logger.Info("Successfully queued confirmation email task", zap.String("email", user.Email))
+		logger.Error("Failed to notify new user", zap.String("email", user.Email), zap.Error(err))
 	}
 	toEntity, err := user.ToEntity()
 	if err != nil {
@@ -196,22 +187,16 @@ func (h *AuthHandler) ResendConfirmationEmailHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// ส่ง email confirmation ผ่าน Machinery
-	host := fmt.Sprintf("http://%s:%s", config.Config.Server.Host, config.Config.Server.Port)
-	signature := &tasks.Signature{
-		Name: "send_confirmation_email",
-		Args: []tasks.Arg{
-			{Type: "string", Value: user.Email},
-			{Type: "string", Value: user.ConfirmToken},
-			{Type: "string", Value: user.FirstName},
-			{Type: "string", Value: host},
-		},
-	}
-	_, err = h.server.SendTask(signature)
+	// ส่ง email confirmation ผ่าน notification service
+	err = h.notifier.Notify(c.UserContext(), In.Notification{
+		User:     user,
+		Category: entity.NotificationCategoryAccount,
+		Title:    "Confirmation email re-sent",
+		Body:     "A new confirmation link has been sent to your email address.",
+		Email:    &In.EmailNotification{Task: "send_confirmation_email", Token: user.ConfirmToken},
+	})
 	if err != nil {
-		logger.Error("Failed to queue resend confirmation email task", zap.String("email", user.Email), zap.Error(err))
-	} else {
-		logger.Info("Successfully queued resend confirmation email task", zap.String("email", user.Email))
+		logger.Error("Failed to notify resend confirmation", zap.String("email", user.Email), zap.Error(err))
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
@@ -248,22 +233,16 @@ func (h *AuthHandler) ResetPasswordHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// ส่ง email reset password ผ่าน Machinery
-	host := fmt.Sprintf("http://%s:%s", config.Config.Server.Host, config.Config.Server.Port)
-	signature := &tasks.Signature{
-		Name: "send_reset_password_email",
-		Args: []tasks.Arg{
-			{Type: "string", Value: user.Email},
-			{Type: "string", Value: user.ResetPasswordToken},
-			{Type: "string", Value: user.FirstName},
-			{Type: "string", Value: host},
-		},
-	}
-	_, err = h.server.SendTask(signature)
+	// ส่ง email reset password ผ่าน notification service
+	err = h.notifier.Notify(c.UserContext(), In.Notification{
+		User:     user,
+		Category: entity.NotificationCategoryAccount,
+		Title:    "Password reset requested",
+		Body:     "A password reset was requested for your account. If this wasn't you, you can ignore the email we sent.",
+		Email:    &In.EmailNotification{Task: "send_reset_password_email", Token: user.ResetPasswordToken},
+	})
 	if err != nil {
-		logger.Error("Failed to queue reset password email task", zap.String("email", user.Email), zap.Error(err))
-	} else {
-		logger.Info("Successfully queued reset password email task", zap.String("email", user.Email))
+		logger.Error("Failed to notify reset password", zap.String("email", user.Email), zap.Error(err))
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// maxByteRanges is how many ranges one request may ask for before it gets
+// the whole body instead
+const maxByteRanges = 16
+
+var errRangeUnsatisfiable = errors.New("no requested range overlaps the content")
+
+// openRange reads length bytes of content from offset; a negative length
+// reads the whole content
+type openRange func(offset, length int64) (io.ReadCloser, error)
+
+// byteRange is one satisfiable range of a Range header
+type byteRange struct {
+	start, length int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// serveContent answers a download of file with its validators, a 304 when
+// the client's copy is current and a 206 when it asks for ranges. Errors
+// opening the content go through fail.
+func serveContent(c *fiber.Ctx, file *entity.File, open openRange, fail func(error) error) error {
+	etag := entityTag(file)
+	modified := file.UploadedAt.UTC().Truncate(time.Second)
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, modified.Format(http.TimeFormat))
+	if notModified(c, etag, modified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	size := file.FileSize
+	var ranges []byteRange
+	if header := c.Get(fiber.HeaderRange); header != "" && rangeApplies(c, etag, modified) {
+		var err error
+		if ranges, err = parseRange(header, size); err != nil {
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+			return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+		}
+	}
+
+	switch len(ranges) {
+	case 0:
+		body, err := open(0, -1)
+		if err != nil {
+			return fail(err)
+		}
+		c.Set(fiber.HeaderContentType, file.FileType)
+		c.Set(fiber.HeaderContentDisposition, attachment(file.FileName))
+		// fasthttp closes body once it has been sent
+		return c.SendStream(body, int(size))
+	case 1:
+		body, err := open(ranges[0].start, ranges[0].length)
+		if err != nil {
+			return fail(err)
+		}
+		c.Status(fiber.StatusPartialContent)
+		c.Set(fiber.HeaderContentType, file.FileType)
+		c.Set(fiber.HeaderContentRange, ranges[0].contentRange(size))
+		return c.SendStream(body, int(ranges[0].length))
+	}
+	return sendRanges(c, file, ranges, open, fail)
+}
+
+// sendRanges streams several ranges as multipart/byteranges, opening each
+// one as the previous is done. Only the first can still fail with an error
+// response; later failures cut the body short.
+func sendRanges(c *fiber.Ctx, file *entity.File, ranges []byteRange, open openRange, fail func(error) error) error {
+	body, err := open(ranges[0].start, ranges[0].length)
+	if err != nil {
+		return fail(err)
+	}
+
+	reader, writer := io.Pipe()
+	parts := multipart.NewWriter(writer)
+	go func(body io.ReadCloser) {
+		for i, r := range ranges {
+			if i > 0 {
+				var err error
+				if body, err = open(r.start, r.length); err != nil {
+					writer.CloseWithError(err)
+					return
+				}
+			}
+			part, err := parts.CreatePart(textproto.MIMEHeader{
+				fiber.HeaderContentType:  {file.FileType},
+				fiber.HeaderContentRange: {r.contentRange(file.FileSize)},
+			})
+			if err == nil {
+				_, err = io.CopyN(part, body, r.length)
+			}
+			body.Close()
+			if err != nil {
+				logger.Warn("Multi-range download cut short", zap.String("fileID", file.ID.String()), zap.Error(err))
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		writer.CloseWithError(parts.Close())
+	}(body)
+
+	c.Status(fiber.StatusPartialContent)
+	c.Set(fiber.HeaderContentType, "multipart/byteranges; boundary="+parts.Boundary())
+	// Closing the reader once the response is done, or the client is gone,
+	// stops the goroutine
+	return c.SendStream(reader)
+}
+
+// entityTag is the hash of the content, which makes it a strong validator;
+// content stored before hashing gets a weak one from the file version
+func entityTag(file *entity.File) string {
+	if file.ContentHash != "" {
+		return `"` + file.ContentHash + `"`
+	}
+	return fmt.Sprintf(`W/"%s-%d"`, file.ID, file.Version)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// none, the way RFC 9110 orders them
+func notModified(c *fiber.Ctx, etag string, modified time.Time) bool {
+	if ifNoneMatch := c.Get(fiber.HeaderIfNoneMatch); ifNoneMatch != "" {
+		if strings.TrimSpace(ifNoneMatch) == "*" {
+			return true
+		}
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			// If-None-Match compares weakly
+			if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince := c.Get(fiber.HeaderIfModifiedSince); ifModifiedSince != "" {
+		since, err := http.ParseTime(ifModifiedSince)
+		return err == nil && !modified.After(since)
+	}
+	return false
+}
+
+// rangeApplies reports whether If-Range, when sent, still names the current
+// content; a client holding another version gets the whole body
+func rangeApplies(c *fiber.Ctx, etag string, modified time.Time) bool {
+	ifRange := strings.TrimSpace(c.Get(fiber.HeaderIfRange))
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		// If-Range compares strongly, so a weak tag never matches
+		return ifRange == etag && !strings.HasPrefix(etag, "W/")
+	}
+	since, err := http.ParseTime(ifRange)
+	return err == nil && modified.Equal(since)
+}
+
+// parseRange reads a Range header against content of size bytes. A header it
+// can't parse, or that asks for too much, is ignored with a nil result;
+// errRangeUnsatisfiable means none of the ranges overlaps the content.
+func parseRange(header string, size int64) ([]byteRange, error) {
+	unit, spec, ok := strings.Cut(header, "=")
+	if !ok || strings.TrimSpace(unit) != "bytes" {
+		return nil, nil
+	}
+
+	var ranges []byteRange
+	specs, total := 0, int64(0)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		specs++
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, nil
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var r byteRange
+		if first == "" {
+			// A suffix range asks for the last bytes of the content
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, nil
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			r = byteRange{start: size - min(n, size), length: min(n, size)}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, nil
+			}
+			end := size - 1
+			if last != "" {
+				requested, err := strconv.ParseInt(last, 10, 64)
+				if err != nil || requested < start {
+					return nil, nil
+				}
+				end = min(end, requested)
+			}
+			if start >= size {
+				continue
+			}
+			r = byteRange{start: start, length: end - start + 1}
+		}
+		ranges = append(ranges, r)
+		total += r.length
+	}
+
+	switch {
+	case specs == 0:
+		return nil, nil
+	case len(ranges) == 0:
+		return nil, errRangeUnsatisfiable
+	case len(ranges) > maxByteRanges || total > size:
+		return nil, nil
+	}
+	return ranges, nil
+}
+
+// attachment is the Content-Disposition of a download saved as name. Quotes
+// and the like are escaped, and names that aren't plain ASCII are encoded,
+// so no file name can break out of the header.
+func attachment(name string) string {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name})
+	if disposition == "" {
+		return "attachment"
+	}
+	return disposition
+}
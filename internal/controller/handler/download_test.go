@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"project-api/internal/core/entity"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// downloadContent is the body every download test serves
+var downloadContent = []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+
+func newDownloadApp(file *entity.File) *fiber.App {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		open := func(offset, length int64) (io.ReadCloser, error) {
+			end := int64(len(downloadContent))
+			if length >= 0 {
+				end = offset + length
+			}
+			return io.NopCloser(bytes.NewReader(downloadContent[offset:end])), nil
+		}
+		return serveContent(c, file, open, func(err error) error { return err })
+	})
+	return app
+}
+
+func newDownloadFile() *entity.File {
+	return &entity.File{
+		ID:          uuid.New(),
+		FileName:    "content.txt",
+		FileType:    "text/plain",
+		FileSize:    int64(len(downloadContent)),
+		ContentHash: "cafe",
+		UploadedAt:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestServeContent(t *testing.T) {
+	file := newDownloadFile()
+	size := len(downloadContent)
+	lastModified := file.UploadedAt.Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"no range", nil, fiber.StatusOK, string(downloadContent), ""},
+		{"a closed range", map[string]string{"Range": "bytes=2-5"},
+			fiber.StatusPartialContent, "2345", "bytes 2-5/36"},
+		{"a suffix range", map[string]string{"Range": "bytes=-4"},
+			fiber.StatusPartialContent, "wxyz", "bytes 32-35/36"},
+		{"a suffix range longer than the content", map[string]string{"Range": "bytes=-100"},
+			fiber.StatusPartialContent, string(downloadContent), "bytes 0-35/36"},
+		{"an open-ended range", map[string]string{"Range": "bytes=30-"},
+			fiber.StatusPartialContent, "uvwxyz", "bytes 30-35/36"},
+		{"a range past the end", map[string]string{"Range": "bytes=34-100"},
+			fiber.StatusPartialContent, "yz", "bytes 34-35/36"},
+		{"an unsatisfiable range", map[string]string{"Range": "bytes=36-"},
+			fiber.StatusRequestedRangeNotSatisfiable, "", "bytes */36"},
+		{"an empty suffix range", map[string]string{"Range": "bytes=-0"},
+			fiber.StatusRequestedRangeNotSatisfiable, "", "bytes */36"},
+		{"an unparsable range", map[string]string{"Range": "bytes=a-b"},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"another unit", map[string]string{"Range": "items=0-1"},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"too many ranges", map[string]string{"Range": "bytes=" + strings.Repeat("0-0,", maxByteRanges) + "1-1"},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"overlapping ranges asking for more than the content", map[string]string{"Range": "bytes=0-30,10-35"},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"If-Range with the current ETag", map[string]string{"Range": "bytes=0-1", "If-Range": `"cafe"`},
+			fiber.StatusPartialContent, "01", "bytes 0-1/36"},
+		{"If-Range with a stale ETag", map[string]string{"Range": "bytes=0-1", "If-Range": `"beef"`},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"If-Range with a weak ETag", map[string]string{"Range": "bytes=0-1", "If-Range": `W/"cafe"`},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"If-Range with the current date", map[string]string{"Range": "bytes=0-1", "If-Range": lastModified},
+			fiber.StatusPartialContent, "01", "bytes 0-1/36"},
+		{"If-Range with a stale date", map[string]string{"Range": "bytes=0-1", "If-Range": file.UploadedAt.Add(-time.Hour).Format(http.TimeFormat)},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"If-None-Match with the ETag", map[string]string{"If-None-Match": `"beef", "cafe"`},
+			fiber.StatusNotModified, "", ""},
+		{"If-None-Match with a weak ETag", map[string]string{"If-None-Match": `W/"cafe"`},
+			fiber.StatusNotModified, "", ""},
+		{"If-None-Match with any ETag", map[string]string{"If-None-Match": "*"},
+			fiber.StatusNotModified, "", ""},
+		{"If-None-Match with a stale ETag", map[string]string{"If-None-Match": `"beef"`},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"If-Modified-Since the upload", map[string]string{"If-Modified-Since": lastModified},
+			fiber.StatusNotModified, "", ""},
+		{"If-Modified-Since before the upload", map[string]string{"If-Modified-Since": file.UploadedAt.Add(-time.Second).Format(http.TimeFormat)},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"If-None-Match ahead of If-Modified-Since", map[string]string{"If-None-Match": `"beef"`, "If-Modified-Since": lastModified},
+			fiber.StatusOK, string(downloadContent), ""},
+		{"a range on a current copy", map[string]string{"Range": "bytes=0-1", "If-None-Match": `"cafe"`},
+			fiber.StatusNotModified, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			resp, err := newDownloadApp(file).Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status < 300 && string(body) != tt.body {
+				t.Fatalf("body = %q, want %q", body, tt.body)
+			}
+			if got := resp.Header.Get(fiber.HeaderContentRange); got != tt.contentRange {
+				t.Fatalf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+			if got := resp.Header.Get(fiber.HeaderETag); got != `"cafe"` {
+				t.Fatalf("ETag = %q", got)
+			}
+			if tt.status == fiber.StatusOK && resp.ContentLength != int64(size) {
+				t.Fatalf("Content-Length = %d, want %d", resp.ContentLength, size)
+			}
+		})
+	}
+}
+
+func TestServeContentMultipleRanges(t *testing.T) {
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderRange, "bytes=0-1, -2")
+	resp, err := newDownloadApp(newDownloadFile()).Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", resp.StatusCode)
+	}
+	kind, params, err := mime.ParseMediaType(resp.Header.Get(fiber.HeaderContentType))
+	if err != nil || kind != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q, %v", resp.Header.Get(fiber.HeaderContentType), err)
+	}
+
+	want := []struct{ contentRange, body string }{
+		{"bytes 0-1/36", "01"},
+		{"bytes 34-35/36", "yz"},
+	}
+	parts := multipart.NewReader(resp.Body, params["boundary"])
+	for _, w := range want {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := part.Header.Get(fiber.HeaderContentRange); got != w.contentRange || string(body) != w.body {
+			t.Fatalf("part %q %q, want %q %q", got, body, w.contentRange, w.body)
+		}
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Fatalf("got %v after the last part, want EOF", err)
+	}
+}
+
+func TestAttachmentKeepsFileNameInsideHeader(t *testing.T) {
+	for _, name := range []string{
+		"report.pdf",
+		`a "quoted" name.txt`,
+		`back\\slash.txt`,
+		"line\r\nSet-Cookie: a=b",
+		"ไฟล์.txt",
+	} {
+		disposition := attachment(name)
+		if strings.ContainsAny(disposition, "\r\n") {
+			t.Fatalf("attachment(%q) = %q holds a line break", name, disposition)
+		}
+		kind, params, err := mime.ParseMediaType(disposition)
+		if err != nil {
+			t.Fatalf("attachment(%q) = %q does not parse: %v", name, disposition, err)
+		}
+		if kind != "attachment" || params["filename"] != name {
+			t.Fatalf("attachment(%q) = %q parses as %q %q", name, disposition, kind, params["filename"])
+		}
+	}
+}
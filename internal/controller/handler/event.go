@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/model/response"
+	"project-api/internal/core/port/repository"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// eventKeepAlive keeps idle streams open through proxies that close silent connections
+const eventKeepAlive = 25 * time.Second
+
+type EventHandler struct {
+	events repository.IEventBus
+}
+
+func NewEventHandler(events repository.IEventBus) *EventHandler {
+	return &EventHandler{events: events}
+}
+
+// Ticket issues a short-lived ticket that opens the caller's event stream,
+// for clients such as EventSource that can't send the Authorization header
+func (h *EventHandler) Ticket(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	ticket, expiresAt, err := utils.GenerateStreamTicket(claims)
+	if err != nil {
+		logger.Error("Failed to sign stream ticket", zap.Uint("userID", claims.UserID), zap.Error(err))
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to issue stream ticket",
+			Data: err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Stream ticket issued",
+		Data: response.StreamTicket{Ticket: ticket, ExpiresAt: expiresAt.Time},
+	})
+}
+
+// Stream pushes the authenticated user's events as Server-Sent Events
+func (h *EventHandler) Stream(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	events, cancel, err := h.events.Subscribe(c.UserContext(), claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusServiceUnavailable,
+			Msg:  "Fail to subscribe to events",
+			Data: err.Error(),
+		})
+	}
+
+	userID := claims.UserID
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		logger.Info("Event stream opened", zap.Uint("userID", userID))
+
+		ticker := time.NewTicker(eventKeepAlive)
+		defer ticker.Stop()
+
+		fmt.Fprint(w, "retry: 5000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				logger.Info("Event stream closed", zap.Uint("userID", userID), zap.Error(err))
+				return
+			}
+		}
+	})
+	return nil
+}
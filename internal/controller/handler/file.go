@@ -1,13 +1,20 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"time"
 
+	"project-api/internal/core/model/request"
 	"project-api/internal/core/model/response"
+	"project-api/internal/core/port/repository"
 	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type FileHeader struct {
@@ -19,6 +26,8 @@ func NewFileHandler(userService In.IUserService, s3Service In.IS3Service) *FileH
 	return &FileHeader{UserService: userService, S3service: s3Service}
 }
 
+// UploadFile stores the files of the multipart "files" field; the optional
+// "expires_in" field, in seconds, has them deleted once it has passed
 func (f *FileHeader) UploadFile(c *fiber.Ctx) error {
 	var expirt time.Duration = 0
 
@@ -32,6 +41,17 @@ func (f *FileHeader) UploadFile(c *fiber.Ctx) error {
 		})
 	}
 
+	if values := form.Value["expires_in"]; len(values) > 0 && values[0] != "" {
+		seconds, err := strconv.Atoi(values[0])
+		if err != nil {
+			return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+				Code: fiber.StatusBadRequest,
+				Msg:  "Error: expires_in must be a number of seconds",
+			})
+		}
+		expirt = time.Duration(seconds) * time.Second
+	}
+
 	files := form.File["files"]
 	if len(files) == 0 {
 		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
@@ -44,8 +64,17 @@ func (f *FileHeader) UploadFile(c *fiber.Ctx) error {
 	// สมมติว่า S3Service.UploadFile รับ []*multipart.FileHeader และคืน []string
 	fileURLs, err := f.S3service.UploadFile(c, files, &expirt)
 	if err != nil {
+		code := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrFileTooLarge), errors.Is(err, service.ErrQuotaExceeded):
+			code = fiber.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrFileExpiry):
+			code = fiber.StatusBadRequest
+		case errors.Is(err, service.ErrFileType), errors.Is(err, service.ErrFileTypeMismatch):
+			code = fiber.StatusUnsupportedMediaType
+		}
 		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
-			Code: fiber.StatusInternalServerError,
+			Code: code,
 			Msg:  "Fail to UploadFile",
 			Data: err.Error(),
 		})
@@ -59,7 +88,7 @@ func (f *FileHeader) UploadFile(c *fiber.Ctx) error {
 
 func (f *FileHeader) DeleteFile(c *fiber.Ctx) error {
 	key := c.Params("key")
-	if key != "" {
+	if key == "" {
 		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
 			Code: fiber.StatusBadRequest,
 			Msg:  "Failed to delete file, key is required",
@@ -67,14 +96,43 @@ func (f *FileHeader) DeleteFile(c *fiber.Ctx) error {
 	}
 
 	if err := f.S3service.DeleteFile(c, key); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(response.ErrorResponse{
-			Code: fiber.StatusInternalServerError,
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, service.ErrFileForbidden) {
+			code = fiber.StatusForbidden
+		}
+		return c.Status(code).JSON(response.ErrorResponse{
+			Code: code,
 			Msg:  "Fail to delete file.",
 			Data: err.Error(),
 		})
 	}
 	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
-		Msg: "Successfully deleted file.",
+		Msg: "Successfully moved file to trash.",
+	})
+}
+
+// RestoreFile takes one of the caller's files out of the trash
+func (f *FileHeader) RestoreFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	file, err := f.S3service.RestoreFile(c, id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotFound) {
+			return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to restore file",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File restored",
+		Data: response.NewFileItem(file),
 	})
 }
 
@@ -87,21 +145,396 @@ func (f *FileHeader) DownloadFile(c *fiber.Ctx) error {
 		})
 	}
 
-	// Download the file using S3service
-	data, file, err := f.S3service.DownloadFile(c, key)
+	fail := func(err error) error {
+		code := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrFileForbidden):
+			code = fiber.StatusForbidden
+		case errors.Is(err, service.ErrFileNotFound):
+			code = fiber.StatusNotFound
+		case errors.Is(err, service.ErrFileQuarantined):
+			code = fiber.StatusLocked
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Error: Fail to download file.",
+			Data: err.Error(),
+		})
+	}
+
+	file, err := f.S3service.StatDownload(c, key)
+	if err != nil {
+		return fail(err)
+	}
+
+	// The ranges may be read after the handler returns, so they take the
+	// request's context rather than c
+	ctx := c.UserContext()
+	return serveContent(c, file, func(offset, length int64) (io.ReadCloser, error) {
+		return f.S3service.OpenRange(ctx, file, offset, length)
+	}, fail)
+}
+
+// PresignUpload signs a request that uploads one file straight to S3; the
+// client confirms it with CompleteUpload afterwards
+func (f *FileHeader) PresignUpload(c *fiber.Ctx) error {
+	var req request.PresignUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid upload request",
+			Data: err.Error(),
+		})
+	}
+
+	upload, presigned, err := f.S3service.PresignUpload(c, &req)
 	if err != nil {
+		code := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrFileTooLarge) || errors.Is(err, service.ErrChecksumFormat):
+			code = fiber.StatusBadRequest
+		case errors.Is(err, service.ErrQuotaExceeded):
+			code = fiber.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrFileType), errors.Is(err, service.ErrFileTypeMismatch):
+			code = fiber.StatusUnsupportedMediaType
+		case errors.Is(err, service.ErrPresignDisabled):
+			code = fiber.StatusNotImplemented
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Fail to presign upload",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg: "Upload presigned",
+		Data: response.PresignedUpload{
+			UploadID:         upload.ID,
+			PresignedRequest: presigned,
+		},
+	})
+}
+
+// CompleteUpload verifies a presigned upload and returns the saved file
+func (f *FileHeader) CompleteUpload(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: upload id is required",
+		})
+	}
+
+	file, err := f.S3service.CompleteUpload(c, uint(id))
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrUploadNotFound):
+			code = fiber.StatusNotFound
+		case errors.Is(err, service.ErrUploadState), errors.Is(err, service.ErrUploadIncomplete):
+			code = fiber.StatusConflict
+		case errors.Is(err, service.ErrUploadExpired), errors.Is(err, service.ErrUploadMismatch):
+			code = fiber.StatusUnprocessableEntity
+		case errors.Is(err, service.ErrQuotaExceeded), errors.Is(err, service.ErrFileTooLarge):
+			code = fiber.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrFileType), errors.Is(err, service.ErrFileTypeMismatch):
+			code = fiber.StatusUnsupportedMediaType
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Fail to complete upload",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Upload completed",
+		Data: response.NewFileItem(file),
+	})
+}
+
+// PresignDownload returns a URL that downloads the file straight from S3;
+// the optional ttl query is in seconds
+func (f *FileHeader) PresignDownload(c *fiber.Ctx) error {
+	key := c.Params("key")
+	if key == "" {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: File key is required",
+		})
+	}
+	ttl := c.QueryInt("ttl", 0)
+	if ttl < 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: ttl must be a positive number of seconds",
+		})
+	}
+
+	presigned, err := f.S3service.PresignDownload(c, key, time.Duration(ttl)*time.Second)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrFileQuarantined):
+			code = fiber.StatusLocked
+		case errors.Is(err, service.ErrPresignDisabled):
+			code = fiber.StatusNotImplemented
+		case errors.Is(err, service.ErrPresignEncrypted):
+			code = fiber.StatusConflict
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Error: Fail to presign download.",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Download presigned",
+		Data: presigned,
+	})
+}
+
+// ListFiles pages through the caller's files. Query parameters: cursor,
+// limit, sort (created_at, name, size), order (asc, desc), q, type (a MIME
+// type or a prefix like "image/"), tag, min_size, max_size, from and to
+// (RFC 3339 or YYYY-MM-DD; to is exclusive).
+func (f *FileHeader) ListFiles(c *fiber.Ctx) error {
+	return f.listFiles(c, false, false)
+}
+
+// ListSharedFiles pages through the files other users shared with the caller;
+// it takes the same query parameters as ListFiles
+func (f *FileHeader) ListSharedFiles(c *fiber.Ctx) error {
+	return f.listFiles(c, true, false)
+}
+
+// ListTrash pages through the caller's deleted files that can still be
+// restored; it takes the same query parameters as ListFiles
+func (f *FileHeader) ListTrash(c *fiber.Ctx) error {
+	return f.listFiles(c, false, true)
+}
+
+func (f *FileHeader) listFiles(c *fiber.Ctx, sharedWith, trashed bool) error {
+	query := repository.FileListQuery{
+		Cursor:  c.Query("cursor"),
+		Limit:   c.QueryInt("limit"),
+		Sort:    c.Query("sort"),
+		Desc:    c.Query("order", "desc") != "asc",
+		Name:    c.Query("q"),
+		Type:    c.Query("type"),
+		Tag:     c.Query("tag"),
+		MinSize: int64(c.QueryInt("min_size")),
+		MaxSize: int64(c.QueryInt("max_size")),
+	}
+	query.SharedWith = sharedWith
+	query.Trashed = trashed
+
+	var err error
+	if query.From, err = parseDateQuery(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: from must be RFC 3339 or YYYY-MM-DD",
+		})
+	}
+	if query.To, err = parseDateQuery(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: to must be RFC 3339 or YYYY-MM-DD",
+		})
+	}
+
+	result, err := f.S3service.ListFiles(c, query)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, repository.ErrInvalidCursor) || errors.Is(err, repository.ErrInvalidSort) {
+			code = fiber.StatusBadRequest
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Error to list files",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Files found successfully",
+		Data: response.NewFilePage(result),
+	})
+}
+
+// GetFile returns the metadata of one of the caller's files
+func (f *FileHeader) GetFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	file, err := f.S3service.GetFile(c, id)
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File found",
+		Data: response.NewFileItem(file),
+	})
+}
+
+// UpdateFileTags replaces the tags of one of the caller's files
+func (f *FileHeader) UpdateFileTags(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	var req request.UpdateFileTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+
+	file, err := f.S3service.UpdateFileTags(c, id, req.Tags)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFileNotFound):
+			return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+		case errors.Is(err, service.ErrFileTags):
+			return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+				Code: fiber.StatusBadRequest,
+				Msg:  "Fail to update file tags",
+				Data: err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
 			Code: fiber.StatusInternalServerError,
-			Msg:  "Error: Fail to download file.",
+			Msg:  "Fail to update file tags",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File tags updated",
+		Data: response.NewFileItem(file),
+	})
+}
+
+// GrantPermission gives a user, or with "org" every user of the tenant, read
+// or write access to one of the caller's files
+func (f *FileHeader) GrantPermission(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	var req request.GrantFilePermissionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid file permission",
+			Data: err.Error(),
+		})
+	}
+
+	permission, err := f.S3service.GrantPermission(c, id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFileNotFound):
+			return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+		case errors.Is(err, service.ErrGranteeNotFound):
+			return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+				Code: fiber.StatusNotFound,
+				Msg:  "User not found",
+				Data: err.Error(),
+			})
+		case errors.Is(err, service.ErrFileGrant):
+			return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+				Code: fiber.StatusBadRequest,
+				Msg:  "Invalid file permission",
+				Data: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to grant file permission",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File permission granted",
+		Data: response.NewFilePermissionItem(permission),
+	})
+}
+
+func (f *FileHeader) ListPermissions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	permissions, err := f.S3service.ListPermissions(c, id)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotFound) {
+			return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to list file permissions",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File permissions found",
+		Data: response.NewFilePermissionList(permissions),
+	})
+}
+
+func (f *FileHeader) RevokePermission(c *fiber.Ctx) error {
+	id := c.Params("id")
+	permissionID, err := c.ParamsInt("permissionId")
+	if _, perr := uuid.Parse(id); perr != nil || err != nil || permissionID <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	if err := f.S3service.RevokePermission(c, id, uint(permissionID)); err != nil {
+		if errors.Is(err, service.ErrFileNotFound) {
+			return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to revoke file permission",
 			Data: err.Error(),
 		})
 	}
 
-	// Set appropriate headers for file download
-	c.Set("Content-Type", file.FileType)
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", file.FileName))
-	c.Set("Content-Length", fmt.Sprintf("%d", file.FileSize))
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg: "File permission revoked",
+	})
+}
 
-	// Send the file data
-	return c.Send(data)
+// parseDateQuery accepts RFC 3339 or a plain date; a plain date used as an
+// exclusive upper bound covers the whole day
+func parseDateQuery(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
 }
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// trashService keeps files in memory and moves them to the trash on delete;
+// every other method of the interface is left unimplemented
+type trashService struct {
+	In.IS3Service
+	files map[string]*entity.File
+}
+
+func (s *trashService) DeleteFile(c *fiber.Ctx, key string) error {
+	file, ok := s.files[key]
+	if !ok {
+		return service.ErrFileNotFound
+	}
+	now := time.Now()
+	file.IsDeleted = true
+	file.TrashedAt = &now
+	return nil
+}
+
+func (s *trashService) ListFiles(c *fiber.Ctx, query repository.FileListQuery) (*repository.FileListResult, error) {
+	result := &repository.FileListResult{}
+	for _, file := range s.files {
+		if file.IsDeleted == query.Trashed {
+			result.Files = append(result.Files, *file)
+		}
+	}
+	return result, nil
+}
+
+type listResponse struct {
+	Msg  string `json:"msg"`
+	Data struct {
+		Items []struct {
+			ID        string     `json:"id"`
+			TrashedAt *time.Time `json:"trashed_at"`
+		} `json:"items"`
+	} `json:"data"`
+}
+
+func TestDeleteFileMovesItToTrash(t *testing.T) {
+	file := &entity.File{ID: uuid.New(), FileName: "report.pdf", FileType: "application/pdf", FileSize: 10, Version: 1}
+	svc := &trashService{files: map[string]*entity.File{file.ID.String(): file}}
+	handler := NewFileHandler(nil, svc)
+
+	app := fiber.New()
+	app.Delete("/files/delete/:key", handler.DeleteFile)
+	app.Get("/files/trash", handler.ListTrash)
+	app.Get("/files", handler.ListFiles)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodDelete, "/files/delete/"+file.ID.String(), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deleted struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&deleted); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK || deleted.Code != 0 {
+		t.Fatalf("delete: status %d, body %+v", resp.StatusCode, deleted)
+	}
+
+	list := func(path string) listResponse {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body listResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	trash := list("/files/trash")
+	if len(trash.Data.Items) != 1 || trash.Data.Items[0].ID != file.ID.String() || trash.Data.Items[0].TrashedAt == nil {
+		t.Fatalf("trash should hold the deleted file, got %+v", trash)
+	}
+	if files := list("/files"); len(files.Data.Items) != 0 {
+		t.Fatalf("deleted file still listed: %+v", files)
+	}
+}
+
+func TestDeleteFileRequiresKey(t *testing.T) {
+	handler := NewFileHandler(nil, &trashService{files: map[string]*entity.File{}})
+	app := fiber.New()
+	app.Delete("/files/delete/:key?", handler.DeleteFile)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodDelete, "/files/delete/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Code int `json:"code"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != fiber.StatusBadRequest {
+		t.Fatalf("expected a bad request, got %+v", body)
+	}
+}
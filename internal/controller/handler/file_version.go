@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"project-api/internal/core/model/response"
+	"project-api/internal/core/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UploadVersion replaces the content of a file with the multipart "file" field;
+// the previous content stays available as an earlier version
+func (f *FileHeader) UploadVersion(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Requires a file in 'file' field",
+			Data: err.Error(),
+		})
+	}
+
+	file, err := f.S3service.UploadVersion(c, id, header)
+	if err != nil {
+		return versionError(c, "Fail to upload file version", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  fmt.Sprintf("Uploaded version %d", file.Version),
+		Data: response.NewFileItem(file),
+	})
+}
+
+// ListVersions returns the current version of a file followed by its history
+func (f *FileHeader) ListVersions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	file, history, err := f.S3service.ListVersions(c, id)
+	if err != nil {
+		return versionError(c, "Fail to list file versions", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File versions found",
+		Data: response.NewVersionList(file, history),
+	})
+}
+
+func (f *FileHeader) DownloadVersion(c *fiber.Ctx) error {
+	id := c.Params("id")
+	version, err := c.ParamsInt("version")
+	if _, perr := uuid.Parse(id); perr != nil || err != nil || version <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	body, file, err := f.S3service.DownloadVersion(c, id, version)
+	if err != nil {
+		return versionError(c, "Error: Fail to download file version.", err)
+	}
+
+	c.Set("Content-Type", file.FileType)
+	c.Set("Content-Disposition", attachment(file.FileName))
+	return c.SendStream(body, int(file.FileSize))
+}
+
+// RestoreVersion makes an earlier version the current one again
+func (f *FileHeader) RestoreVersion(c *fiber.Ctx) error {
+	id := c.Params("id")
+	version, err := c.ParamsInt("version")
+	if _, perr := uuid.Parse(id); perr != nil || err != nil || version <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	file, err := f.S3service.RestoreVersion(c, id, version)
+	if err != nil {
+		return versionError(c, "Fail to restore file version", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  fmt.Sprintf("Restored version %d as version %d", version, file.Version),
+		Data: response.NewFileItem(file),
+	})
+}
+
+func versionError(c *fiber.Ctx, msg string, err error) error {
+	code := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrFileNotFound), errors.Is(err, service.ErrVersionNotFound):
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	case errors.Is(err, service.ErrFileTooLarge), errors.Is(err, service.ErrQuotaExceeded):
+		code = fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrFileType), errors.Is(err, service.ErrFileTypeMismatch):
+		code = fiber.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrFileQuarantined), errors.Is(err, service.ErrVersionUnscanned):
+		code = fiber.StatusLocked
+	}
+	return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+		Code: code,
+		Msg:  msg,
+		Data: err.Error(),
+	})
+}
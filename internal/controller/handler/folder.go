@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"errors"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/model/request"
+	"project-api/internal/core/model/response"
+	"project-api/internal/core/port/repository"
+	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FolderHandler struct {
+	service In.IFolderService
+}
+
+func NewFolderHandler(service In.IFolderService) *FolderHandler {
+	return &FolderHandler{service: service}
+}
+
+// ListPath lists the folder at the path query parameter, the root by default.
+// Files are paged with cursor, limit, sort (created_at, name, size) and order.
+func (h *FolderHandler) ListPath(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	contents, err := h.service.ListPath(c.UserContext(), claims.UserID, c.Query("path"), folderFileQuery(c))
+	if err != nil {
+		return folderError(c, "Fail to list folder", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Folder found",
+		Data: response.NewFolderContents(contents),
+	})
+}
+
+// ListFolder lists a folder by id with the same paging as ListPath
+func (h *FolderHandler) ListFolder(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	contents, err := h.service.ListFolder(c.UserContext(), claims.UserID, id, folderFileQuery(c))
+	if err != nil {
+		return folderError(c, "Fail to list folder", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Folder found",
+		Data: response.NewFolderContents(contents),
+	})
+}
+
+func (h *FolderHandler) CreateFolder(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	var req request.CreateFolderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid folder",
+			Data: err.Error(),
+		})
+	}
+
+	folder, err := h.service.CreateFolder(c.UserContext(), claims.UserID, req.Name, parseFolderID(req.ParentID))
+	if err != nil {
+		return folderError(c, "Fail to create folder", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Folder created",
+		Data: response.NewFolderItem(folder),
+	})
+}
+
+func (h *FolderHandler) RenameFolder(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	var req request.RenameRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid folder name",
+			Data: err.Error(),
+		})
+	}
+
+	folder, err := h.service.RenameFolder(c.UserContext(), claims.UserID, id, req.Name)
+	if err != nil {
+		return folderError(c, "Fail to rename folder", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Folder renamed",
+		Data: response.NewFolderItem(folder),
+	})
+}
+
+func (h *FolderHandler) MoveFolder(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	var req request.MoveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid destination folder",
+			Data: err.Error(),
+		})
+	}
+
+	folder, err := h.service.MoveFolder(c.UserContext(), claims.UserID, id, parseFolderID(req.FolderID))
+	if err != nil {
+		return folderError(c, "Fail to move folder", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Folder moved",
+		Data: response.NewFolderItem(folder),
+	})
+}
+
+// DeleteFolder deletes a folder with everything below it
+func (h *FolderHandler) DeleteFolder(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	if err := h.service.DeleteFolder(c.UserContext(), claims.UserID, id); err != nil {
+		return folderError(c, "Fail to delete folder", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg: "Folder deleted",
+	})
+}
+
+func (h *FolderHandler) RenameFile(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	var req request.RenameRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid file name",
+			Data: err.Error(),
+		})
+	}
+
+	file, err := h.service.RenameFile(c.UserContext(), claims.UserID, id, req.Name)
+	if err != nil {
+		return folderError(c, "Fail to rename file", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File renamed",
+		Data: response.NewFileItem(file),
+	})
+}
+
+func (h *FolderHandler) MoveFile(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	var req request.MoveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid destination folder",
+			Data: err.Error(),
+		})
+	}
+
+	file, err := h.service.MoveFile(c.UserContext(), claims.UserID, id, parseFolderID(req.FolderID))
+	if err != nil {
+		return folderError(c, "Fail to move file", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "File moved",
+		Data: response.NewFileItem(file),
+	})
+}
+
+// folderFileQuery reads how the files of a folder are paged
+func folderFileQuery(c *fiber.Ctx) repository.FileListQuery {
+	return repository.FileListQuery{
+		Cursor: c.Query("cursor"),
+		Limit:  c.QueryInt("limit"),
+		Sort:   c.Query("sort"),
+		Desc:   c.Query("order", "asc") == "desc",
+	}
+}
+
+// parseFolderID turns an already validated optional uuid into a folder id,
+// nil meaning the root
+func parseFolderID(value *string) *uuid.UUID {
+	if value == nil {
+		return nil
+	}
+	id := uuid.MustParse(*value)
+	return &id
+}
+
+func folderError(c *fiber.Ctx, msg string, err error) error {
+	code := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrFolderNotFound), errors.Is(err, service.ErrFileNotFound):
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	case errors.Is(err, service.ErrFolderName), errors.Is(err, repository.ErrInvalidCursor), errors.Is(err, repository.ErrInvalidSort):
+		code = fiber.StatusBadRequest
+	case errors.Is(err, service.ErrFolderExists), errors.Is(err, service.ErrFolderCycle):
+		code = fiber.StatusConflict
+	}
+	return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+		Code: code,
+		Msg:  msg,
+		Data: err.Error(),
+	})
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"errors"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/response"
+	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationHandler struct {
+	service In.INotificationService
+}
+
+func NewNotificationHandler(service In.INotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// ListNotifications returns the inbox newest first; ?before=<id> pages to older entries
+func (h *NotificationHandler) ListNotifications(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	notifications, err := h.service.ListNotifications(c.UserContext(), claims.UserID, c.QueryBool("unread"), uint(c.QueryInt("before")), c.QueryInt("limit"))
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to list notifications",
+			Data: err.Error(),
+		})
+	}
+	if notifications == nil {
+		notifications = []entity.Notification{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Notifications found successfully",
+		Data: notifications,
+	})
+}
+
+func (h *NotificationHandler) UnreadCount(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	count, err := h.service.CountUnread(c.UserContext(), claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to count notifications",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Unread notifications counted",
+		Data: fiber.Map{"unread": count},
+	})
+}
+
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: notification id is required",
+		})
+	}
+
+	if err := h.service.MarkRead(c.UserContext(), claims.UserID, uint(id)); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg: "Notification marked as read",
+	})
+}
+
+func (h *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	count, err := h.service.MarkAllRead(c.UserContext(), claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to mark notifications as read",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Notifications marked as read",
+		Data: fiber.Map{"updated": count},
+	})
+}
+
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	preferences, err := h.service.GetPreferences(c.UserContext(), claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to load notification preferences",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Notification preferences found",
+		Data: preferences,
+	})
+}
+
+// UpdatePreferences takes a map of category to channels, e.g. {"file": {"email": true, "in_app": false}}
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+
+	var req map[string]entity.NotificationChannels
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+
+	preferences, err := h.service.UpdatePreferences(c.UserContext(), claims.UserID, req)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, service.ErrNotificationCategory) {
+			code = fiber.StatusBadRequest
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Fail to update notification preferences",
+			Data: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Notification preferences updated",
+		Data: preferences,
+	})
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"errors"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+	"project-api/internal/core/model/response"
+	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type QuotaHandler struct {
+	service In.IQuotaService
+}
+
+func NewQuotaHandler(service In.IQuotaService) *QuotaHandler {
+	return &QuotaHandler{service: service}
+}
+
+// GetUsage returns what the caller stores and the limits that apply to them
+func (h *QuotaHandler) GetUsage(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	return h.usage(c, claims.UserID)
+}
+
+// GetOrgUsage returns what the whole tenant stores
+func (h *QuotaHandler) GetOrgUsage(c *fiber.Ctx) error {
+	return h.usage(c, entity.OrgUsageUserID)
+}
+
+func (h *QuotaHandler) usage(c *fiber.Ctx, userID uint) error {
+	usage, err := h.service.GetUsage(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to load storage usage",
+			Data: err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Storage usage found",
+		Data: usage,
+	})
+}
+
+func (h *QuotaHandler) ListPlans(c *fiber.Ctx) error {
+	plans, err := h.service.ListPlans(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to list quota plans",
+			Data: err.Error(),
+		})
+	}
+	if plans == nil {
+		plans = []entity.QuotaPlan{}
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Quota plans found",
+		Data: plans,
+	})
+}
+
+func (h *QuotaHandler) CreatePlan(c *fiber.Ctx) error {
+	var req request.CreateQuotaPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid quota plan",
+			Data: err.Error(),
+		})
+	}
+
+	plan := &entity.QuotaPlan{
+		Name:        req.Name,
+		QuotaLimits: entity.QuotaLimits{MaxBytes: req.MaxBytes, MaxFiles: req.MaxFiles},
+	}
+	if err := h.service.CreatePlan(c.UserContext(), plan); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to create quota plan",
+			Data: err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Quota plan created",
+		Data: plan,
+	})
+}
+
+// AssignUserPlan sets the quota plan of the user in the id parameter
+func (h *QuotaHandler) AssignUserPlan(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Error: user id is required",
+		})
+	}
+	return h.assignPlan(c, uint(id))
+}
+
+// AssignOrgPlan sets the quota plan of the whole tenant
+func (h *QuotaHandler) AssignOrgPlan(c *fiber.Ctx) error {
+	return h.assignPlan(c, entity.OrgUsageUserID)
+}
+
+func (h *QuotaHandler) assignPlan(c *fiber.Ctx, userID uint) error {
+	var req request.AssignQuotaPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+	}
+
+	if err := h.service.AssignPlan(c.UserContext(), userID, req.PlanID); err != nil {
+		if errors.Is(err, service.ErrQuotaPlan) {
+			return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+		}
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to assign quota plan",
+			Data: err.Error(),
+		})
+	}
+	return h.usage(c, userID)
+}
+
+// Reconcile recomputes the tenant's usage from its files right away, without
+// waiting for the scheduled job
+func (h *QuotaHandler) Reconcile(c *fiber.Ctx) error {
+	if err := h.service.Reconcile(c.UserContext()); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusInternalServerError,
+			Msg:  "Fail to reconcile storage usage",
+			Data: err.Error(),
+		})
+	}
+	return h.usage(c, entity.OrgUsageUserID)
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/model/request"
+	"project-api/internal/core/model/response"
+	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// sharePasswordHeader carries the password of a protected link on GET requests
+const sharePasswordHeader = "X-Share-Password"
+
+type ShareHandler struct {
+	service In.IShareService
+}
+
+func NewShareHandler(service In.IShareService) *ShareHandler {
+	return &ShareHandler{service: service}
+}
+
+// CreateLink creates a public link to one of the caller's files
+func (h *ShareHandler) CreateLink(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	fileID := c.Params("id")
+	if _, err := uuid.Parse(fileID); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	var req request.CreateShareLinkRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusOK).JSON(response.ErrParser)
+		}
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+			Code: fiber.StatusBadRequest,
+			Msg:  "Invalid share link",
+			Data: err.Error(),
+		})
+	}
+
+	link, token, err := h.service.CreateLink(c.UserContext(), claims.UserID, fileID, &req)
+	if err != nil {
+		return shareError(c, "Fail to create share link", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg: "Share link created",
+		Data: response.CreatedShareLink{
+			ShareLinkItem: response.NewShareLinkItem(link),
+			Token:         token,
+			URL:           fmt.Sprintf("%s/api/v1/share/%s", c.BaseURL(), token),
+		},
+	})
+}
+
+// ListLinks returns every link of a file, revoked ones included
+func (h *ShareHandler) ListLinks(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	fileID := c.Params("id")
+	if _, err := uuid.Parse(fileID); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	links, err := h.service.ListLinks(c.UserContext(), claims.UserID, fileID)
+	if err != nil {
+		return shareError(c, "Fail to list share links", err)
+	}
+
+	items := make([]response.ShareLinkItem, 0, len(links))
+	for i := range links {
+		items = append(items, response.NewShareLinkItem(&links[i]))
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Share links found",
+		Data: items,
+	})
+}
+
+func (h *ShareHandler) RevokeLink(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	fileID := c.Params("id")
+	linkID, err := c.ParamsInt("linkId")
+	if _, perr := uuid.Parse(fileID); perr != nil || err != nil || linkID <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	if err := h.service.RevokeLink(c.UserContext(), claims.UserID, fileID, uint(linkID)); err != nil {
+		return shareError(c, "Fail to revoke share link", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg: "Share link revoked",
+	})
+}
+
+// ListAccess returns the latest download attempts through a link
+func (h *ShareHandler) ListAccess(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(response.ErrAuth)
+	}
+	fileID := c.Params("id")
+	linkID, err := c.ParamsInt("linkId")
+	if _, perr := uuid.Parse(fileID); perr != nil || err != nil || linkID <= 0 {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	accesses, err := h.service.ListAccess(c.UserContext(), claims.UserID, fileID, uint(linkID))
+	if err != nil {
+		return shareError(c, "Fail to list share link access", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Share link access found",
+		Data: response.NewShareLinkAccessList(accesses),
+	})
+}
+
+// Download serves a shared file without authentication. The password of a
+// protected link comes in the X-Share-Password header or a "password" form field.
+func (h *ShareHandler) Download(c *fiber.Ctx) error {
+	password := c.Get(sharePasswordHeader)
+	if password == "" {
+		password = c.FormValue("password")
+	}
+
+	body, file, err := h.service.Download(c.UserContext(), c.Params("token"), password, In.ShareClient{
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrShareNotFound):
+			code = fiber.StatusNotFound
+		case errors.Is(err, service.ErrShareExpired):
+			code = fiber.StatusGone
+		case errors.Is(err, service.ErrSharePassword):
+			code = fiber.StatusUnauthorized
+		case errors.Is(err, service.ErrShareThrottled):
+			code = fiber.StatusTooManyRequests
+		case errors.Is(err, service.ErrFileQuarantined):
+			code = fiber.StatusLocked
+		}
+		return c.Status(code).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Error: Fail to download shared file.",
+			Data: err.Error(),
+		})
+	}
+
+	c.Set("Content-Type", file.FileType)
+	c.Set("Content-Disposition", attachment(file.FileName))
+	return c.SendStream(body, int(file.FileSize))
+}
+
+func shareError(c *fiber.Ctx, msg string, err error) error {
+	if errors.Is(err, service.ErrFileNotFound) || errors.Is(err, service.ErrShareNotFound) {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.ErrorResponse{
+		Code: fiber.StatusInternalServerError,
+		Msg:  msg,
+		Data: err.Error(),
+	})
+}
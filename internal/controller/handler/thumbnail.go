@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"errors"
+
+	"project-api/internal/core/model/response"
+	"project-api/internal/core/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DownloadThumbnail streams one of the thumbnails listed on a file
+func (f *FileHeader) DownloadThumbnail(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+	}
+
+	body, thumb, err := f.S3service.DownloadThumbnail(c, id, c.Params("name"))
+	if err != nil {
+		if errors.Is(err, service.ErrThumbnailNotFound) {
+			return c.Status(fiber.StatusOK).JSON(response.ErrNotFound)
+		}
+		return versionError(c, "Error: Fail to download thumbnail.", err)
+	}
+
+	c.Set("Content-Type", thumb.ContentType)
+	return c.SendStream(body, int(thumb.Size))
+}
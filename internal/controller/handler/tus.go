@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/service"
+	"project-api/internal/core/service"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+	tusChunkType  = "application/offset+octet-stream"
+)
+
+// TusHandler speaks the tus 1.0 resumable upload protocol. Replies carry
+// their state in headers, so errors are plain text with the tus status codes.
+type TusHandler struct {
+	tusService In.ITusService
+}
+
+func NewTusHandler(tusService In.ITusService) *TusHandler {
+	return &TusHandler{tusService: tusService}
+}
+
+// Resumable rejects clients that speak another tus version; OPTIONS is exempt
+// because it is how a client discovers the version
+func (h *TusHandler) Resumable(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	if c.Method() != fiber.MethodOptions && c.Get("Tus-Resumable") != tusVersion {
+		c.Set("Tus-Version", tusVersion)
+		return c.Status(fiber.StatusPreconditionFailed).SendString("unsupported tus version")
+	}
+	return c.Next()
+}
+
+// Options advertises the supported version, extensions and maximum size
+func (h *TusHandler) Options(c *fiber.Ctx) error {
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", tusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(service.MaxFileSize, 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Create starts an upload of Upload-Length bytes. Upload-Metadata may carry
+// "filename" and "filetype".
+func (h *TusHandler) Create(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	size, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Upload-Length is required")
+	}
+	metadata := parseTusMetadata(c.Get("Upload-Metadata"))
+	fileName := metadata["filename"]
+	if fileName == "" {
+		fileName = "upload"
+	}
+	contentType := metadata["filetype"]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	upload, err := h.tusService.CreateUpload(c.UserContext(), claims.UserID, fileName, contentType, size)
+	if err != nil {
+		return tusError(c, err)
+	}
+
+	// A path rather than a full URL, so it stays valid behind the proxy
+	c.Location(strings.TrimSuffix(c.Path(), "/") + "/" + strconv.FormatUint(uint64(upload.ID), 10))
+	setUploadExpires(c, upload)
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// Head reports how many bytes have arrived so the client knows where to resume
+func (h *TusHandler) Head(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	upload, err := h.tusService.GetUpload(c.UserContext(), claims.UserID, uint(id))
+	if err != nil {
+		return tusError(c, err)
+	}
+
+	c.Set("Cache-Control", "no-store")
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	setUploadExpires(c, upload)
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// Patch appends the request body at Upload-Offset
+func (h *TusHandler) Patch(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	if c.Get(fiber.HeaderContentType) != tusChunkType {
+		return c.Status(fiber.StatusUnsupportedMediaType).SendString("Content-Type must be " + tusChunkType)
+	}
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Upload-Offset is required")
+	}
+
+	upload, err := h.tusService.WriteChunk(c.UserContext(), claims.UserID, uint(id), offset, requestBody(c))
+	if err != nil {
+		return tusError(c, err)
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	setUploadExpires(c, upload)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Terminate discards an unfinished upload
+func (h *TusHandler) Terminate(c *fiber.Ctx) error {
+	claims, ok := utils.GetUserIDFromContext(c.UserContext())
+	if !ok {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	if err := h.tusService.TerminateUpload(c.UserContext(), claims.UserID, uint(id)); err != nil {
+		return tusError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// requestBody reads a streamed body without buffering it; small bodies are
+// already read by the time the handler runs
+func requestBody(c *fiber.Ctx) io.Reader {
+	if stream := c.Context().RequestBodyStream(); stream != nil {
+		return stream
+	}
+	return bytes.NewReader(c.Body())
+}
+
+// parseTusMetadata decodes "key base64value,key base64value"; pairs that
+// don't decode are skipped
+func parseTusMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(decoded)
+	}
+	return metadata
+}
+
+func setUploadExpires(c *fiber.Ctx, upload *entity.Upload) {
+	if upload.Status != entity.UploadStatusCompleted {
+		c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+func tusError(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		code = fiber.StatusNotFound
+	case errors.Is(err, service.ErrUploadExpired):
+		code = fiber.StatusGone
+	case errors.Is(err, service.ErrUploadOffset), errors.Is(err, service.ErrUploadState):
+		code = fiber.StatusConflict
+	case errors.Is(err, service.ErrUploadBusy):
+		code = fiber.StatusLocked
+	case errors.Is(err, service.ErrFileTooLarge), errors.Is(err, service.ErrUploadOverflow), errors.Is(err, service.ErrQuotaExceeded):
+		code = fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrFileType), errors.Is(err, service.ErrFileTypeMismatch):
+		code = fiber.StatusUnsupportedMediaType
+	default:
+		logger.Error("tus request failed", zap.String("path", c.Path()), zap.Error(err))
+	}
+	return c.Status(code).SendString(err.Error())
+}
@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 
 	"project-api/internal/core/model/request"
 	"project-api/internal/core/model/response"
+	"project-api/internal/core/port/repository"
+	"project-api/internal/core/service"
 
 	In "project-api/internal/core/port/service"
 
@@ -34,7 +37,7 @@ func (u *UserHandler) CreateUser(ctx *fiber.Ctx) error {
 			Data: err.Error(),
 		})
 	}
-	if err := u.service.Create(ctx.Context(), userEntity); err != nil {
+	if err := u.service.Create(ctx.UserContext(), userEntity); err != nil {
 		return ctx.Status(http.StatusOK).JSON(response.ErrorResponse{
 			Code: fiber.StatusInternalServerError,
 			Msg:  "Error to create user",
@@ -49,7 +52,7 @@ func (u *UserHandler) CreateUser(ctx *fiber.Ctx) error {
 
 func (u *UserHandler) GetUserByEmail(ctx *fiber.Ctx) error {
 	email := ctx.Params("email")
-	user, err := u.service.GetUserByEmail(ctx.Context(), email)
+	user, err := u.service.GetUserByEmail(ctx.UserContext(), email)
 	if err != nil {
 		return ctx.Status(http.StatusOK).JSON(response.ErrNotFound)
 	}
@@ -58,3 +61,47 @@ func (u *UserHandler) GetUserByEmail(ctx *fiber.Ctx) error {
 		Data: user,
 	})
 }
+
+// SearchUsers is the user directory search, restricted to active users and to
+// their names; emails are neither searched nor returned
+func (u *UserHandler) SearchUsers(ctx *fiber.Ctx) error {
+	return u.searchUsers(ctx, false)
+}
+
+// AdminSearchUsers searches every user of the tenant, including inactive ones,
+// by email too and returns the full records
+func (u *UserHandler) AdminSearchUsers(ctx *fiber.Ctx) error {
+	return u.searchUsers(ctx, true)
+}
+
+func (u *UserHandler) searchUsers(ctx *fiber.Ctx, admin bool) error {
+	query := repository.UserSearchQuery{
+		Query:      ctx.Query("q"),
+		Cursor:     ctx.Query("cursor"),
+		Limit:      ctx.QueryInt("limit"),
+		ActiveOnly: !admin,
+		MatchEmail: admin,
+	}
+	result, err := u.service.SearchUsers(ctx.UserContext(), query)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, service.ErrSearchQuery) || errors.Is(err, repository.ErrInvalidCursor) {
+			code = fiber.StatusBadRequest
+		}
+		return ctx.Status(http.StatusOK).JSON(response.ErrorResponse{
+			Code: code,
+			Msg:  "Error to search users",
+			Data: err.Error(),
+		})
+	}
+	if !admin {
+		return ctx.Status(http.StatusOK).JSON(response.SuccResponse{
+			Msg:  "Users found successfully",
+			Data: response.NewUserDirectoryPage(result),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(response.SuccResponse{
+		Msg:  "Users found successfully",
+		Data: response.NewUserSearchPage(result),
+	})
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"project-api/internal/controller/handler"
+	"project-api/internal/core/entity"
 	"project-api/internal/core/middleware"
+	"project-api/internal/core/port/repository"
 	In "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
 	"project-api/internal/infra/logger"
 	"github.com/gofiber/template/html/v2"
 
-	"github.com/RichardKnop/machinery/v2"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 )
@@ -22,9 +24,15 @@ type RouterConfig struct {
 
 // Services holds all required services
 type Services struct {
-	UserService In.IUserService
-	FileService In.IS3Service
-	Server      *machinery.Server
+	UserService         In.IUserService
+	FileService         In.IS3Service
+	UserImportService   In.IUserImportService
+	NotificationService In.INotificationService
+	TusService          In.ITusService
+	QuotaService        In.IQuotaService
+	ShareService        In.IShareService
+	FolderService       In.IFolderService
+	Events              repository.IEventBus
 }
 
 // Router encapsulates the Fiber app and its configuration
@@ -34,14 +42,18 @@ type Router struct {
 
 // New creates a new Router instance with optimized configuration
 func New(services *Services) (*Router, error) {
-	if services == nil || services.UserService == nil || services.FileService == nil {
+	if services == nil || services.UserService == nil || services.FileService == nil || services.UserImportService == nil || services.NotificationService == nil || services.TusService == nil || services.QuotaService == nil || services.ShareService == nil || services.FolderService == nil || services.Events == nil {
 		return nil, fmt.Errorf("services cannot be nil")
 	}
 
 	// Initialize Fiber with custom configuration
+	// Request bodies are streamed so multipart uploads spill to temporary files
+	// instead of being held in memory
 	app := fiber.New(fiber.Config{
-		ErrorHandler: customErrorHandler,
-		Views:        html.New("./templates", ".html"),
+		ErrorHandler:      customErrorHandler,
+		Views:             html.New("./templates", ".html"),
+		BodyLimit:         config.Config.GetBodyLimit(),
+		StreamRequestBody: true,
 	})
 
 	// Configure router
@@ -58,9 +70,17 @@ func (r *Router) setupRoutes(services *Services) {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Welcome to the API"})
 
 	})
+	// Resolve the tenant of every request before any route touches the database
+	r.app.Use(middleware.TenantMiddleware)
+
 	// Public routes (no authentication)
 	auth := r.app.Group("/api/v1/auth")
-	r.setupAuthRoutes(auth, services.UserService, services.Server)
+	r.setupAuthRoutes(auth, services.UserService, services.NotificationService)
+
+	// Public share links; the token is the only credential
+	shareHandler := controller.NewShareHandler(services.ShareService)
+	r.app.Get("/api/v1/share/:token", shareHandler.Download)
+	r.app.Post("/api/v1/share/:token", shareHandler.Download)
 
 	// Protected routes
 	v1 := r.app.Group("/api/v1", middleware.JWTAuthMiddleware)
@@ -68,8 +88,8 @@ func (r *Router) setupRoutes(services *Services) {
 }
 
 // setupAuthRoutes configures authentication routes
-func (r *Router) setupAuthRoutes(group fiber.Router, userService In.IUserService, machineryServer *machinery.Server) {
-	authHandler := controller.NewAuthHandler(userService, machineryServer)
+func (r *Router) setupAuthRoutes(group fiber.Router, userService In.IUserService, notifier In.INotificationService) {
+	authHandler := controller.NewAuthHandler(userService, notifier)
 	group.Post("/login", authHandler.LoginHandle)
 	group.Post("/register", authHandler.RegisterHandler)
 	group.Get("/confirm/:token", authHandler.ConfirmEmailHandler)
@@ -84,14 +104,93 @@ func (r *Router) setupProtectedRoutes(group fiber.Router, services *Services) {
 	userGroup := group.Group("/users")
 	userHandler := controller.NewUserHandler(services.UserService)
 	userGroup.Post("/", userHandler.CreateUser)
+	userGroup.Get("/search", userHandler.SearchUsers)
 	userGroup.Get("/:email", userHandler.GetUserByEmail)
 
+	// Notification routes
+	notificationGroup := group.Group("/notifications")
+	notificationHandler := controller.NewNotificationHandler(services.NotificationService)
+	notificationGroup.Get("/", notificationHandler.ListNotifications)
+	notificationGroup.Get("/unread-count", notificationHandler.UnreadCount)
+	notificationGroup.Post("/read-all", notificationHandler.MarkAllRead)
+	notificationGroup.Post("/:id/read", notificationHandler.MarkRead)
+	notificationGroup.Get("/preferences", notificationHandler.GetPreferences)
+	notificationGroup.Put("/preferences", notificationHandler.UpdatePreferences)
+
+	// Real-time events
+	eventHandler := controller.NewEventHandler(services.Events)
+	group.Post("/events/ticket", eventHandler.Ticket)
+	group.Get("/events", eventHandler.Stream)
+
+	// Admin routes
+	adminGroup := group.Group("/admin", middleware.RequireRole(entity.RoleAdmin))
+	adminHandler := controller.NewAdminHandler(services.UserImportService)
+	adminGroup.Post("/users/import", adminHandler.ImportUsers)
+	adminGroup.Get("/users/import/:id", adminHandler.GetImportJob)
+	adminGroup.Get("/users/export", adminHandler.ExportUsers)
+	adminGroup.Get("/users/search", userHandler.AdminSearchUsers)
+	quotaHandler := controller.NewQuotaHandler(services.QuotaService)
+	adminGroup.Get("/quota/plans", quotaHandler.ListPlans)
+	adminGroup.Post("/quota/plans", quotaHandler.CreatePlan)
+	adminGroup.Put("/quota/users/:id", quotaHandler.AssignUserPlan)
+	adminGroup.Get("/quota/org", quotaHandler.GetOrgUsage)
+	adminGroup.Put("/quota/org", quotaHandler.AssignOrgPlan)
+	adminGroup.Post("/quota/reconcile", quotaHandler.Reconcile)
+
+	// Folder routes
+	folderGroup := group.Group("/folders")
+	folderHandler := controller.NewFolderHandler(services.FolderService)
+	folderGroup.Get("/", folderHandler.ListPath)
+	folderGroup.Post("/", folderHandler.CreateFolder)
+	folderGroup.Get("/:id", folderHandler.ListFolder)
+	folderGroup.Post("/:id/rename", folderHandler.RenameFolder)
+	folderGroup.Post("/:id/move", folderHandler.MoveFolder)
+	folderGroup.Delete("/:id", folderHandler.DeleteFolder)
+
 	// File routes
 	fileGroup := group.Group("/files")
 	fileHandler := controller.NewFileHandler(services.UserService, services.FileService)
+	shareHandler := controller.NewShareHandler(services.ShareService)
+	fileGroup.Get("/", fileHandler.ListFiles)
+	fileGroup.Get("/usage", quotaHandler.GetUsage)
+	fileGroup.Get("/shared", fileHandler.ListSharedFiles)
+	fileGroup.Get("/trash", fileHandler.ListTrash)
 	fileGroup.Post("/upload", fileHandler.UploadFile)
 	fileGroup.Delete("/delete/:key", fileHandler.DeleteFile)
 	fileGroup.Get("/download/:key", fileHandler.DownloadFile)
+	fileGroup.Post("/archive", fileHandler.DownloadArchive)
+	fileGroup.Post("/presign/upload", fileHandler.PresignUpload)
+	fileGroup.Post("/presign/upload/:id/complete", fileHandler.CompleteUpload)
+	fileGroup.Get("/presign/download/:key", fileHandler.PresignDownload)
+
+	// Resumable uploads (tus 1.0)
+	tusGroup := fileGroup.Group("/tus")
+	tusHandler := controller.NewTusHandler(services.TusService)
+	tusGroup.Use(tusHandler.Resumable)
+	tusGroup.Options("/", tusHandler.Options)
+	tusGroup.Post("/", tusHandler.Create)
+	tusGroup.Options("/:id", tusHandler.Options)
+	tusGroup.Head("/:id", tusHandler.Head)
+	tusGroup.Patch("/:id", tusHandler.Patch)
+	tusGroup.Delete("/:id", tusHandler.Terminate)
+
+	fileGroup.Get("/:id", fileHandler.GetFile)
+	fileGroup.Put("/:id/tags", fileHandler.UpdateFileTags)
+	fileGroup.Post("/:id/restore", fileHandler.RestoreFile)
+	fileGroup.Post("/:id/rename", folderHandler.RenameFile)
+	fileGroup.Post("/:id/move", folderHandler.MoveFile)
+	fileGroup.Post("/:id/links", shareHandler.CreateLink)
+	fileGroup.Get("/:id/links", shareHandler.ListLinks)
+	fileGroup.Delete("/:id/links/:linkId", shareHandler.RevokeLink)
+	fileGroup.Get("/:id/links/:linkId/access", shareHandler.ListAccess)
+	fileGroup.Post("/:id/permissions", fileHandler.GrantPermission)
+	fileGroup.Get("/:id/permissions", fileHandler.ListPermissions)
+	fileGroup.Delete("/:id/permissions/:permissionId", fileHandler.RevokePermission)
+	fileGroup.Post("/:id/versions", fileHandler.UploadVersion)
+	fileGroup.Get("/:id/versions", fileHandler.ListVersions)
+	fileGroup.Get("/:id/versions/:version/download", fileHandler.DownloadVersion)
+	fileGroup.Post("/:id/versions/:version/restore", fileHandler.RestoreVersion)
+	fileGroup.Get("/:id/thumbnails/:name", fileHandler.DownloadThumbnail)
 	fileGroup.Use(func(c *fiber.Ctx) error {
 		logger.Warn("Unhandled file route", zap.String("path", c.Path()))
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -0,0 +1,255 @@
+// Package envelope seals content with AES-256-GCM in fixed-size chunks, so it
+// can be encrypted while it streams and any range of it decrypted without
+// reading what comes before.
+//
+// Chunk i holds plaintext bytes [i*chunkSize, (i+1)*chunkSize) followed by
+// its GCM tag and is sealed with the chunk number as nonce. Every data key
+// seals one content only, so nonces never repeat under a key. The plaintext
+// size is kept with the key, which gives every chunk its length: chunks that
+// are cut short, dropped or moved fail to open.
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	// KeySize is the size of a data key, for AES-256
+	KeySize = 32
+	// Overhead is what sealing adds to each chunk
+	Overhead = 16
+	// DefaultChunkSize is the plaintext sealed by each chunk
+	DefaultChunkSize = 64 << 10
+	// MaxChunkSize keeps a chunk, which is held in memory, reasonable
+	MaxChunkSize = 16 << 20
+)
+
+var (
+	ErrKeySize   = errors.New("data key must be 32 bytes")
+	ErrChunkSize = errors.New("chunk size is out of range")
+	ErrRange     = errors.New("range is outside the content")
+	// ErrCorrupt is content that does not open with its key: damaged,
+	// truncated or sealed with another key
+	ErrCorrupt = errors.New("encrypted content is corrupt")
+)
+
+// NewKey returns a random data key
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SealedSize is the size of size bytes of plaintext once sealed
+func SealedSize(size int64, chunkSize int) int64 {
+	return size + chunks(size, chunkSize)*Overhead
+}
+
+func chunks(size int64, chunkSize int) int64 {
+	return (size + int64(chunkSize) - 1) / int64(chunkSize)
+}
+
+func newAEAD(key []byte, chunkSize int) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return nil, ErrChunkSize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(nonce []byte, index int64) []byte {
+	clear(nonce)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], uint64(index))
+	return nonce
+}
+
+// sealer seals the plaintext it reads one chunk at a time
+type sealer struct {
+	src   io.Reader
+	aead  cipher.AEAD
+	nonce []byte
+	plain []byte
+	out   []byte
+	// pending is the part of out not read yet
+	pending []byte
+	index   int64
+	err     error
+}
+
+// NewSealer returns a reader of the content read from r sealed with key.
+// The key must not seal anything else.
+func NewSealer(r io.Reader, key []byte, chunkSize int) (io.Reader, error) {
+	aead, err := newAEAD(key, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{
+		src:   r,
+		aead:  aead,
+		nonce: make([]byte, aead.NonceSize()),
+		plain: make([]byte, chunkSize),
+		out:   make([]byte, 0, chunkSize+Overhead),
+	}, nil
+}
+
+func (s *sealer) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		n, err := io.ReadFull(s.src, s.plain)
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			// A short chunk is the last one
+			s.err = io.EOF
+		default:
+			s.err = err
+			return 0, err
+		}
+		if n > 0 {
+			s.pending = s.aead.Seal(s.out[:0], chunkNonce(s.nonce, s.index), s.plain[:n], nil)
+			s.index++
+		}
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// opener opens chunks of sealed content in order
+type opener struct {
+	src   io.ReadCloser
+	aead  cipher.AEAD
+	nonce []byte
+	buf   []byte
+	// pending is the opened plaintext not read yet
+	pending   []byte
+	chunkSize int64
+	size      int64
+	index     int64
+	// skip is dropped from the first chunk and remaining is what is left to
+	// return; a negative remaining reads to the end
+	skip      int64
+	remaining int64
+	// whole is set when src is the whole content, which must then end with
+	// the last chunk
+	whole bool
+	err   error
+}
+
+// OpenRange returns length bytes of plaintext starting at offset, or up to
+// the end for a negative length, of content of size bytes sealed with key.
+// read gets the range of the sealed content to decrypt, again with a
+// negative length for up to its end; it is called once.
+func OpenRange(read func(offset, length int64) (io.ReadCloser, error), key []byte, chunkSize int, size, offset, length int64) (io.ReadCloser, error) {
+	aead, err := newAEAD(key, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || offset > size || (length >= 0 && offset+length > size) {
+		return nil, ErrRange
+	}
+	whole := offset == 0 && length < 0
+	if length < 0 {
+		length = size - offset
+	}
+	if length == 0 && !whole {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	chunk := int64(chunkSize)
+	first := offset / chunk
+	var src io.ReadCloser
+	if whole {
+		src, err = read(0, -1)
+	} else {
+		last := (offset + length - 1) / chunk
+		start := first * (chunk + Overhead)
+		end := SealedSize(min((last+1)*chunk, size), chunkSize)
+		src, err = read(start, end-start)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &opener{
+		src:       src,
+		aead:      aead,
+		nonce:     make([]byte, aead.NonceSize()),
+		buf:       make([]byte, chunkSize+Overhead),
+		chunkSize: chunk,
+		size:      size,
+		index:     first,
+		skip:      offset - first*chunk,
+		remaining: length,
+		whole:     whole,
+	}, nil
+}
+
+func (o *opener) Read(p []byte) (int, error) {
+	if o.remaining == 0 {
+		return 0, io.EOF
+	}
+	if len(o.pending) == 0 {
+		if o.err != nil {
+			return 0, o.err
+		}
+		if o.err = o.next(); o.err != nil {
+			return 0, o.err
+		}
+	}
+	if int64(len(p)) > o.remaining {
+		p = p[:o.remaining]
+	}
+	n := copy(p, o.pending)
+	o.pending = o.pending[n:]
+	o.remaining -= int64(n)
+	if o.remaining == 0 && o.whole {
+		// Whatever follows the last chunk was not sealed with it
+		var extra [1]byte
+		if n, _ := o.src.Read(extra[:]); n > 0 {
+			o.err = ErrCorrupt
+			return n, ErrCorrupt
+		}
+	}
+	return n, nil
+}
+
+// next opens the chunk at index
+func (o *opener) next() error {
+	start := o.index * o.chunkSize
+	plainLen := min(o.chunkSize, o.size-start)
+	sealed := o.buf[:plainLen+Overhead]
+	if _, err := io.ReadFull(o.src, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrCorrupt
+		}
+		return err
+	}
+	plain, err := o.aead.Open(sealed[:0], chunkNonce(o.nonce, o.index), sealed, nil)
+	if err != nil {
+		return ErrCorrupt
+	}
+	o.index++
+	o.pending = plain[o.skip:]
+	o.skip = 0
+	return nil
+}
+
+func (o *opener) Close() error {
+	return o.src.Close()
+}
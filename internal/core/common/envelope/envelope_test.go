@@ -0,0 +1,209 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+const testChunkSize = 16
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func plaintext(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*7 + 3)
+	}
+	return data
+}
+
+func seal(t *testing.T, key, data []byte) []byte {
+	t.Helper()
+	r, err := NewSealer(bytes.NewReader(data), key, testChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(sealed)) != SealedSize(int64(len(data)), testChunkSize) {
+		t.Fatalf("sealed %d bytes into %d, SealedSize says %d", len(data), len(sealed), SealedSize(int64(len(data)), testChunkSize))
+	}
+	return sealed
+}
+
+// open reads a range of sealed the way storage would serve it
+func open(key, sealed []byte, size, offset, length int64) ([]byte, error) {
+	read := func(offset, length int64) (io.ReadCloser, error) {
+		if length < 0 {
+			length = int64(len(sealed)) - offset
+		}
+		end := min(offset+length, int64(len(sealed)))
+		return io.NopCloser(bytes.NewReader(sealed[offset:end])), nil
+	}
+	r, err := OpenRange(read, key, testChunkSize, size, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, testChunkSize - 1, testChunkSize, testChunkSize + 1, 3 * testChunkSize, 3*testChunkSize + 5} {
+		key := testKey(t)
+		data := plaintext(size)
+		got, err := open(key, seal(t, key, data), int64(size), 0, -1)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("size %d: opened content differs", size)
+		}
+	}
+}
+
+func TestOpenRange(t *testing.T) {
+	const size = 3*testChunkSize + 5
+	key := testKey(t)
+	data := plaintext(size)
+	sealed := seal(t, key, data)
+
+	tests := []struct {
+		name           string
+		offset, length int64
+	}{
+		{"inside the first chunk", 3, 5},
+		{"inside a middle chunk", testChunkSize + 2, 4},
+		{"a whole chunk", testChunkSize, testChunkSize},
+		{"ending on a chunk boundary", 5, testChunkSize - 5},
+		{"starting on a chunk boundary", 2 * testChunkSize, 3},
+		{"across two chunks", testChunkSize - 2, 4},
+		{"across every chunk", 1, size - 2},
+		{"the short last chunk", 3 * testChunkSize, 5},
+		{"the last byte", size - 1, 1},
+		{"to the end", testChunkSize + 7, -1},
+		{"to the end from the last chunk", 3*testChunkSize + 1, -1},
+		{"nothing", 10, 0},
+		{"nothing at the end", size, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := open(key, sealed, size, tt.offset, tt.length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end := int64(size)
+			if tt.length >= 0 {
+				end = tt.offset + tt.length
+			}
+			if !bytes.Equal(got, data[tt.offset:end]) {
+				t.Fatalf("got %x, want %x", got, data[tt.offset:end])
+			}
+		})
+	}
+}
+
+func TestOpenRangeOutsideContent(t *testing.T) {
+	const size = 2 * testChunkSize
+	key := testKey(t)
+	sealed := seal(t, key, plaintext(size))
+
+	for _, tt := range []struct{ offset, length int64 }{
+		{-1, 2},
+		{size + 1, -1},
+		{size - 1, 2},
+		{0, size + 1},
+	} {
+		if _, err := open(key, sealed, size, tt.offset, tt.length); !errors.Is(err, ErrRange) {
+			t.Fatalf("offset %d length %d: got %v, want ErrRange", tt.offset, tt.length, err)
+		}
+	}
+}
+
+func TestDamagedContentFailsToOpen(t *testing.T) {
+	const size = 3*testChunkSize + 5
+	const sealedChunk = testChunkSize + Overhead
+	key := testKey(t)
+	sealed := seal(t, key, plaintext(size))
+
+	tests := []struct {
+		name   string
+		damage func(sealed []byte) []byte
+		key    []byte
+	}{
+		{"a flipped byte", func(s []byte) []byte {
+			s[sealedChunk+3] ^= 1
+			return s
+		}, key},
+		{"a flipped tag", func(s []byte) []byte {
+			s[sealedChunk-1] ^= 1
+			return s
+		}, key},
+		{"a truncated last chunk", func(s []byte) []byte {
+			return s[:len(s)-1]
+		}, key},
+		{"a dropped last chunk", func(s []byte) []byte {
+			return s[:3*sealedChunk]
+		}, key},
+		{"a dropped middle chunk", func(s []byte) []byte {
+			return append(s[:sealedChunk:sealedChunk], s[2*sealedChunk:]...)
+		}, key},
+		{"swapped chunks", func(s []byte) []byte {
+			first := bytes.Clone(s[:sealedChunk])
+			copy(s, s[sealedChunk:2*sealedChunk])
+			copy(s[sealedChunk:], first)
+			return s
+		}, key},
+		{"bytes after the last chunk", func(s []byte) []byte {
+			return append(s, 0)
+		}, key},
+		{"another key", func(s []byte) []byte {
+			return s
+		}, testKey(t)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			damaged := tt.damage(bytes.Clone(sealed))
+			if _, err := open(tt.key, damaged, size, 0, -1); !errors.Is(err, ErrCorrupt) {
+				t.Fatalf("whole content: got %v, want ErrCorrupt", err)
+			}
+		})
+	}
+}
+
+func TestDamagedChunkFailsRangeOpen(t *testing.T) {
+	const size = 3 * testChunkSize
+	key := testKey(t)
+	sealed := seal(t, key, plaintext(size))
+	sealed[testChunkSize+Overhead+1] ^= 1
+
+	// Ranges that stay clear of the damaged chunk still open
+	if _, err := open(key, sealed, size, 2*testChunkSize, 4); err != nil {
+		t.Fatalf("range after the damaged chunk: %v", err)
+	}
+	if _, err := open(key, sealed, size, testChunkSize-2, 4); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("range into the damaged chunk: got %v, want ErrCorrupt", err)
+	}
+}
+
+func TestInvalidKeyAndChunkSize(t *testing.T) {
+	if _, err := NewSealer(bytes.NewReader(nil), make([]byte, 16), testChunkSize); !errors.Is(err, ErrKeySize) {
+		t.Fatalf("short key: got %v, want ErrKeySize", err)
+	}
+	for _, chunkSize := range []int{0, -1, MaxChunkSize + 1} {
+		if _, err := NewSealer(bytes.NewReader(nil), testKey(t), chunkSize); !errors.Is(err, ErrChunkSize) {
+			t.Fatalf("chunk size %d: got %v, want ErrChunkSize", chunkSize, err)
+		}
+	}
+}
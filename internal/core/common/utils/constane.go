@@ -1,13 +1,21 @@
 package utils
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 type contextKey string
 
 const (
-	userContextKey contextKey = "user"
+	userContextKey   contextKey = "user"
+	tenantContextKey contextKey = "tenant"
 )
 
+// TenantQueryParam names the tenant in links that leave the API, such as the
+// ones sent by email, since whoever follows them can't set the tenant header
+const TenantQueryParam = "tenant"
+
 func GetUserContextKey() contextKey {
 	return userContextKey
 }
@@ -16,3 +24,27 @@ func GetUserIDFromContext(ctx context.Context) (*UserClaims, bool) {
 	userClaims, ok := valFromContext.(*UserClaims)
 	return userClaims, ok
 }
+
+// WithTenantID returns a copy of ctx carrying the given tenant identifier
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenantID)
+}
+
+// GetTenantIDFromContext returns the tenant identifier stored in ctx
+func GetTenantIDFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey).(string)
+	if !ok || tenantID == "" {
+		return "", false
+	}
+	return tenantID, true
+}
+
+// TenantKey prefixes a storage key with the tenant found in ctx so objects
+// belonging to different tenants never share a key space
+func TenantKey(ctx context.Context, key string) (string, error) {
+	tenantID, ok := GetTenantIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("tenant is required to build storage key")
+	}
+	return fmt.Sprintf("tenants/%s/%s", tenantID, key), nil
+}
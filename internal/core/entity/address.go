@@ -8,6 +8,7 @@ import (
 
 type Address struct {
 	gorm.Model
+	TenantID    string `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index"`
 	Title       string `json:"title" gorm:"type:varchar(64);not null"`
 	Street      string `json:"street" gorm:"type:varchar(255);not null"`
 	City        string `json:"city" gorm:"type:varchar(255);not null"`
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// Blob is a stored object addressed by the SHA-256 of its content. Files with
+// the same content share one blob, which is deleted when RefCount drops to zero.
+type Blob struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TenantID  string    `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_blob_tenant_hash"`
+	Hash      string    `json:"hash" gorm:"type:varchar(64);not null;uniqueIndex:idx_blob_tenant_hash"` // hex
+	Key       string    `json:"key" gorm:"type:varchar(255);not null"`
+	Size      int64     `json:"size" gorm:"not null"`
+	RefCount  int       `json:"ref_count" gorm:"not null;default:1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (b *Blob) TableName() string {
+	return "blobs"
+}
@@ -0,0 +1,14 @@
+package entity
+
+// EncryptionChunkedAESGCM is content sealed with AES-256-GCM in chunks
+const EncryptionChunkedAESGCM = "AES-256-GCM-CHUNKED"
+
+// Encryption describes content stored encrypted under a data key of its own.
+// The data key is only kept wrapped by the key provider's key KeyID, and
+// ChunkSize is the plaintext each chunk seals.
+type Encryption struct {
+	Algorithm  string `json:"algorithm"`
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	ChunkSize  int    `json:"chunk_size"`
+}
@@ -8,17 +8,55 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	FileScanPending  = "pending"
+	FileScanScanning = "scanning"
+	FileScanClean    = "clean"
+	FileScanInfected = "infected"
+	// FileScanFailed is content the scanner refused, such as a file over its
+	// size limit; it stays quarantined
+	FileScanFailed = "failed"
+)
+
 type File struct {
-	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	UserID     uint           `gorm:"not null;index"`
-	User       User           `gorm:"foreignKey:UserID" json:"-"`
-	FileName   string         `gorm:"type:varchar(255);not null" json:"file_name"`
-	FilePath   string         `gorm:"type:varchar(255);not null" json:"file_path"`
-	UrlPath    string         `gorm:"type:varchar(512);not null" json:"url_path"`
-	FileType   string         `gorm:"type:varchar(100);not null" json:"file_type"`
-	FileSize   int64          `gorm:"not null" json:"file_size"`
-	UploadedAt time.Time      `gorm:"autoCreateTime" json:"uploaded_at"`
-	IsDeleted  bool           `gorm:"default:false" json:"is_deleted"`
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID string    `gorm:"type:varchar(64);not null;default:'default';index" json:"tenant_id"`
+	UserID   uint      `gorm:"not null;index"`
+	User     User      `gorm:"foreignKey:UserID" json:"-"`
+	FileName string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	FilePath string    `gorm:"type:varchar(255);not null" json:"file_path"`
+	UrlPath  string    `gorm:"type:varchar(512);not null" json:"url_path"`
+	FileType string    `gorm:"type:varchar(100);not null" json:"file_type"`
+	FileSize int64     `gorm:"not null" json:"file_size"`
+	// FolderID is nil for files at the root of the owner's tree
+	FolderID *uuid.UUID `gorm:"type:uuid;index" json:"folder_id"`
+	// ContentHash is the hex SHA-256 of the content and names the blob at
+	// FilePath, unless the content is encrypted; files stored before content
+	// addressing have none
+	ContentHash string `gorm:"type:varchar(64);index" json:"content_hash"`
+	// Encryption is set when the content at FilePath is encrypted, which
+	// gives the file an object of its own instead of a shared blob
+	Encryption *Encryption `gorm:"type:jsonb;serializer:json" json:"-"`
+	// Version numbers the current content; earlier ones are FileVersion rows
+	Version int      `gorm:"not null;default:1" json:"version"`
+	Tags    []string `gorm:"type:jsonb;serializer:json;not null;default:'[]'" json:"tags"`
+	// Thumbnails are made by the worker for image uploads, after the upload
+	// returns, and belong to the current version only
+	Thumbnails []Thumbnail `gorm:"type:jsonb;serializer:json;not null;default:'[]'" json:"thumbnails"`
+	UploadedAt time.Time   `gorm:"autoCreateTime" json:"uploaded_at"`
+	IsDeleted  bool        `gorm:"default:false" json:"is_deleted"`
+	// TrashedAt is set while a deleted file waits in the trash for its purge;
+	// files deleted before the trash existed have none and can't be restored
+	TrashedAt *time.Time `gorm:"index" json:"trashed_at"`
+	// ExpiresAt is when the uploader asked for the file to go away; nil keeps it
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+	// ScanStatus is the virus scan verdict on the current content, which can
+	// only be downloaded once it is clean. ScanResult names the signature an
+	// infected file matched, and ScannedAt is when the last scan started or
+	// ended. Content stored before scanning existed counts as clean.
+	ScanStatus string         `gorm:"type:varchar(16);not null;default:'clean';index" json:"scan_status"`
+	ScanResult string         `gorm:"type:varchar(255)" json:"scan_result"`
+	ScannedAt  *time.Time     `json:"scanned_at"`
 	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -32,6 +70,25 @@ func (f *File) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// Expired reports whether the file's expiry has passed at now
+func (f *File) Expired(now time.Time) bool {
+	return f.ExpiresAt != nil && !now.Before(*f.ExpiresAt)
+}
+
+// BlobHash names the blob holding the current content; it is empty when the
+// file owns its object
+func (f *File) BlobHash() string {
+	if f.Encryption != nil {
+		return ""
+	}
+	return f.ContentHash
+}
+
+// Quarantined reports whether the content waits for, or failed, its scan
+func (f *File) Quarantined() bool {
+	return f.ScanStatus != FileScanClean
+}
+
 func (f *File) ToJson() ([]byte, error) {
 	return json.Marshal(f)
 }
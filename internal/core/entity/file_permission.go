@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FileAccessRead  = "read"
+	FileAccessWrite = "write"
+
+	GranteeUser = "user"
+	GranteeOrg  = "org" // every user of the tenant
+)
+
+// FilePermission grants a user, or the whole tenant, access to a file it
+// doesn't own. Write access includes read.
+type FilePermission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	TenantID    string    `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index"`
+	FileID      uuid.UUID `json:"file_id" gorm:"type:uuid;not null;uniqueIndex:idx_file_permission_grantee"`
+	GranteeType string    `json:"grantee_type" gorm:"type:varchar(10);not null;uniqueIndex:idx_file_permission_grantee"`
+	// GranteeID is the user of a user grant and zero for an org grant
+	GranteeID uint      `json:"grantee_id" gorm:"not null;uniqueIndex:idx_file_permission_grantee;index"`
+	Access    string    `json:"access" gorm:"type:varchar(10);not null"`
+	GrantedBy uint      `json:"granted_by" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p *FilePermission) TableName() string {
+	return "file_permissions"
+}
+
+// FileAccessAllows reports whether the granted access covers the wanted one
+func FileAccessAllows(granted, wanted string) bool {
+	return granted == FileAccessWrite || (granted == FileAccessRead && wanted == FileAccessRead)
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileVersion is an earlier content of a file. The File row always holds the
+// current version; each history entry keeps its own blob reference.
+type FileVersion struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	TenantID string    `json:"-" gorm:"type:varchar(64);not null;default:'default';index"`
+	FileID   uuid.UUID `json:"file_id" gorm:"type:uuid;not null;uniqueIndex:idx_file_version"`
+	Version  int       `json:"version" gorm:"not null;uniqueIndex:idx_file_version"`
+	FilePath string    `json:"-" gorm:"type:varchar(255);not null"`
+	UrlPath  string    `json:"-" gorm:"type:varchar(512);not null"`
+	FileType string    `json:"file_type" gorm:"type:varchar(100);not null"`
+	FileSize int64     `json:"file_size" gorm:"not null"`
+	// ContentHash is empty for content stored before content addressing
+	ContentHash string `json:"content_hash" gorm:"type:varchar(64)"`
+	// Encryption is set when the content is encrypted in an object of its own
+	Encryption *Encryption `json:"-" gorm:"type:jsonb;serializer:json"`
+	// CreatedAt is when this content was uploaded, not when it was replaced
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (v *FileVersion) TableName() string {
+	return "file_versions"
+}
+
+// BlobHash names the blob holding the content; it is empty when the version
+// owns its object
+func (v *FileVersion) BlobHash() string {
+	if v.Encryption != nil {
+		return ""
+	}
+	return v.ContentHash
+}
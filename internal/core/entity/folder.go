@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Folder groups the files of one owner into a tree. A nil ParentID is the
+// owner's root. Folders only exist in the database, so moving one never
+// touches storage keys.
+type Folder struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID  string     `gorm:"type:varchar(64);not null;default:'default';index" json:"tenant_id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	User      User       `gorm:"foreignKey:UserID" json:"-"`
+	ParentID  *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	Name      string     `gorm:"type:varchar(255);not null" json:"name"`
+	IsDeleted bool       `gorm:"default:false" json:"is_deleted"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (f *Folder) TableName() string {
+	return "folders"
+}
+
+func (f *Folder) BeforeCreate(tx *gorm.DB) error {
+	f.ID = uuid.New()
+	return nil
+}
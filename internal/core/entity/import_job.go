@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+// ImportRowError describes why a single row of an import was rejected
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Email   string `json:"email,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportJob tracks an asynchronous bulk user import
+type ImportJob struct {
+	gorm.Model
+	TenantID      string           `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index"`
+	CreatedBy     uint             `json:"created_by" gorm:"not null;index"`
+	Status        string           `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Format        string           `json:"format" gorm:"type:varchar(10);not null"`
+	FileName      string           `json:"file_name" gorm:"type:varchar(255)"`
+	SendInvites   bool             `json:"send_invites" gorm:"default:false"`
+	TotalRows     int              `json:"total_rows" gorm:"default:0"`
+	SucceededRows int              `json:"succeeded_rows" gorm:"default:0"`
+	FailedRows    int              `json:"failed_rows" gorm:"default:0"`
+	RowErrors     []ImportRowError `json:"row_errors" gorm:"type:jsonb;serializer:json"`
+	Message       string           `json:"message,omitempty" gorm:"type:text"`
+	Payload       []byte           `json:"-" gorm:"type:bytea"`
+}
+
+func (j *ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+func (j *ImportJob) ToJson() ([]byte, error) {
+	return json.Marshal(j)
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationCategoryAccount = "account"
+	NotificationCategoryFile    = "file"
+	NotificationCategoryAdmin   = "admin"
+)
+
+// NotificationChannels says through which channels a category is delivered
+type NotificationChannels struct {
+	Email bool `json:"email"`
+	InApp bool `json:"in_app"`
+}
+
+// DefaultNotificationChannels applies to users that never changed their preferences
+var DefaultNotificationChannels = map[string]NotificationChannels{
+	NotificationCategoryAccount: {Email: true, InApp: true},
+	NotificationCategoryFile:    {Email: false, InApp: true},
+	NotificationCategoryAdmin:   {Email: true, InApp: true},
+}
+
+// IsMandatoryEmail reports whether a category is always emailed regardless of
+// preferences, as account emails carry confirmation and reset links
+func IsMandatoryEmail(category string) bool {
+	return category == NotificationCategoryAccount
+}
+
+// Notification is a message in a user's in-app inbox
+type Notification struct {
+	gorm.Model
+	TenantID string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index"`
+	UserID   uint       `json:"user_id" gorm:"not null;index:idx_notification_user_read"`
+	User     User       `json:"-" gorm:"foreignKey:UserID"`
+	Category string     `json:"category" gorm:"type:varchar(32);not null"`
+	Title    string     `json:"title" gorm:"type:varchar(255);not null"`
+	Body     string     `json:"body" gorm:"type:text"`
+	ReadAt   *time.Time `json:"read_at" gorm:"index:idx_notification_user_read"`
+}
+
+func (n *Notification) TableName() string {
+	return "notifications"
+}
+
+func (n *Notification) ToJson() ([]byte, error) {
+	return json.Marshal(n)
+}
+
+// NotificationPreference overrides the default channels of one category for a user
+type NotificationPreference struct {
+	gorm.Model
+	TenantID string `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index"`
+	UserID   uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_preference_user_category"`
+	User     User   `json:"-" gorm:"foreignKey:UserID"`
+	Category string `json:"category" gorm:"type:varchar(32);not null;uniqueIndex:idx_notification_preference_user_category"`
+	Email    bool   `json:"email" gorm:"not null;default:true"`
+	InApp    bool   `json:"in_app" gorm:"not null;default:true"`
+}
+
+func (p *NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
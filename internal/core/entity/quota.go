@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuotaLimits caps stored bytes and file count; a zero limit is unlimited
+type QuotaLimits struct {
+	MaxBytes int64 `json:"max_bytes" gorm:"not null;default:0"`
+	MaxFiles int64 `json:"max_files" gorm:"not null;default:0"`
+}
+
+// Allows reports whether usage of bytes and files stays within the limits
+func (l QuotaLimits) Allows(bytes, files int64) bool {
+	if l.MaxBytes > 0 && bytes > l.MaxBytes {
+		return false
+	}
+	if l.MaxFiles > 0 && files > l.MaxFiles {
+		return false
+	}
+	return true
+}
+
+// QuotaPlan is a named set of limits assigned to users or to a whole tenant
+type QuotaPlan struct {
+	gorm.Model
+	TenantID    string `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_quota_plan_tenant_name"`
+	Name        string `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_quota_plan_tenant_name"`
+	QuotaLimits `gorm:"embedded"`
+}
+
+func (p *QuotaPlan) TableName() string {
+	return "quota_plans"
+}
+
+// OrgUsageUserID is the UserID of the row that holds a tenant's total usage
+const OrgUsageUserID = 0
+
+// StorageUsage is what a user, or with OrgUsageUserID the whole tenant, stores.
+// A user without a plan gets the configured default limits; a tenant without
+// one is unlimited.
+type StorageUsage struct {
+	ID        uint       `json:"-" gorm:"primaryKey"`
+	TenantID  string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_storage_usage_tenant_user"`
+	UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_storage_usage_tenant_user"`
+	PlanID    *uint      `json:"plan_id"`
+	Plan      *QuotaPlan `json:"plan,omitempty" gorm:"foreignKey:PlanID"`
+	Bytes     int64      `json:"bytes" gorm:"not null;default:0"`
+	Files     int64      `json:"files" gorm:"not null;default:0"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (u *StorageUsage) TableName() string {
+	return "storage_usages"
+}
+
+// Limits returns the limits that apply to the row, falling back to userDefault
+// for user rows without a plan
+func (u *StorageUsage) Limits(userDefault QuotaLimits) QuotaLimits {
+	if u.Plan != nil {
+		return u.Plan.QuotaLimits
+	}
+	if u.UserID == OrgUsageUserID {
+		return QuotaLimits{}
+	}
+	return userDefault
+}
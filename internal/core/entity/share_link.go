@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ShareAccessDownloaded  = "downloaded"
+	ShareAccessRevoked     = "revoked"
+	ShareAccessExpired     = "expired"
+	ShareAccessExhausted   = "exhausted"
+	ShareAccessBadPassword = "bad_password"
+	ShareAccessFileMissing = "file_missing"
+)
+
+// ShareLink lets anyone holding its token download a file without signing in.
+// Only the SHA-256 of the token is stored; the token itself is shown once.
+type ShareLink struct {
+	gorm.Model
+	TenantID     string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index"`
+	FileID       uuid.UUID  `json:"file_id" gorm:"type:uuid;not null;index"`
+	CreatedBy    uint       `json:"created_by" gorm:"not null"`
+	TokenHash    string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"` // hex
+	PasswordHash string     `json:"-" gorm:"type:varchar(255)"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	// MaxDownloads of zero allows any number of downloads
+	MaxDownloads  int        `json:"max_downloads" gorm:"not null;default:0"`
+	DownloadCount int        `json:"download_count" gorm:"not null;default:0"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+}
+
+func (l *ShareLink) TableName() string {
+	return "share_links"
+}
+
+// Expired reports whether the link's expiry has passed at now
+func (l *ShareLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// Exhausted reports whether the link has no downloads left
+func (l *ShareLink) Exhausted() bool {
+	return l.MaxDownloads > 0 && l.DownloadCount >= l.MaxDownloads
+}
+
+// ShareLinkAccess records one attempt to download through a share link
+type ShareLinkAccess struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	TenantID    string    `json:"-" gorm:"type:varchar(64);not null;default:'default'"`
+	ShareLinkID uint      `json:"share_link_id" gorm:"not null;index:idx_share_link_access_link"`
+	Outcome     string    `json:"outcome" gorm:"type:varchar(20);not null"`
+	IP          string    `json:"ip" gorm:"type:varchar(64)"`
+	UserAgent   string    `json:"user_agent" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index:idx_share_link_access_link"`
+}
+
+func (a *ShareLinkAccess) TableName() string {
+	return "share_link_accesses"
+}
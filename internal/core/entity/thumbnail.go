@@ -0,0 +1,16 @@
+package entity
+
+// Thumbnail is a resized copy of an image file's current content. It is
+// re-encoded from the decoded pixels, so none of the original's metadata,
+// EXIF and GPS included, carries over.
+type Thumbnail struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+	// Encryption follows the image's: thumbnails of encrypted content are
+	// encrypted too
+	Encryption *Encryption `json:"encryption,omitempty"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	UploadStatusPending    = "pending"
+	UploadStatusVerifying  = "verifying"
+	UploadStatusReceiving  = "receiving"
+	UploadStatusCompleted  = "completed"
+	UploadStatusFailed     = "failed"
+	UploadStatusTerminated = "terminated"
+
+	UploadMethodPut  = "put"
+	UploadMethodPost = "post"
+	UploadMethodTus  = "tus"
+)
+
+// Upload is an upload the client sends straight to storage, or in chunks over
+// tus. The File row is only written once the whole object has arrived.
+type Upload struct {
+	gorm.Model
+	TenantID       string     `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';index"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	Method         string     `json:"method" gorm:"type:varchar(10);not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	FileName       string     `json:"file_name" gorm:"type:varchar(255);not null"`
+	ContentType    string     `json:"content_type" gorm:"type:varchar(100);not null"`
+	Size           int64      `json:"size" gorm:"not null"`
+	ChecksumSHA256 string     `json:"checksum_sha256" gorm:"type:varchar(64)"` // base64, as S3 reports it
+	Key            string     `json:"key" gorm:"type:varchar(255);not null;index"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index"`
+	FileID         *uuid.UUID `json:"file_id,omitempty" gorm:"type:uuid"`
+	// Offset counts the bytes received over tus so far: PartCount multipart
+	// parts plus PendingPartSize bytes kept aside until they fill a part
+	Offset          int64  `json:"offset" gorm:"not null;default:0"`
+	S3UploadID      string `json:"-" gorm:"type:varchar(1024)"`
+	PartCount       int32  `json:"-" gorm:"not null;default:0"`
+	PendingPartSize int64  `json:"-" gorm:"not null;default:0"`
+	// HashState is the SHA-256 state over the bytes stored in parts so far
+	HashState []byte `json:"-" gorm:"type:bytea"`
+}
+
+func (u *Upload) TableName() string {
+	return "uploads"
+}
+
+// Expired reports whether the presigned request for the upload can no longer be used
+func (u *Upload) Expired(now time.Time) bool {
+	return now.After(u.ExpiresAt)
+}
+
+// PendingPartKey is where bytes too few for a multipart part wait for the next chunk
+func (u *Upload) PendingPartKey() string {
+	return u.Key + ".part"
+}
@@ -6,14 +6,21 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	gorm.Model
-	UserName           string `json:"user_name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	TenantID           string `json:"tenant_id" gorm:"type:varchar(64);not null;default:'default';uniqueIndex:idx_user_tenant_user_name;uniqueIndex:idx_user_tenant_email;uniqueIndex:idx_user_tenant_identity,where:identity <> ''"`
+	UserName           string `json:"user_name" gorm:"type:varchar(100);not null;uniqueIndex:idx_user_tenant_user_name"`
 	FirstName          string `json:"first_name" gorm:"type:varchar(100);not null"`
 	LastName           string `json:"last_name" gorm:"type:varchar(100);not null"`
-	Email              string `json:"email" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Email              string `json:"email" gorm:"type:varchar(255);not null;uniqueIndex:idx_user_tenant_email"`
 	Password           string `json:"-" gorm:"type:varchar(255);not null"`
-	Identity           string `json:"identity" gorm:"type:varchar(20);not null;uniqueIndex"`
+	Identity           string `json:"identity" gorm:"type:varchar(20);not null;uniqueIndex:idx_user_tenant_identity,where:identity <> ''"`
+	Role               string `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
 	IsActive           bool   `json:"is_active" gorm:"default:false"`
 	ConfirmToken       string `gorm:"unique"`
 	ResetPasswordToken string `gorm:"type:varchar(255)"`
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"project-api/internal/core/common/utils"
 	"project-api/internal/infra/config"
@@ -26,6 +27,7 @@ func isExcludedRoute(path string) bool {
 	excludedRoutes := []*regexp.Regexp{
 		regexp.MustCompile(`^/$`),
 		regexp.MustCompile(`^/api/v1/auth/.*$`),
+		regexp.MustCompile(`^/api/v1/share/[^/]+$`),
 	}
 	for _, pattern := range excludedRoutes {
 		if pattern.MatchString(path) {
@@ -35,36 +37,66 @@ func isExcludedRoute(path string) bool {
 	return false
 }
 
+// isStreamRoute checks if a path is a browser event stream that may authenticate with a ticket in the query string
+func isStreamRoute(path string) bool {
+	return path == "/api/v1/events"
+}
+
 func JWTAuthMiddleware(c *fiber.Ctx) error { // เปลี่ยน signature เป็น Fiber's Middleware Handler
 	if isExcludedRoute(c.Path()) {
 		return c.Next() // ข้าม middleware ถ้าเป็น excluded route
 	}
 	// Get the authorization header
 	authHeader := c.Get("Authorization") // ใช้ c.Get() แทน r.Header.Get()
-	if authHeader == "" {
-		logger.Warn("Missing authorization header", zap.String("path", c.Path()))       // Log path context
-		return fiber.NewError(fiber.StatusUnauthorized, "Missing authorization header") // ใช้ fiber.NewError เพื่อ return error
-	}
-	parts := strings.Split(authHeader, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		logger.Warn("Invalid authorization header format", zap.String("authHeader", authHeader), zap.String("path", c.Path())) // Log authHeader context
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid authorization header format")                                 // ใช้ fiber.NewError เพื่อ return error
+	ticket := authHeader == "" && isStreamRoute(c.Path()) && c.Query("ticket") != ""
+	var tokenString string
+	if ticket {
+		// EventSource can't set headers, so event streams pass a short-lived
+		// ticket from POST /events/ticket in the query
+		tokenString = c.Query("ticket")
+	} else {
+		if authHeader == "" {
+			logger.Warn("Missing authorization header", zap.String("path", c.Path()))       // Log path context
+			return fiber.NewError(fiber.StatusUnauthorized, "Missing authorization header") // ใช้ fiber.NewError เพื่อ return error
+		}
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			logger.Warn("Invalid authorization header format", zap.String("path", c.Path()))
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid authorization header format") // ใช้ fiber.NewError เพื่อ return error
+		}
+		tokenString = parts[1]
 	}
-	tokenString := parts[1]
+	// Tokens are credentials, so they are never logged
 	token, err := jwt.ParseWithClaims(tokenString, &utils.UserClaims{}, func(t *jwt.Token) (interface{}, error) {
 		return []byte(config.Config.JWT.Signed), nil
 	})
 	if err != nil || !token.Valid {
-		logger.Warn("invalid or expired token", zap.Error(err), zap.String("token", tokenString), zap.String("path", c.Path())) // Log token context
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired token")                                             // ใช้ fiber.NewError เพื่อ return error
+		logger.Warn("invalid or expired token", zap.Error(err), zap.String("path", c.Path()))
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired token") // ใช้ fiber.NewError เพื่อ return error
 	}
 	claims, ok := token.Claims.(*utils.UserClaims)
 	if !ok {
-		logger.Error("invalid token claims", zap.String("token", tokenString), zap.String("path", c.Path())) // Log token and path context
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims")                              // ใช้ fiber.NewError เพื่อ return error
+		logger.Error("invalid token claims", zap.String("path", c.Path()))
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims") // ใช้ fiber.NewError เพื่อ return error
+	}
+	if claims.IsStreamTicket() != ticket {
+		logger.Warn("stream ticket used out of place", zap.Bool("ticket", ticket), zap.String("path", c.Path()))
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	if ticket && (claims.ExpiresAt == nil || !claims.ExpiresAt.After(time.Now())) {
+		logger.Warn("expired stream ticket", zap.Uint("userID", claims.UserID), zap.String("path", c.Path()))
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired token")
+	}
+	if claims.TenantID == "" {
+		claims.TenantID = config.Config.GetDefaultTenant()
+	}
+	if tenantID, ok := requestTenant(c); ok && tenantID != claims.TenantID {
+		logger.Warn("token tenant does not match request tenant", zap.String("tenant", tenantID), zap.String("tokenTenant", claims.TenantID), zap.String("path", c.Path()))
+		return fiber.NewError(fiber.StatusUnauthorized, "Token does not belong to this tenant")
 	}
 	// ใช้ c.Context() เพื่อเข้าถึง Go Context ของ Fiber
 	ctx := context.WithValue(c.UserContext(), utils.GetUserContextKey(), claims)
+	ctx = utils.WithTenantID(ctx, claims.TenantID)
 	c.SetUserContext(ctx) // Set Go Context ลง Fiber Context
 
 	return c.Next()
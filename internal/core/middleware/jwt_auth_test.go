@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	"project-api/internal/infra/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+func TestStreamTicketOnlyOpensTheStream(t *testing.T) {
+	saved := config.Config
+	config.Config = &config.AppConfig{}
+	config.Config.JWT.Signed = "test"
+	t.Cleanup(func() { config.Config = saved })
+
+	app := fiber.New()
+	app.Use(JWTAuthMiddleware)
+	user := func(c *fiber.Ctx) error {
+		claims, _ := utils.GetUserIDFromContext(c.UserContext())
+		return c.SendString(strconv.FormatUint(uint64(claims.UserID), 10))
+	}
+	app.Get("/api/v1/events", user)
+	app.Get("/api/v1/files", user)
+
+	tokens, err := utils.GenerateJWT(&entity.User{Model: gorm.Model{ID: 7}, TenantID: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket, _, err := utils.GenerateStreamTicket(&utils.UserClaims{UserID: 7, TenantID: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &utils.UserClaims{
+		UserID:    7,
+		TenantID:  "default",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Second)),
+		Audience:  []string{utils.StreamTicketAudience},
+	}).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, target, bearer string
+		status               int
+	}{
+		{"ticket on the stream", "/api/v1/events?ticket=" + ticket, "", fiber.StatusOK},
+		{"bearer on the stream", "/api/v1/events", tokens.AccessToken, fiber.StatusOK},
+		{"expired ticket", "/api/v1/events?ticket=" + expired, "", fiber.StatusUnauthorized},
+		{"access token in the query", "/api/v1/events?ticket=" + tokens.AccessToken, "", fiber.StatusUnauthorized},
+		{"old access_token query", "/api/v1/events?access_token=" + tokens.AccessToken, "", fiber.StatusUnauthorized},
+		{"ticket as a bearer token", "/api/v1/files", ticket, fiber.StatusUnauthorized},
+		{"ticket on another route", "/api/v1/files?ticket=" + ticket, "", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.target, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == fiber.StatusOK && string(body) != "7" {
+				t.Fatalf("user = %q, want 7", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"project-api/internal/core/common/utils"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// RequireRole only lets through authenticated requests whose token carries role.
+// It must run after JWTAuthMiddleware.
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := utils.GetUserIDFromContext(c.UserContext())
+		if !ok {
+			logger.Warn("Missing user claims for role check", zap.String("path", c.Path()))
+			return fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+		}
+		if claims.Role != role {
+			logger.Warn("Insufficient role",
+				zap.Uint("userID", claims.UserID),
+				zap.String("role", claims.Role),
+				zap.String("required", role),
+				zap.String("path", c.Path()))
+			return fiber.NewError(fiber.StatusForbidden, "Insufficient permissions")
+		}
+		return c.Next()
+	}
+}
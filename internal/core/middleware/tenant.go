@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"regexp"
+	"strings"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+var validTenantID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// requestTenant resolves the tenant named by the request itself, through the
+// tenant header, the tenant query parameter of links or the subdomain of the
+// host. ok is false when the request does not name a tenant.
+func requestTenant(c *fiber.Ctx) (string, bool) {
+	if tenantID := strings.ToLower(strings.TrimSpace(c.Get(config.Config.GetTenantHeader()))); tenantID != "" {
+		return tenantID, true
+	}
+	if tenantID := strings.ToLower(strings.TrimSpace(c.Query(utils.TenantQueryParam))); tenantID != "" {
+		return tenantID, true
+	}
+	suffix := config.Config.Tenant.HostSuffix
+	if suffix == "" {
+		return "", false
+	}
+	host := strings.ToLower(c.Hostname())
+	if i := strings.IndexByte(host, ':'); i >= 0 {
+		host = host[:i]
+	}
+	if !strings.HasSuffix(host, suffix) {
+		return "", false
+	}
+	tenantID := strings.TrimSuffix(host, suffix)
+	if tenantID == "" || strings.Contains(tenantID, ".") {
+		return "", false
+	}
+	return tenantID, true
+}
+
+// TenantMiddleware stores the tenant of the request in the user context so the
+// repository layer can scope every query to it. Authenticated routes later
+// replace it with the tenant carried by the JWT claims.
+func TenantMiddleware(c *fiber.Ctx) error {
+	tenantID, ok := requestTenant(c)
+	if !ok {
+		tenantID = config.Config.GetDefaultTenant()
+	}
+	if !validTenantID.MatchString(tenantID) {
+		logger.Warn("Invalid tenant identifier", zap.String("tenant", tenantID), zap.String("path", c.Path()))
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid tenant identifier")
+	}
+	c.SetUserContext(utils.WithTenantID(c.UserContext(), tenantID))
+	return c.Next()
+}
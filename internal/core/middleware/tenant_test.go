@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/infra/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTenantMiddlewareResolvesTenant(t *testing.T) {
+	saved := config.Config
+	config.Config = &config.AppConfig{}
+	t.Cleanup(func() { config.Config = saved })
+
+	app := fiber.New()
+	app.Use(TenantMiddleware)
+	app.Get("/", func(c *fiber.Ctx) error {
+		tenantID, _ := utils.GetTenantIDFromContext(c.UserContext())
+		return c.SendString(tenantID)
+	})
+
+	tests := []struct {
+		name, target, header, want string
+	}{
+		{"default", "/", "", "default"},
+		{"header", "/", "acme", "acme"},
+		{"link", "/?tenant=Acme", "", "acme"},
+		{"header over link", "/?tenant=other", "acme", "acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Fatalf("tenant = %q, want %q", body, tt.want)
+			}
+		})
+	}
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?tenant=..%2Fother", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("invalid tenant got status %d", resp.StatusCode)
+	}
+}
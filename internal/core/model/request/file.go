@@ -0,0 +1,94 @@
+package request
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// PresignUploadRequest declares the file a client is about to send straight
+// to storage; the presigned request only accepts exactly this file
+type PresignUploadRequest struct {
+	FileName       string `json:"file_name" validate:"required,max=255"`
+	ContentType    string `json:"content_type" validate:"required,max=100"`
+	Size           int64  `json:"size" validate:"required,gt=0"`
+	ChecksumSHA256 string `json:"checksum_sha256" validate:"required"` // hex or base64
+	Method         string `json:"method" validate:"omitempty,oneof=put post"`
+}
+
+func (r *PresignUploadRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// UpdateFileTagsRequest replaces every tag of a file
+type UpdateFileTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// CreateShareLinkRequest sets the limits of a new share link; zero values mean
+// no expiry, no password and unlimited downloads
+type CreateShareLinkRequest struct {
+	ExpiresIn    int    `json:"expires_in" validate:"gte=0"` // seconds
+	Password     string `json:"password" validate:"omitempty,min=4,max=72"`
+	MaxDownloads int    `json:"max_downloads" validate:"gte=0"`
+}
+
+func (r *CreateShareLinkRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// GrantFilePermissionRequest opens a file to one user or, with org, to every
+// user of the tenant
+type GrantFilePermissionRequest struct {
+	UserID uint   `json:"user_id" validate:"required_without=Org,excluded_with=Org"`
+	Org    bool   `json:"org"`
+	Access string `json:"access" validate:"required,oneof=read write"`
+}
+
+func (r *GrantFilePermissionRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// CreateFolderRequest creates a folder under parent_id, or at the root
+type CreateFolderRequest struct {
+	Name     string  `json:"name" validate:"required,max=255"`
+	ParentID *string `json:"parent_id" validate:"omitempty,uuid"`
+}
+
+func (r *CreateFolderRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// RenameRequest renames a file or a folder
+type RenameRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+func (r *RenameRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// MoveRequest moves a file or a folder into folder_id; null moves it to the root
+type MoveRequest struct {
+	FolderID *string `json:"folder_id" validate:"omitempty,uuid"`
+}
+
+func (r *MoveRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// DownloadArchiveRequest picks the files of a ZIP download; name is the
+// archive's file name, without the .zip extension
+type DownloadArchiveRequest struct {
+	FileIDs []string `json:"file_ids" validate:"required,min=1,max=1000,dive,uuid"`
+	Name    string   `json:"name" validate:"omitempty,max=200"`
+}
+
+func (r *DownloadArchiveRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
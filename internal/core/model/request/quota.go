@@ -0,0 +1,23 @@
+package request
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// CreateQuotaPlanRequest defines a quota plan; a zero limit is unlimited
+type CreateQuotaPlanRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	MaxBytes int64  `json:"max_bytes" validate:"gte=0"`
+	MaxFiles int64  `json:"max_files" validate:"gte=0"`
+}
+
+func (r *CreateQuotaPlanRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// AssignQuotaPlanRequest sets the plan of a user or tenant; a null plan_id
+// falls back to the default limits
+type AssignQuotaPlanRequest struct {
+	PlanID *uint `json:"plan_id"`
+}
@@ -0,0 +1,28 @@
+package request
+
+// ImportUserRow is a single user record of a bulk import file. CSV columns and
+// JSON-lines keys use the same names as the registration request.
+type ImportUserRow struct {
+	UserName  string `json:"username"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Password  string `json:"password"`
+}
+
+// ToRegisterRequest converts the row so it can be checked with the same rules
+// as a self-service registration
+func (r *ImportUserRow) ToRegisterRequest() *RegisterRequest {
+	return &RegisterRequest{
+		LoginRequest: LoginRequest{
+			UserName: r.UserName,
+			Password: r.Password,
+		},
+		EmailRequest: EmailRequest{
+			Email: r.Email,
+		},
+		FirstName:       r.FirstName,
+		LastName:        r.LastName,
+		PasswordConfirm: r.Password,
+	}
+}
@@ -1,5 +1,7 @@
 package response
 
+import "time"
+
 type LoginResponse struct {
 	AccessToken string `json:"access_token"`
 }
@@ -8,3 +10,9 @@ type RegisterResponse struct {
 	Email    string `json:"email"`
 	Password string `json:"-"`
 }
+
+// StreamTicket opens GET /events?ticket=... until ExpiresAt
+type StreamTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
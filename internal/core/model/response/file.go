@@ -0,0 +1,275 @@
+package response
+
+import (
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+	"project-api/internal/core/port/service"
+)
+
+// PresignedUpload tells the client where to send a file and which upload to
+// confirm once it has been sent
+type PresignedUpload struct {
+	UploadID uint `json:"upload_id"`
+	*repository.PresignedRequest
+}
+
+// FileItem is the public view of a stored file
+type FileItem struct {
+	ID          string `json:"id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Version     int    `json:"version"`
+	// ScanStatus is clean once the content may be downloaded
+	ScanStatus  string   `json:"scan_status"`
+	FolderID    *string  `json:"folder_id"`
+	ContentHash string   `json:"content_hash,omitempty"`
+	Tags        []string `json:"tags"`
+	// Thumbnails are downloaded from /files/:id/thumbnails/:name and, unlike
+	// the file itself, hold no EXIF or GPS data
+	Thumbnails []ThumbnailItem `json:"thumbnails"`
+	CreatedAt  time.Time       `json:"created_at"`
+	// TrashedAt is only set on files in the trash
+	TrashedAt *time.Time `json:"trashed_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// FilePage is one page of a file listing
+type FilePage struct {
+	Items      []FileItem `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+func NewFileItem(file *entity.File) FileItem {
+	tags := file.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	item := FileItem{
+		ID:          file.ID.String(),
+		FileName:    file.FileName,
+		ContentType: file.FileType,
+		Size:        file.FileSize,
+		Version:     file.Version,
+		ScanStatus:  file.ScanStatus,
+		ContentHash: file.ContentHash,
+		Tags:        tags,
+		CreatedAt:   file.CreatedAt,
+		TrashedAt:   file.TrashedAt,
+		ExpiresAt:   file.ExpiresAt,
+	}
+	if file.FolderID != nil {
+		folderID := file.FolderID.String()
+		item.FolderID = &folderID
+	}
+	item.Thumbnails = make([]ThumbnailItem, len(file.Thumbnails))
+	for i, thumb := range file.Thumbnails {
+		item.Thumbnails[i] = ThumbnailItem{
+			Name:        thumb.Name,
+			ContentType: thumb.ContentType,
+			Width:       thumb.Width,
+			Height:      thumb.Height,
+			Size:        thumb.Size,
+		}
+	}
+	return item
+}
+
+// ThumbnailItem describes one generated thumbnail of an image file
+type ThumbnailItem struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+func NewFilePage(result *repository.FileListResult) *FilePage {
+	page := &FilePage{
+		Items:      make([]FileItem, 0, len(result.Files)),
+		NextCursor: result.NextCursor,
+	}
+	for i := range result.Files {
+		page.Items = append(page.Items, NewFileItem(&result.Files[i]))
+	}
+	return page
+}
+
+// VersionItem is one version of a file; UploadedAt is when that content arrived
+type VersionItem struct {
+	Version     int       `json:"version"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	ContentHash string    `json:"content_hash,omitempty"`
+	Current     bool      `json:"current"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// NewVersionList puts the current version of file ahead of its history
+func NewVersionList(file *entity.File, history []entity.FileVersion) []VersionItem {
+	items := make([]VersionItem, 0, len(history)+1)
+	items = append(items, VersionItem{
+		Version:     file.Version,
+		ContentType: file.FileType,
+		Size:        file.FileSize,
+		ContentHash: file.ContentHash,
+		Current:     true,
+		UploadedAt:  file.UploadedAt,
+	})
+	for _, version := range history {
+		items = append(items, VersionItem{
+			Version:     version.Version,
+			ContentType: version.FileType,
+			Size:        version.FileSize,
+			ContentHash: version.ContentHash,
+			UploadedAt:  version.CreatedAt,
+		})
+	}
+	return items
+}
+
+// ShareLinkItem is the owner's view of a share link; the token is never listed
+type ShareLinkItem struct {
+	ID            uint       `json:"id"`
+	FileID        string     `json:"file_id"`
+	HasPassword   bool       `json:"has_password"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	MaxDownloads  int        `json:"max_downloads"`
+	DownloadCount int        `json:"download_count"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// CreatedShareLink carries the token of a new link, the only time it is shown
+type CreatedShareLink struct {
+	ShareLinkItem
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func NewShareLinkItem(link *entity.ShareLink) ShareLinkItem {
+	return ShareLinkItem{
+		ID:            link.ID,
+		FileID:        link.FileID.String(),
+		HasPassword:   link.PasswordHash != "",
+		ExpiresAt:     link.ExpiresAt,
+		MaxDownloads:  link.MaxDownloads,
+		DownloadCount: link.DownloadCount,
+		RevokedAt:     link.RevokedAt,
+		CreatedAt:     link.CreatedAt,
+	}
+}
+
+// ShareLinkAccessItem is one download attempt through a share link
+type ShareLinkAccessItem struct {
+	ID        uint      `json:"id"`
+	Outcome   string    `json:"outcome"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewShareLinkAccessList(accesses []entity.ShareLinkAccess) []ShareLinkAccessItem {
+	items := make([]ShareLinkAccessItem, 0, len(accesses))
+	for _, access := range accesses {
+		items = append(items, ShareLinkAccessItem{
+			ID:        access.ID,
+			Outcome:   access.Outcome,
+			IP:        access.IP,
+			UserAgent: access.UserAgent,
+			CreatedAt: access.CreatedAt,
+		})
+	}
+	return items
+}
+
+// FilePermissionItem is the owner's view of a grant on a file. GranteeID is
+// zero for a grant to the whole tenant.
+type FilePermissionItem struct {
+	ID          uint      `json:"id"`
+	FileID      string    `json:"file_id"`
+	GranteeType string    `json:"grantee_type"`
+	GranteeID   uint      `json:"grantee_id"`
+	Access      string    `json:"access"`
+	GrantedBy   uint      `json:"granted_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func NewFilePermissionItem(permission *entity.FilePermission) FilePermissionItem {
+	return FilePermissionItem{
+		ID:          permission.ID,
+		FileID:      permission.FileID.String(),
+		GranteeType: permission.GranteeType,
+		GranteeID:   permission.GranteeID,
+		Access:      permission.Access,
+		GrantedBy:   permission.GrantedBy,
+		CreatedAt:   permission.CreatedAt,
+		UpdatedAt:   permission.UpdatedAt,
+	}
+}
+
+func NewFilePermissionList(permissions []entity.FilePermission) []FilePermissionItem {
+	items := make([]FilePermissionItem, 0, len(permissions))
+	for i := range permissions {
+		items = append(items, NewFilePermissionItem(&permissions[i]))
+	}
+	return items
+}
+
+// FolderItem is the public view of a folder
+type FolderItem struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  *string   `json:"parent_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Breadcrumb is one step of the path from the root to a folder
+type Breadcrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// FolderContents is one level of the caller's tree. Folder is null and
+// Breadcrumbs empty at the root.
+type FolderContents struct {
+	Folder      *FolderItem  `json:"folder"`
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs"`
+	Folders     []FolderItem `json:"folders"`
+	Files       *FilePage    `json:"files"`
+}
+
+func NewFolderItem(folder *entity.Folder) FolderItem {
+	item := FolderItem{
+		ID:        folder.ID.String(),
+		Name:      folder.Name,
+		CreatedAt: folder.CreatedAt,
+	}
+	if folder.ParentID != nil {
+		parentID := folder.ParentID.String()
+		item.ParentID = &parentID
+	}
+	return item
+}
+
+func NewFolderContents(contents *service.FolderContents) *FolderContents {
+	result := &FolderContents{
+		Breadcrumbs: make([]Breadcrumb, 0, len(contents.Breadcrumbs)),
+		Folders:     make([]FolderItem, 0, len(contents.Folders)),
+		Files:       NewFilePage(contents.Files),
+	}
+	if contents.Folder != nil {
+		folder := NewFolderItem(contents.Folder)
+		result.Folder = &folder
+	}
+	for _, crumb := range contents.Breadcrumbs {
+		result.Breadcrumbs = append(result.Breadcrumbs, Breadcrumb{ID: crumb.ID.String(), Name: crumb.Name})
+	}
+	for i := range contents.Folders {
+		result.Folders = append(result.Folders, NewFolderItem(&contents.Folders[i]))
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package response
+
+import "project-api/internal/core/port/repository"
+
+// UserSearchItem is the public view of a user returned by search
+type UserSearchItem struct {
+	ID        uint    `json:"id"`
+	UserName  string  `json:"user_name"`
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Email     string  `json:"email"`
+	IsActive  bool    `json:"is_active"`
+	Score     float64 `json:"score"`
+}
+
+// UserSearchPage is one page of search results
+type UserSearchPage struct {
+	Items      []UserSearchItem `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// UserDirectoryItem is what any user may see of another: no email or status
+type UserDirectoryItem struct {
+	ID        uint    `json:"id"`
+	UserName  string  `json:"user_name"`
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Score     float64 `json:"score"`
+}
+
+// UserDirectoryPage is one page of directory search results
+type UserDirectoryPage struct {
+	Items      []UserDirectoryItem `json:"items"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+func NewUserSearchPage(result *repository.UserSearchResult) *UserSearchPage {
+	page := &UserSearchPage{
+		Items:      make([]UserSearchItem, 0, len(result.Hits)),
+		NextCursor: result.NextCursor,
+	}
+	for _, hit := range result.Hits {
+		page.Items = append(page.Items, UserSearchItem{
+			ID:        hit.User.ID,
+			UserName:  hit.User.UserName,
+			FirstName: hit.User.FirstName,
+			LastName:  hit.User.LastName,
+			Email:     hit.User.Email,
+			IsActive:  hit.User.IsActive,
+			Score:     hit.Score,
+		})
+	}
+	return page
+}
+
+func NewUserDirectoryPage(result *repository.UserSearchResult) *UserDirectoryPage {
+	page := &UserDirectoryPage{
+		Items:      make([]UserDirectoryItem, 0, len(result.Hits)),
+		NextCursor: result.NextCursor,
+	}
+	for _, hit := range result.Hits {
+		page.Items = append(page.Items, UserDirectoryItem{
+			ID:        hit.User.ID,
+			UserName:  hit.User.UserName,
+			FirstName: hit.User.FirstName,
+			LastName:  hit.User.LastName,
+			Score:     hit.Score,
+		})
+	}
+	return page
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"project-api/internal/core/entity"
+)
+
+type IBlobRepository interface {
+	// Create stores a new blob, or adds a reference when the tenant already
+	// has the hash and then fills blob with the existing row
+	Create(ctx context.Context, blob *entity.Blob) error
+	// AddRef adds a reference to an existing blob and fails with
+	// gorm.ErrRecordNotFound when there is none
+	AddRef(ctx context.Context, hash string) (*entity.Blob, error)
+	// Release drops a reference and deletes the row with the last one; the
+	// returned flag tells the caller to delete the object too
+	Release(ctx context.Context, hash string) (*entity.Blob, bool, error)
+}
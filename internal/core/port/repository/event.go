@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Event is a real-time message pushed to the connections of one user
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// IEventBus delivers events to a user's live connections, whichever process
+// publishes them. The tenant is taken from ctx.
+type IEventBus interface {
+	Publish(ctx context.Context, userID uint, eventType string, data interface{}) error
+	Subscribe(ctx context.Context, userID uint) (<-chan Event, func(), error)
+	Close() error
+}
@@ -2,16 +2,85 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"project-api/internal/core/entity"
 	"project-api/internal/core/port/utils"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	FileSortCreatedAt = "created_at"
+	FileSortName      = "name"
+	FileSortSize      = "size"
+)
+
+var ErrInvalidSort = errors.New("sort must be one of created_at, name or size")
+
+// FileListQuery filters and orders the files of one user; zero values mean no filter
+type FileListQuery struct {
+	UserID  uint
+	Cursor  string
+	Limit   int
+	Sort    string
+	Desc    bool
+	Name    string // substring of the file name
+	Type    string // MIME type, or a prefix such as "image/"
+	Tag     string
+	MinSize int64
+	MaxSize int64
+	From    time.Time
+	To      time.Time
+	// SharedWith lists the files other users granted UserID access to
+	// instead of the ones UserID owns
+	SharedWith bool
+	// Folder keeps the files directly inside a folder, uuid.Nil being the root
+	Folder *uuid.UUID
+	// Trashed lists the deleted files of UserID that can still be restored
+	Trashed bool
+}
+
+// FileListResult is one page of files; NextCursor is empty on the last page
+type FileListResult struct {
+	Files      []entity.File
+	NextCursor string
+}
+
 type IFileRepository interface {
 	utils.BaseInterface[entity.File]
 	BeginTransaction(ctx context.Context) *gorm.DB
 	FindByKey(ctx context.Context, key string, file *entity.File) error
+	FindByID(ctx context.Context, id string, file *entity.File) error
 	FindByKeyForUpdate(ctx context.Context, key string, file *entity.File) error // New: with lock
 	Update(ctx context.Context, file *entity.File) error
+	// UpdateColumns writes only the named columns of file, leaving what others
+	// changed since it was read
+	UpdateColumns(ctx context.Context, file *entity.File, columns ...string) error
+	List(ctx context.Context, query FileListQuery) (*FileListResult, error)
+	// Restore takes a file out of the trash; a file whose folder is gone
+	// comes back at the root
+	Restore(ctx context.Context, id string) (*entity.File, error)
+	// PurgeTrashed removes, across every tenant, up to limit files trashed
+	// before the given time along with their grants, and returns them so
+	// their content can be released
+	PurgeTrashed(ctx context.Context, before time.Time, limit int) ([]entity.File, error)
+	// PurgeExpired does the same for files, trashed or not, whose expiry has passed
+	PurgeExpired(ctx context.Context, now time.Time, limit int) ([]entity.File, error)
+	// ClaimUnscanned marks up to limit files of any tenant, oldest first, as
+	// being scanned and returns them. It takes pending files, and files whose
+	// scan started before staleBefore and never finished, skipping those
+	// another worker is claiming at the same time.
+	ClaimUnscanned(ctx context.Context, staleBefore time.Time, limit int) ([]entity.File, error)
+	// FinishScan records the verdict on a claimed file. It reports
+	// gorm.ErrRecordNotFound when the content changed since the claim, so
+	// the verdict no longer applies. An infected file is taken out of the
+	// owner's files and the trash.
+	FinishScan(ctx context.Context, file *entity.File, status, result string) error
+	// SetThumbnails records the thumbnails of a live file while its current
+	// version is still version, or reports gorm.ErrRecordNotFound
+	SetThumbnails(ctx context.Context, id uuid.UUID, version int, thumbs []entity.Thumbnail) error
+	// SetTags replaces the tags of a file and leaves the rest of it alone
+	SetTags(ctx context.Context, id uuid.UUID, tags []string) error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"project-api/internal/core/entity"
+)
+
+type IFilePermissionRepository interface {
+	// Grant creates the permission, or replaces the access of an existing
+	// grant to the same grantee
+	Grant(ctx context.Context, permission *entity.FilePermission) error
+	ListByFile(ctx context.Context, fileID string) ([]entity.FilePermission, error)
+	// Revoke deletes a permission of fileID and fails with
+	// gorm.ErrRecordNotFound when there is no such permission
+	Revoke(ctx context.Context, fileID string, id uint) error
+	// AccessFor returns the widest access userID has on fileID through user
+	// and org grants, or an empty string
+	AccessFor(ctx context.Context, fileID string, userID uint) (string, error)
+}
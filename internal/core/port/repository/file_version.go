@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"project-api/internal/core/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrContentUnscanned is a push onto content that has not passed its virus
+// scan; the history only keeps clean content, since it is downloaded
+// without a scan status of its own
+var ErrContentUnscanned = errors.New("current content has not passed its virus scan")
+
+// PrunedVersion is a history entry removed by retention, with the owner of
+// its file so the quota can be given back
+type PrunedVersion struct {
+	entity.FileVersion
+	OwnerID uint
+}
+
+type IFileVersionRepository interface {
+	// Push makes next the current content of a live file and moves the
+	// content it replaces into the history, in one transaction. next.Version
+	// is ignored; the returned file carries the new version number. The new
+	// content starts with scanStatus and without thumbnails. A file whose
+	// content is not clean fails with ErrContentUnscanned.
+	Push(ctx context.Context, fileID uuid.UUID, next *entity.FileVersion, scanStatus string) (*entity.File, error)
+	// List returns the history of a file, newest first
+	List(ctx context.Context, fileID string) ([]entity.FileVersion, error)
+	Find(ctx context.Context, fileID string, version int) (*entity.FileVersion, error)
+	// DeleteByFile removes the whole history of a file and returns it
+	DeleteByFile(ctx context.Context, fileID uuid.UUID) ([]entity.FileVersion, error)
+	// Prune removes, across every tenant, the history entries beyond the keep
+	// newest of each file and those uploaded before olderThan. A zero keep or
+	// olderThan disables that rule.
+	Prune(ctx context.Context, keep int, olderThan time.Time) ([]PrunedVersion, error)
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"project-api/internal/core/entity"
+
+	"github.com/google/uuid"
+)
+
+type IFolderRepository interface {
+	Create(ctx context.Context, folder *entity.Folder) error
+	Update(ctx context.Context, folder *entity.Folder) error
+	// UpdateColumns writes only the named columns of folder
+	UpdateColumns(ctx context.Context, folder *entity.Folder, columns ...string) error
+	// FindByID returns a live folder
+	FindByID(ctx context.Context, id string) (*entity.Folder, error)
+	// FindChild returns the live folder called name under parentID, nil being
+	// the root of userID
+	FindChild(ctx context.Context, userID uint, parentID *uuid.UUID, name string) (*entity.Folder, error)
+	ListChildren(ctx context.Context, userID uint, parentID *uuid.UUID) ([]entity.Folder, error)
+	// Ancestors returns the path from the root down to and including id
+	Ancestors(ctx context.Context, id uuid.UUID) ([]entity.Folder, error)
+	// DeleteTree marks a folder and its subfolders as deleted and moves every
+	// file in them to the trash, in one transaction
+	DeleteTree(ctx context.Context, id uuid.UUID) error
+}
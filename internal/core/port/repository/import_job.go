@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/utils"
+)
+
+type IImportJobRepository interface {
+	utils.BaseInterface[entity.ImportJob]
+	// Transition moves a job from one status to another and fails with
+	// gorm.ErrRecordNotFound when it is no longer in the from status
+	Transition(ctx context.Context, id uint, from, to string) error
+}
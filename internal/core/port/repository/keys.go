@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrKeyUnwrap is a wrapped key that its key encryption key does not open
+var ErrKeyUnwrap = errors.New("failed to unwrap data key")
+
+// IKeyProvider holds the key encryption keys that wrap data keys. The keys
+// never leave the provider, so a KMS can stand behind it.
+type IKeyProvider interface {
+	// WrapKey encrypts a data key under the key named keyID
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key wrapped by WrapKey under the same keyID
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
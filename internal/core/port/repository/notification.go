@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/utils"
+)
+
+type INotificationRepository interface {
+	utils.BaseInterface[entity.Notification]
+	ListByUser(ctx context.Context, userID uint, unreadOnly bool, beforeID uint, limit int) ([]entity.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID uint, id uint) error
+	MarkAllRead(ctx context.Context, userID uint) (int64, error)
+}
+
+type INotificationPreferenceRepository interface {
+	ListByUser(ctx context.Context, userID uint) ([]entity.NotificationPreference, error)
+	Upsert(ctx context.Context, preference *entity.NotificationPreference) error
+}
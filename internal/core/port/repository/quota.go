@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"project-api/internal/core/entity"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+type IQuotaRepository interface {
+	CreatePlan(ctx context.Context, plan *entity.QuotaPlan) error
+	GetPlan(ctx context.Context, id uint) (*entity.QuotaPlan, error)
+	ListPlans(ctx context.Context) ([]entity.QuotaPlan, error)
+	// AssignPlan sets the plan of userID, or of the tenant with
+	// entity.OrgUsageUserID; a nil planID removes it
+	AssignPlan(ctx context.Context, userID uint, planID *uint) error
+	// GetUsage returns the usage of userID with its plan; a user that never
+	// stored anything gets an empty row
+	GetUsage(ctx context.Context, userID uint) (*entity.StorageUsage, error)
+	// Reserve adds bytes and files to the usage of userID and of the tenant in
+	// one transaction, or fails with ErrQuotaExceeded and changes nothing
+	Reserve(ctx context.Context, userID uint, bytes, files int64, userDefault entity.QuotaLimits) error
+	// Release takes bytes and files back from the usage of userID and of the tenant
+	Release(ctx context.Context, userID uint, bytes, files int64) error
+	// Reconcile recomputes the usage of the tenant in ctx from the files table
+	Reconcile(ctx context.Context) error
+	// ReconcileAll recomputes the usage of every tenant; it is meant for the
+	// worker, which has no tenant of its own
+	ReconcileAll(ctx context.Context) error
+}
@@ -1,13 +1,73 @@
 package repository
 
 import (
-	"mime/multipart"
+	"context"
+	"errors"
+	"io"
 	"time"
 )
 
+var ErrObjectNotFound = errors.New("object not found in storage")
+
+// ErrPresignUnsupported is returned by backends clients can't reach directly,
+// which have no presigned requests to hand out
+var ErrPresignUnsupported = errors.New("storage backend does not support presigned requests")
+
+// MinPartSize is the smallest part S3 accepts in a multipart upload, except for the last one
+const MinPartSize = 5 << 20 // 5MB
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key            string
+	Size           int64
+	ContentType    string
+	ETag           string
+	ChecksumSHA256 string // base64, empty when the object was stored without one
+	LastModified   time.Time
+}
+
+// PresignUploadInput pins down the object a client may upload with a presigned request
+type PresignUploadInput struct {
+	Key            string
+	ContentType    string
+	Size           int64
+	ChecksumSHA256 string // base64
+	TTL            time.Duration
+}
+
+// PresignedRequest is a signed request a client sends straight to storage.
+// Headers must be sent as they are; Fields are the form fields of a POST
+// policy upload and go before the file part.
+type PresignedRequest struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// IS3Repository stores objects as streams so that memory use does not depend on
+// the object size
 type IS3Repository interface {
-	UploadFile(file *multipart.FileHeader, expir *time.Duration) (string, error)
-	DeleteFile(key string) error
-	DownloadFile(key string) ([]byte, error)
-	UploadMultipleFiles(files []*multipart.FileHeader, expir *time.Duration) ([]string, error)
+	UploadFile(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error)
+	DownloadFile(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// DownloadRange streams length bytes of an object starting at offset
+	DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	DeleteFile(ctx context.Context, key string) error
+	CopyFile(ctx context.Context, srcKey, dstKey string) error
+	StatFile(ctx context.Context, key string) (*ObjectInfo, error)
+	FileURL(key string) string
+	// ListObjects calls fn for every object whose key starts with prefix, in
+	// no guaranteed order; ContentType and ChecksumSHA256 may be left empty.
+	// An error from fn stops the listing and is returned.
+	ListObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+	PresignPut(ctx context.Context, input PresignUploadInput) (*PresignedRequest, error)
+	PresignPost(ctx context.Context, input PresignUploadInput) (*PresignedRequest, error)
+	PresignGet(ctx context.Context, key, fileName string, ttl time.Duration) (*PresignedRequest, error)
+	// Multipart uploads let a file arrive over many requests; every part but
+	// the last must be at least MinPartSize bytes
+	CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error)
+	UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.ReadSeeker, size int64) error
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string) error
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
 }
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrScanRejected is a scanner refusing the content itself, such as a stream
+// over its size limit, as opposed to the scanner being unreachable
+var ErrScanRejected = errors.New("scanner rejected the content")
+
+// ScanResult is the verdict on one stream; Signature names what an infected
+// stream matched
+type ScanResult struct {
+	Infected  bool
+	Signature string
+}
+
+type IVirusScanner interface {
+	// Scan streams r to the scanner and waits for its verdict
+	Scan(ctx context.Context, r io.Reader) (*ScanResult, error)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/utils"
+)
+
+type IShareLinkRepository interface {
+	utils.BaseInterface[entity.ShareLink]
+	// FindByTokenHash looks the link up in every tenant, since whoever follows
+	// it doesn't know the tenant; token hashes are unique across tenants
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entity.ShareLink, error)
+	ListByFile(ctx context.Context, fileID string) ([]entity.ShareLink, error)
+	// Revoke disables a link of fileID and fails with gorm.ErrRecordNotFound
+	// when there is no such link
+	Revoke(ctx context.Context, fileID string, id uint) error
+	// ClaimDownload counts one download, and fails with gorm.ErrRecordNotFound
+	// when the link was revoked, expired or used up in the meantime
+	ClaimDownload(ctx context.Context, id uint) error
+	LogAccess(ctx context.Context, access *entity.ShareLinkAccess) error
+	// ListAccess returns the newest access log entries of a link first
+	ListAccess(ctx context.Context, id uint, limit int) ([]entity.ShareLinkAccess, error)
+	// CountAccess counts the attempts on a link with the given outcome since a time
+	CountAccess(ctx context.Context, id uint, outcome string, since time.Time) (int64, error)
+}
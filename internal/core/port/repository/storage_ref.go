@@ -0,0 +1,28 @@
+package repository
+
+import "context"
+
+// Kinds of rows that point at storage
+const (
+	StorageRefBlob      = "blob"
+	StorageRefFile      = "file"
+	StorageRefVersion   = "version"
+	StorageRefThumbnail = "thumbnail"
+	StorageRefUpload    = "upload"
+)
+
+// StorageRef is one storage key the database points at. ID names the row:
+// a blob hash, a file ID, "<file ID>/<version>" or an upload ID.
+type StorageRef struct {
+	Key      string
+	Kind     string
+	TenantID string
+	ID       string
+}
+
+type IStorageRefRepository interface {
+	// EachRef calls fn for every storage key the rows of any tenant point at:
+	// blobs, the content, versions and thumbnails of files that still hold
+	// content, and uploads in progress, whose objects may not exist yet
+	EachRef(ctx context.Context, fn func(StorageRef) error) error
+}
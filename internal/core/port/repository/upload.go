@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/utils"
+)
+
+type IUploadRepository interface {
+	utils.BaseInterface[entity.Upload]
+	// Transition moves an upload from one status to another and fails with
+	// gorm.ErrRecordNotFound when it is no longer in the from status
+	Transition(ctx context.Context, id uint, from, to string) error
+	// Claim moves a tus upload to the to status from pending, or from a
+	// receiving status whose writer saved nothing since staleBefore and is
+	// taken for gone. It fails with gorm.ErrRecordNotFound otherwise.
+	Claim(ctx context.Context, id uint, to string, staleBefore time.Time) error
+	// TerminateExpired marks terminated and returns, across every tenant, up
+	// to limit tus uploads that expired before now and are not claimed by a
+	// writer that saved since staleBefore
+	TerminateExpired(ctx context.Context, now, staleBefore time.Time, limit int) ([]entity.Upload, error)
+}
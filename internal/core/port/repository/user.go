@@ -2,15 +2,42 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"project-api/internal/core/entity"
 	"project-api/internal/core/port/utils"
 )
 
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// UserSearchQuery describes a ranked, cursor-paginated user search
+type UserSearchQuery struct {
+	Query      string
+	Cursor     string
+	Limit      int
+	ActiveOnly bool
+	// MatchEmail searches the email too, which only admins may do
+	MatchEmail bool
+}
+
+// UserSearchHit is a user matched by a search together with its relevance
+type UserSearchHit struct {
+	User  entity.User
+	Score float64
+}
+
+// UserSearchResult is one page of search hits; NextCursor is empty on the last page
+type UserSearchResult struct {
+	Hits       []UserSearchHit
+	NextCursor string
+}
+
 type IUserRepository interface {
 	utils.BaseInterface[entity.User]
 	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetUserByName(ctx context.Context, name string) (*entity.User, error)
 	FindByToken(ctx context.Context, token string) (*entity.User, error)
 	FindByResetToken(ctx context.Context, token string) (*entity.User, error)
+	FindInBatches(ctx context.Context, batchSize int, fn func(users []entity.User) error) error
+	Search(ctx context.Context, query UserSearchQuery) (*UserSearchResult, error)
 }
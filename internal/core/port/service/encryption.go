@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"project-api/internal/core/entity"
+)
+
+// SealedContent is content encrypted for storage; Body yields Size bytes
+type SealedContent struct {
+	Body       io.Reader
+	Size       int64
+	Encryption *entity.Encryption
+}
+
+// IEncryptionService encrypts content on its way to storage and decrypts it
+// on its way out. Content stored without encryption passes through as it is.
+type IEncryptionService interface {
+	// Enabled reports whether new content is stored encrypted
+	Enabled() bool
+	// Seal encrypts the size bytes of body under a new data key, wrapped by
+	// the key that belongs to userID
+	Seal(ctx context.Context, userID uint, body io.Reader, size int64) (*SealedContent, error)
+	// Open streams length bytes of the content of size bytes stored at key,
+	// starting at offset, or all of it for a negative length; encryption is
+	// what Seal returned, or nil for content stored as it is
+	Open(ctx context.Context, key string, encryption *entity.Encryption, size, offset, length int64) (io.ReadCloser, error)
+}
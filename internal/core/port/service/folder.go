@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"github.com/google/uuid"
+)
+
+// FolderContents is one level of a user's tree
+type FolderContents struct {
+	Folder      *entity.Folder  // nil at the root
+	Breadcrumbs []entity.Folder // from the root down to Folder
+	Folders     []entity.Folder
+	Files       *repository.FileListResult
+}
+
+// IFolderService organizes the files of a user into folders; every method
+// works on the tree of userID only. Moves and renames change metadata only.
+type IFolderService interface {
+	CreateFolder(ctx context.Context, userID uint, name string, parentID *uuid.UUID) (*entity.Folder, error)
+	RenameFolder(ctx context.Context, userID uint, id, name string) (*entity.Folder, error)
+	// MoveFolder moves a folder under parentID, nil being the root
+	MoveFolder(ctx context.Context, userID uint, id string, parentID *uuid.UUID) (*entity.Folder, error)
+	// DeleteFolder deletes a folder with its subfolders and moves their files to the trash
+	DeleteFolder(ctx context.Context, userID uint, id string) error
+	// ListPath lists the folder at a slash-separated path such as /docs/2024
+	ListPath(ctx context.Context, userID uint, path string, query repository.FileListQuery) (*FolderContents, error)
+	ListFolder(ctx context.Context, userID uint, id string, query repository.FileListQuery) (*FolderContents, error)
+	RenameFile(ctx context.Context, userID uint, fileID, name string) (*entity.File, error)
+	// MoveFile moves a file into folderID, nil being the root
+	MoveFile(ctx context.Context, userID uint, fileID string, folderID *uuid.UUID) (*entity.File, error)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+)
+
+// EmailNotification selects the templated email task sent for a notification.
+// When nil, the generic notification email carrying Title and Body is used.
+type EmailNotification struct {
+	Task  string
+	Token string
+}
+
+// Notification is a message to deliver to a user through the channels their
+// preferences allow for its category
+type Notification struct {
+	UserID   uint
+	User     *entity.User // optional, avoids reloading the recipient
+	Category string
+	Title    string
+	Body     string
+	Email    *EmailNotification
+}
+
+type INotificationService interface {
+	Notify(ctx context.Context, notification Notification) error
+	ListNotifications(ctx context.Context, userID uint, unreadOnly bool, beforeID uint, limit int) ([]entity.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID uint, id uint) error
+	MarkAllRead(ctx context.Context, userID uint) (int64, error)
+	GetPreferences(ctx context.Context, userID uint) (map[string]entity.NotificationChannels, error)
+	UpdatePreferences(ctx context.Context, userID uint, preferences map[string]entity.NotificationChannels) (map[string]entity.NotificationChannels, error)
+}
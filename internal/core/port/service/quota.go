@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+)
+
+// QuotaUsage is what a user, or a whole tenant, stores against the limits
+// that apply to it
+type QuotaUsage struct {
+	Plan   *entity.QuotaPlan  `json:"plan,omitempty"`
+	Bytes  int64              `json:"bytes"`
+	Files  int64              `json:"files"`
+	Limits entity.QuotaLimits `json:"limits"`
+}
+
+type IQuotaService interface {
+	// Reserve counts bytes in files new files against the quota of userID and
+	// of its tenant before they are stored
+	Reserve(ctx context.Context, userID uint, bytes, files int64) error
+	// Release gives back what Reserve took, after a delete or a failed upload
+	Release(ctx context.Context, userID uint, bytes, files int64)
+	// Check rejects an upload of bytes that can't fit without reserving anything
+	Check(ctx context.Context, userID uint, bytes int64) error
+	// GetUsage returns the usage of userID, or of the tenant with entity.OrgUsageUserID
+	GetUsage(ctx context.Context, userID uint) (*QuotaUsage, error)
+	CreatePlan(ctx context.Context, plan *entity.QuotaPlan) error
+	ListPlans(ctx context.Context) ([]entity.QuotaPlan, error)
+	AssignPlan(ctx context.Context, userID uint, planID *uint) error
+	Reconcile(ctx context.Context) error
+	ReconcileAll(ctx context.Context) error
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// ReconcileOptions picks what a reconciliation checks and whether it fixes
+// what it finds; without Repair it is a dry run that only reports
+type ReconcileOptions struct {
+	Repair bool
+	// Prefix limits the objects checked; empty checks the whole bucket
+	Prefix string
+	// GracePeriod keeps objects younger than this from being taken for
+	// orphans, since their rows may still be on the way
+	GracePeriod time.Duration
+}
+
+// OrphanObject is an object no row points at
+type OrphanObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// MissingObject is a row pointing at an object that isn't there
+type MissingObject struct {
+	Key      string `json:"key"`
+	Kind     string `json:"kind"`
+	TenantID string `json:"tenant_id"`
+	ID       string `json:"id"`
+}
+
+// ReconcileReport sums up a reconciliation. The lists are capped; the
+// counts are not.
+type ReconcileReport struct {
+	DryRun     bool      `json:"dry_run"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Objects    int       `json:"objects"`
+	References int       `json:"references"`
+
+	OrphanCount  int             `json:"orphan_count"`
+	OrphanBytes  int64           `json:"orphan_bytes"`
+	Orphans      []OrphanObject  `json:"orphans"`
+	MissingCount int             `json:"missing_count"`
+	Missing      []MissingObject `json:"missing"`
+
+	// What a repair did; a failed repair is logged and counted
+	DeletedObjects    int `json:"deleted_objects"`
+	ClearedThumbnails int `json:"cleared_thumbnails"`
+	RepairFailures    int `json:"repair_failures"`
+}
+
+// IReconcileService finds where storage and the database disagree: objects
+// no row points at, and rows pointing at objects that are gone
+type IReconcileService interface {
+	Reconcile(ctx context.Context, options ReconcileOptions) (*ReconcileReport, error)
+	// ReconcileScheduled runs Reconcile with the options from configuration
+	// and logs the report
+	ReconcileScheduled(ctx context.Context) error
+}
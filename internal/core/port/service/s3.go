@@ -1,15 +1,63 @@
 package service
 
 import (
+	"context"
+	"io"
 	"mime/multipart"
 	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+	"project-api/internal/core/port/repository"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type IS3Service interface {
+	// DeleteFile moves a file to the trash, from which RestoreFile brings it back
 	DeleteFile(c *fiber.Ctx, key string) error
-	DownloadFile(c *fiber.Ctx, key string) ([]byte, *entity.File, error)
+	RestoreFile(c *fiber.Ctx, id string) (*entity.File, error)
+	// PurgeTrash removes for good the files trashed longer than the retention window
+	PurgeTrash(ctx context.Context) error
+	// DeleteExpiredFiles removes for good the files whose requested expiry has passed
+	DeleteExpiredFiles(ctx context.Context) error
+	DownloadFile(c *fiber.Ctx, key string) (io.ReadCloser, *entity.File, error)
+	// StatDownload runs the checks of DownloadFile without opening the
+	// content, which OpenRange then reads part by part
+	StatDownload(c *fiber.Ctx, key string) (*entity.File, error)
+	// OpenRange reads the whole content for a negative length
+	OpenRange(ctx context.Context, file *entity.File, offset, length int64) (io.ReadCloser, error)
 	UploadFile(c *fiber.Ctx, files []*multipart.FileHeader, expir *time.Duration) ([]string, error)
+	// PresignUpload records a pending upload and signs a request that sends it straight to S3
+	PresignUpload(c *fiber.Ctx, req *request.PresignUploadRequest) (*entity.Upload, *repository.PresignedRequest, error)
+	// CompleteUpload verifies the uploaded object and saves its File row
+	CompleteUpload(c *fiber.Ctx, uploadID uint) (*entity.File, error)
+	PresignDownload(c *fiber.Ctx, key string, ttl time.Duration) (*repository.PresignedRequest, error)
+	// ListFiles pages through the caller's files, with query.SharedWith the
+	// files shared with them, or with query.Trashed their trash; query.UserID
+	// is set from the context
+	ListFiles(c *fiber.Ctx, query repository.FileListQuery) (*repository.FileListResult, error)
+	GetFile(c *fiber.Ctx, id string) (*entity.File, error)
+	UpdateFileTags(c *fiber.Ctx, id string, tags []string) (*entity.File, error)
+	// GrantPermission, ListPermissions and RevokePermission manage who else may
+	// read or write a file; only its owner can call them
+	GrantPermission(c *fiber.Ctx, fileID string, req *request.GrantFilePermissionRequest) (*entity.FilePermission, error)
+	ListPermissions(c *fiber.Ctx, fileID string) ([]entity.FilePermission, error)
+	RevokePermission(c *fiber.Ctx, fileID string, permissionID uint) error
+	// UploadVersion replaces the content of a file and keeps the old one in its history
+	UploadVersion(c *fiber.Ctx, fileID string, file *multipart.FileHeader) (*entity.File, error)
+	ListVersions(c *fiber.Ctx, fileID string) (*entity.File, []entity.FileVersion, error)
+	DownloadVersion(c *fiber.Ctx, fileID string, version int) (io.ReadCloser, *entity.File, error)
+	// RestoreVersion makes the content of an earlier version current as a new version
+	RestoreVersion(c *fiber.Ctx, fileID string, version int) (*entity.File, error)
+	// PruneVersions drops the history the retention policy no longer keeps
+	PruneVersions(ctx context.Context) error
+	// GenerateThumbnails makes the configured thumbnails of an image file at
+	// the given version; the worker runs it after image uploads
+	GenerateThumbnails(ctx context.Context, tenantID, fileID string, version int) error
+	DownloadThumbnail(c *fiber.Ctx, fileID, name string) (io.ReadCloser, *entity.Thumbnail, error)
+	// ArchiveFiles checks that every file can be downloaded by the caller
+	// and returns them in the order asked for; WriteArchive then streams
+	// them as a ZIP archive
+	ArchiveFiles(c *fiber.Ctx, ids []string) ([]*entity.File, error)
+	WriteArchive(ctx context.Context, w io.Writer, files []*entity.File) error
 }
@@ -0,0 +1,10 @@
+package service
+
+import "context"
+
+// IScanService clears quarantined uploads by scanning them for viruses
+type IScanService interface {
+	// ScanPending scans the quarantined uploads of every tenant until none
+	// is left; infected files are deleted and their owners told
+	ScanPending(ctx context.Context) error
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+)
+
+// ShareClient identifies who downloads through a share link, for its access log
+type ShareClient struct {
+	IP        string
+	UserAgent string
+}
+
+// IShareService manages public share links; every method but Download checks
+// that userID owns the file
+type IShareService interface {
+	// CreateLink returns the new link and its token, which is not stored and
+	// can't be shown again
+	CreateLink(ctx context.Context, userID uint, fileID string, req *request.CreateShareLinkRequest) (*entity.ShareLink, string, error)
+	ListLinks(ctx context.Context, userID uint, fileID string) ([]entity.ShareLink, error)
+	RevokeLink(ctx context.Context, userID uint, fileID string, linkID uint) error
+	ListAccess(ctx context.Context, userID uint, fileID string, linkID uint) ([]entity.ShareLinkAccess, error)
+	// Download opens the file behind token once the link's expiry, password and
+	// download limit allow it; the caller must close the stream
+	Download(ctx context.Context, token, password string, client ShareClient) (io.ReadCloser, *entity.File, error)
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"project-api/internal/core/entity"
+)
+
+// ITusService keeps the state of resumable uploads; every method checks that
+// the upload belongs to userID
+type ITusService interface {
+	CreateUpload(ctx context.Context, userID uint, fileName, contentType string, size int64) (*entity.Upload, error)
+	GetUpload(ctx context.Context, userID, id uint) (*entity.Upload, error)
+	// WriteChunk appends body at offset, which must be the current offset of
+	// the upload, and creates the File once the last byte has arrived
+	WriteChunk(ctx context.Context, userID, id uint, offset int64, body io.Reader) (*entity.Upload, error)
+	TerminateUpload(ctx context.Context, userID, id uint) error
+	// PurgeExpiredUploads frees the storage of the tus uploads of every tenant
+	// that expired unfinished
+	PurgeExpiredUploads(ctx context.Context) error
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
 	"project-api/internal/core/port/utils"
 )
 
@@ -15,4 +16,5 @@ type IUserService interface {
 	ResendConfirmationEmail(ctx context.Context, email string) (*entity.User, error)
 	ResetPassword(ctx context.Context, email string) (*entity.User, error)
 	ConfirmResetPassword(ctx context.Context, token string, newPassword string) error
+	SearchUsers(ctx context.Context, query repository.UserSearchQuery) (*repository.UserSearchResult, error)
 }
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"project-api/internal/core/entity"
+)
+
+type IUserImportService interface {
+	CreateImportJob(ctx context.Context, createdBy uint, fileName, format string, payload []byte, sendInvites bool) (*entity.ImportJob, error)
+	GetImportJob(ctx context.Context, id uint) (*entity.ImportJob, error)
+	ProcessImportJob(ctx context.Context, id uint) error
+	ExportUsers(ctx context.Context, w io.Writer) error
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// blobKey is a new storage key for the content with the given hex SHA-256,
+// sharded by the first byte so no prefix grows too large. Each put of the
+// content gets a key of its own, so an object being deleted with the blob
+// it belonged to is never the one a new blob for that content points at.
+func blobKey(ctx context.Context, hash string) (string, error) {
+	key, err := utils.TenantKey(ctx, "blob/"+hash[:2]+"/"+hash+"/"+uuid.NewString())
+	if err != nil {
+		logger.Error("Failed to generate storage key", zap.Error(err))
+		return "", errors.New("tenant is required")
+	}
+	return key, nil
+}
+
+// storedContent is where new content went: a shared blob, or an object of
+// its own when it is encrypted
+type storedContent struct {
+	Key        string
+	Hash       string
+	Encryption *entity.Encryption
+}
+
+func blobContent(blob *entity.Blob) *storedContent {
+	return &storedContent{Key: blob.Key, Hash: blob.Hash}
+}
+
+// release gives back what storing the content took
+func (content *storedContent) release(ctx context.Context, blobs In.IBlobRepository, storage In.IS3Repository) error {
+	hash := content.Hash
+	if content.Encryption != nil {
+		hash = ""
+	}
+	return releaseContent(ctx, blobs, storage, hash, content.Key)
+}
+
+// sealContent encrypts size bytes of body for userID into a new object. Each
+// encrypted upload has a data key, and so an object, of its own: content
+// shared between files could only be opened with one owner's key.
+func sealContent(ctx context.Context, storage In.IS3Repository, crypto InS.IEncryptionService, userID uint, hash string, body io.Reader, size int64) (*storedContent, error) {
+	key, err := newUploadKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := crypto.Seal(ctx, userID, body, size)
+	if err != nil {
+		return nil, err
+	}
+	// The stored type would tell what the content is
+	if _, err := storage.UploadFile(ctx, key, sealed.Body, sealed.Size, "application/octet-stream"); err != nil {
+		logger.Error("Failed to store encrypted file", zap.String("key", key), zap.Error(err))
+		return nil, errors.New("failed to store file")
+	}
+	return &storedContent{Key: key, Hash: hash, Encryption: sealed.Encryption}, nil
+}
+
+// storeBlob takes a reference to the content with the given hash. put is only
+// called, with a new blob key, when the tenant does not store that content yet.
+func storeBlob(ctx context.Context, blobs In.IBlobRepository, storage In.IS3Repository, hash string, size int64, put func(key string) error) (*entity.Blob, error) {
+	blob, err := blobs.AddRef(ctx, hash)
+	if err == nil {
+		return blob, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("Failed to reference blob", zap.String("hash", hash), zap.Error(err))
+		return nil, errors.New("failed to store file")
+	}
+
+	key, err := blobKey(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := put(key); err != nil {
+		return nil, err
+	}
+
+	blob = &entity.Blob{Hash: hash, Key: key, Size: size, RefCount: 1}
+	if err := blobs.Create(ctx, blob); err != nil {
+		logger.Error("Failed to save blob", zap.String("hash", hash), zap.Error(err))
+		if err := storage.DeleteFile(ctx, key); err != nil {
+			logger.Warn("Failed to delete unsaved blob", zap.String("key", key), zap.Error(err))
+		}
+		return nil, errors.New("failed to store file")
+	}
+	// Another upload of the same content saved its blob first, and this
+	// one only took a reference to it
+	if blob.Key != key {
+		if err := storage.DeleteFile(ctx, key); err != nil {
+			logger.Warn("Failed to delete duplicate blob", zap.String("key", key), zap.Error(err))
+		}
+	}
+	return blob, nil
+}
+
+// releaseBlob drops a reference and deletes the object with the last one
+func releaseBlob(ctx context.Context, blobs In.IBlobRepository, storage In.IS3Repository, hash string) error {
+	blob, last, err := blobs.Release(ctx, hash)
+	if err != nil {
+		logger.Error("Failed to release blob", zap.String("hash", hash), zap.Error(err))
+		return errors.New("failed to release stored file")
+	}
+	if !last {
+		return nil
+	}
+	if err := storage.DeleteFile(ctx, blob.Key); err != nil {
+		logger.Error("Failed to delete blob from S3", zap.String("key", blob.Key), zap.Error(err))
+		return errors.New("failed to delete file from S3")
+	}
+	return nil
+}
+
+// releaseContent drops what one version of a file holds in storage: a blob
+// reference, or the object itself when hash is empty, for content stored
+// before content addressing and encrypted content
+func releaseContent(ctx context.Context, blobs In.IBlobRepository, storage In.IS3Repository, hash, key string) error {
+	if hash != "" {
+		return releaseBlob(ctx, blobs, storage, hash)
+	}
+	return storage.DeleteFile(ctx, key)
+}
+
+// purgeFile gives back everything a deleted file stores: its current content,
+// its version history and the quota they count against the owner. Only the
+// error of the current content is returned; history failures are logged.
+func purgeFile(ctx context.Context, versions In.IFileVersionRepository, blobs In.IBlobRepository, storage In.IS3Repository, quota InS.IQuotaService, file *entity.File) error {
+	history, err := versions.DeleteByFile(ctx, file.ID)
+	if err != nil {
+		logger.Error("Failed to delete file versions", zap.String("fileID", file.ID.String()), zap.Error(err))
+	}
+
+	size := file.FileSize
+	for _, version := range history {
+		size += version.FileSize
+	}
+	quota.Release(ctx, file.UserID, size, 1)
+
+	for _, version := range history {
+		if err := releaseContent(ctx, blobs, storage, version.BlobHash(), version.FilePath); err != nil {
+			logger.Error("Failed to delete file version",
+				zap.String("fileID", file.ID.String()),
+				zap.Int("version", version.Version),
+				zap.Error(err))
+		}
+	}
+	deleteThumbnails(ctx, storage, file.Thumbnails)
+	return releaseContent(ctx, blobs, storage, file.BlobHash(), file.FilePath)
+}
+
+// promoteStaged moves content verified at stagingKey to its blob, or seals
+// it for userID when encryption is on; the staged object is always removed
+func promoteStaged(ctx context.Context, blobs In.IBlobRepository, storage In.IS3Repository, crypto InS.IEncryptionService, userID uint, stagingKey, hash string, size int64) (*storedContent, error) {
+	defer func() {
+		if err := storage.DeleteFile(ctx, stagingKey); err != nil {
+			logger.Warn("Failed to delete staged upload", zap.String("key", stagingKey), zap.Error(err))
+		}
+	}()
+
+	if crypto.Enabled() {
+		body, _, err := storage.DownloadFile(ctx, stagingKey)
+		if err != nil {
+			logger.Error("Failed to read staged upload", zap.String("key", stagingKey), zap.Error(err))
+			return nil, errors.New("failed to store file")
+		}
+		defer body.Close()
+		return sealContent(ctx, storage, crypto, userID, hash, body, size)
+	}
+
+	blob, err := storeBlob(ctx, blobs, storage, hash, size, func(key string) error {
+		if err := storage.CopyFile(ctx, stagingKey, key); err != nil {
+			logger.Error("Failed to move staged upload", zap.String("key", stagingKey), zap.Error(err))
+			return errors.New("failed to store file")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blobContent(blob), nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	"project-api/internal/infra/repository"
+	"project-api/internal/infra/storage"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// hookedStorage runs beforeDelete, once, ahead of the next object delete
+type hookedStorage struct {
+	In.IS3Repository
+	beforeDelete func()
+}
+
+func (s *hookedStorage) DeleteFile(ctx context.Context, key string) error {
+	if hook := s.beforeDelete; hook != nil {
+		s.beforeDelete = nil
+		hook()
+	}
+	return s.IS3Repository.DeleteFile(ctx, key)
+}
+
+type blobFixture struct {
+	ctx     context.Context
+	db      *gorm.DB
+	blobs   In.IBlobRepository
+	storage *hookedStorage
+	content []byte
+	hash    string
+}
+
+func newBlobFixture(t *testing.T) *blobFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&entity.Blob{}); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("the same content")
+	digest := sha256.Sum256(content)
+	return &blobFixture{
+		ctx:     utils.WithTenantID(context.Background(), "a"),
+		db:      db,
+		blobs:   repository.NewBlobRepository(db),
+		storage: &hookedStorage{IS3Repository: storage.NewMemory()},
+		content: content,
+		hash:    hex.EncodeToString(digest[:]),
+	}
+}
+
+func (f *blobFixture) store(t *testing.T, put func(key string) error) *entity.Blob {
+	t.Helper()
+	blob, err := storeBlob(f.ctx, f.blobs, f.storage, f.hash, int64(len(f.content)), put)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func (f *blobFixture) put(key string) error {
+	_, err := f.storage.UploadFile(f.ctx, key, bytes.NewReader(f.content), int64(len(f.content)), "text/plain")
+	return err
+}
+
+func (f *blobFixture) expectObject(t *testing.T, key string) {
+	t.Helper()
+	body, _, err := f.storage.DownloadFile(f.ctx, key)
+	if err != nil {
+		t.Fatalf("object %s is gone: %v", key, err)
+	}
+	defer body.Close()
+	got, err := io.ReadAll(body)
+	if err != nil || !bytes.Equal(got, f.content) {
+		t.Fatalf("object %s = %q, %v", key, got, err)
+	}
+}
+
+func TestStoreDuringReleaseKeepsNewBlob(t *testing.T) {
+	f := newBlobFixture(t)
+	old := f.store(t, f.put)
+
+	// The same content arrives after the last reference was released and
+	// before the released object is deleted
+	var stored *entity.Blob
+	f.storage.beforeDelete = func() {
+		stored = f.store(t, f.put)
+	}
+	if err := releaseBlob(f.ctx, f.blobs, f.storage, f.hash); err != nil {
+		t.Fatal(err)
+	}
+	if stored == nil {
+		t.Fatal("released blob was not deleted")
+	}
+	if stored.Key == old.Key {
+		t.Fatalf("new blob reuses the released key %s", old.Key)
+	}
+	f.expectObject(t, stored.Key)
+	if _, _, err := f.storage.DownloadFile(f.ctx, old.Key); err == nil {
+		t.Fatalf("released object %s was kept", old.Key)
+	}
+}
+
+func TestConcurrentStoresShareOneObject(t *testing.T) {
+	f := newBlobFixture(t)
+
+	// A second upload of the content saves its blob while the first is
+	// still putting the object
+	var second *entity.Blob
+	var firstKey string
+	first := f.store(t, func(key string) error {
+		firstKey = key
+		second = f.store(t, f.put)
+		return f.put(key)
+	})
+	if first.Key != second.Key {
+		t.Fatalf("uploads point at %s and %s", first.Key, second.Key)
+	}
+	if first.RefCount != 2 {
+		t.Fatalf("ref count = %d, want 2", first.RefCount)
+	}
+	f.expectObject(t, first.Key)
+	if _, _, err := f.storage.DownloadFile(f.ctx, firstKey); err == nil {
+		t.Fatalf("duplicate object %s was kept", firstKey)
+	}
+
+	for range 2 {
+		if err := releaseBlob(f.ctx, f.blobs, f.storage, f.hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var count int64
+	if err := f.db.Model(&entity.Blob{}).Count(&count).Error; err != nil || count != 0 {
+		t.Fatalf("blobs left = %d, %v", count, err)
+	}
+}
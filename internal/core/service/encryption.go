@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"project-api/internal/core/common/envelope"
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"go.uber.org/zap"
+)
+
+// EncryptionService seals content under a data key of its own and keeps the
+// data key wrapped by the key provider next to the file
+type EncryptionService struct {
+	// Keys is nil when no keys are configured: nothing new is encrypted and
+	// encrypted content can't be read
+	Keys In.IKeyProvider
+	S3   In.IS3Repository
+}
+
+func NewEncryptionService(keys In.IKeyProvider, s3Repo In.IS3Repository) InS.IEncryptionService {
+	return &EncryptionService{
+		Keys: keys,
+		S3:   s3Repo,
+	}
+}
+
+func (s *EncryptionService) Enabled() bool {
+	return s.Keys != nil && config.Config.Encryption.Enabled
+}
+
+// keyID names the key that wraps the data keys of userID's content. The
+// scope can change at any time: every file keeps the ID it was sealed with.
+func keyID(ctx context.Context, userID uint) (string, error) {
+	if config.Config.GetEncryptionKeyScope() == config.KeyScopeMaster {
+		return config.KeyScopeMaster, nil
+	}
+	tenantID, ok := utils.GetTenantIDFromContext(ctx)
+	if !ok {
+		return "", errors.New("tenant is required")
+	}
+	return fmt.Sprintf("tenants/%s/users/%d", tenantID, userID), nil
+}
+
+func (s *EncryptionService) Seal(ctx context.Context, userID uint, body io.Reader, size int64) (*InS.SealedContent, error) {
+	if s.Keys == nil {
+		return nil, ErrNoEncryptionKeys
+	}
+	id, err := keyID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := envelope.NewKey()
+	if err != nil {
+		logger.Error("Failed to generate data key", zap.Error(err))
+		return nil, errors.New("failed to encrypt file")
+	}
+	wrapped, err := s.Keys.WrapKey(ctx, id, dataKey)
+	if err != nil {
+		logger.Error("Failed to wrap data key", zap.String("keyID", id), zap.Error(err))
+		return nil, errors.New("failed to encrypt file")
+	}
+
+	chunkSize := config.Config.GetEncryptionChunkSize()
+	sealed, err := envelope.NewSealer(body, dataKey, chunkSize)
+	if err != nil {
+		logger.Error("Failed to start encryption", zap.Error(err))
+		return nil, errors.New("failed to encrypt file")
+	}
+	return &InS.SealedContent{
+		Body: sealed,
+		Size: envelope.SealedSize(size, chunkSize),
+		Encryption: &entity.Encryption{
+			Algorithm:  entity.EncryptionChunkedAESGCM,
+			KeyID:      id,
+			WrappedKey: wrapped,
+			ChunkSize:  chunkSize,
+		},
+	}, nil
+}
+
+func (s *EncryptionService) Open(ctx context.Context, key string, encryption *entity.Encryption, size, offset, length int64) (io.ReadCloser, error) {
+	if encryption == nil {
+		return s.read(ctx, key, offset, length)
+	}
+	if s.Keys == nil {
+		return nil, ErrNoEncryptionKeys
+	}
+	if encryption.Algorithm != entity.EncryptionChunkedAESGCM {
+		return nil, fmt.Errorf("unknown encryption %q", encryption.Algorithm)
+	}
+	dataKey, err := s.Keys.UnwrapKey(ctx, encryption.KeyID, encryption.WrappedKey)
+	if err != nil {
+		logger.Error("Failed to unwrap data key", zap.String("key", key), zap.String("keyID", encryption.KeyID), zap.Error(err))
+		return nil, errors.New("failed to decrypt file")
+	}
+	return envelope.OpenRange(func(offset, length int64) (io.ReadCloser, error) {
+		return s.read(ctx, key, offset, length)
+	}, dataKey, encryption.ChunkSize, size, offset, length)
+}
+
+// read streams stored bytes as they are, all of them for a negative length
+func (s *EncryptionService) read(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length < 0 && offset == 0 {
+		body, _, err := s.S3.DownloadFile(ctx, key)
+		return body, err
+	}
+	if length < 0 {
+		info, err := s.S3.StatFile(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		length = info.Size - offset
+	}
+	return s.S3.DownloadRange(ctx, key, offset, length)
+}
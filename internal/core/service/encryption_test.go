@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"project-api/internal/core/common/envelope"
+	"project-api/internal/core/common/utils"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/keys"
+	"project-api/internal/infra/storage"
+)
+
+func newTestEncryption(t *testing.T) InS.IEncryptionService {
+	t.Helper()
+	saved := config.Config
+	config.Config = &config.AppConfig{}
+	config.Config.Encryption.ChunkSize = 16
+	t.Cleanup(func() { config.Config = saved })
+
+	master := make([]byte, 32)
+	if _, err := rand.Read(master); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(master)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := keys.NewKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEncryptionService(provider, storage.NewMemory())
+}
+
+func TestSealedContentOpensByRange(t *testing.T) {
+	ctx := utils.WithTenantID(context.Background(), "a")
+	crypto := newTestEncryption(t)
+	store := crypto.(*EncryptionService).S3
+	data := []byte("a file long enough to fill a few encrypted chunks")
+	size := int64(len(data))
+
+	sealed, err := crypto.Seal(ctx, 1, bytes.NewReader(data), size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UploadFile(ctx, "file", sealed.Body, sealed.Size, "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		offset, length int64
+	}{
+		{"whole file", 0, -1},
+		{"inside a chunk", 2, 5},
+		{"across chunks", 10, 20},
+		{"to the end", 17, -1},
+		{"last byte", size - 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := crypto.Open(ctx, "file", sealed.Encryption, size, tt.offset, tt.length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer body.Close()
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end := size
+			if tt.length >= 0 {
+				end = tt.offset + tt.length
+			}
+			if !bytes.Equal(got, data[tt.offset:end]) {
+				t.Fatalf("got %q, want %q", got, data[tt.offset:end])
+			}
+		})
+	}
+
+	// A data key wrapped for another user does not open
+	stolen := *sealed.Encryption
+	stolen.KeyID = "tenants/a/users/2"
+	if _, err := crypto.Open(ctx, "file", &stolen, size, 0, -1); err == nil {
+		t.Fatal("content opened with another user's key ID")
+	}
+
+	// Damaged content fails instead of returning wrong plaintext
+	if _, err := store.UploadFile(ctx, "file", bytes.NewReader(make([]byte, sealed.Size)), sealed.Size, "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	body, err := crypto.Open(ctx, "file", sealed.Encryption, size, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	if _, err := io.ReadAll(body); !errors.Is(err, envelope.ErrCorrupt) {
+		t.Fatalf("got %v, want ErrCorrupt", err)
+	}
+}
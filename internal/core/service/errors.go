@@ -2,4 +2,45 @@ package service
 
 import "errors"
 
-var ErrCreateUser = errors.New("failed to create user") // Generic create error
+var (
+	ErrCreateUser  = errors.New("failed to create user") // Generic create error
+	ErrSearchQuery = errors.New("search query must be between 2 and 100 characters")
+)
+
+var (
+	ErrFileTooLarge      = errors.New("file size exceeds the upload limit for its type")
+	ErrFileType          = errors.New("file type is not allowed")
+	ErrFileTypeMismatch  = errors.New("file content does not match its name or declared type")
+	ErrChecksumFormat    = errors.New("checksum_sha256 must be a hex or base64 SHA-256 digest")
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrUploadState       = errors.New("upload is already being completed or has failed")
+	ErrUploadBusy        = errors.New("upload is receiving another request")
+	ErrUploadOffset      = errors.New("offset does not match the bytes received so far")
+	ErrUploadOverflow    = errors.New("chunk goes past the declared upload length")
+	ErrUploadIncomplete  = errors.New("file has not been uploaded to storage yet")
+	ErrUploadExpired     = errors.New("upload has expired")
+	ErrUploadMismatch    = errors.New("uploaded file does not match the declared size or checksum")
+	ErrPresignDisabled   = errors.New("direct uploads and downloads are not available with this storage backend")
+	ErrPresignEncrypted  = errors.New("encrypted files are decrypted by the API and can't be downloaded from storage directly")
+	ErrFileNotFound      = errors.New("file not found")
+	ErrFileForbidden     = errors.New("unauthorized: you do not have access to this file")
+	ErrFileGrant         = errors.New("a permission is granted to one user other than the owner, or to the org")
+	ErrGranteeNotFound   = errors.New("no active user to grant the permission to")
+	ErrFileExpiry        = errors.New("expiry can't be negative or longer than the allowed maximum")
+	ErrFileTags          = errors.New("a file takes at most 20 tags of 1 to 50 characters")
+	ErrVersionNotFound   = errors.New("file version not found")
+	ErrVersionUnscanned  = errors.New("a file gets no new version until its current content passes its virus scan")
+	ErrFileQuarantined   = errors.New("file can't be downloaded until it passes its virus scan")
+	ErrThumbnailNotFound = errors.New("thumbnail not found; it may not have been generated yet")
+	ErrQuotaExceeded     = errors.New("upload would exceed the storage quota")
+	ErrQuotaPlan         = errors.New("quota plan not found")
+	ErrFolderNotFound    = errors.New("folder not found")
+	ErrFolderName        = errors.New("name must be 1 to 255 characters without slashes and can't be . or ..")
+	ErrFolderExists      = errors.New("a folder with this name already exists here")
+	ErrFolderCycle       = errors.New("a folder can't be moved into itself or one of its subfolders")
+	ErrShareNotFound     = errors.New("share link not found")
+	ErrShareExpired      = errors.New("share link has expired or reached its download limit")
+	ErrSharePassword     = errors.New("share link requires a valid password")
+	ErrShareThrottled    = errors.New("too many wrong passwords for this share link, try again later")
+	ErrNoEncryptionKeys  = errors.New("file is encrypted and no encryption keys are configured")
+)
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	"project-api/internal/infra/logger"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultFileListLimit = 20
+	maxFileListLimit     = 100
+	maxFileTags          = 20
+	maxFileTagLength     = 50
+)
+
+// ListFiles pages through the caller's files, or the ones shared with them,
+// newest first unless asked otherwise
+func (s *S3Service) ListFiles(c *fiber.Ctx, query In.FileListQuery) (*In.FileListResult, error) {
+	userID, err := s.getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	query.UserID = userID
+	if query.Sort == "" {
+		query.Sort = In.FileSortCreatedAt
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultFileListLimit
+	}
+	if query.Limit > maxFileListLimit {
+		query.Limit = maxFileListLimit
+	}
+	query.Tag = normalizeTag(query.Tag)
+
+	result, err := s.FileRepo.List(c.UserContext(), query)
+	if err != nil {
+		if errors.Is(err, In.ErrInvalidCursor) || errors.Is(err, In.ErrInvalidSort) {
+			return nil, err
+		}
+		logger.Error("Failed to list files", zap.Uint("userID", userID), zap.Error(err))
+		return nil, wrapError(errors.New("failed to list files"), err)
+	}
+	return result, nil
+}
+
+// GetFile returns a file the caller may read
+func (s *S3Service) GetFile(c *fiber.Ctx, id string) (*entity.File, error) {
+	return s.accessibleFile(c, id, entity.FileAccessRead)
+}
+
+// UpdateFileTags replaces the tags of a file; tags are lower-cased and deduplicated
+func (s *S3Service) UpdateFileTags(c *fiber.Ctx, id string, tags []string) (*entity.File, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > maxFileTagLength {
+			return nil, ErrFileTags
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxFileTags {
+		return nil, ErrFileTags
+	}
+
+	file, err := s.accessibleFile(c, id, entity.FileAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	file.Tags = normalized
+	if err := s.FileRepo.SetTags(c.UserContext(), file.ID, normalized); err != nil {
+		logger.Error("Failed to update file tags", zap.String("fileID", id), zap.Error(err))
+		return nil, errors.New("failed to update file tags")
+	}
+	return file, nil
+}
+
+// accessibleFile returns a live file the caller has access to. Files without
+// access are reported as missing so their existence isn't revealed.
+func (s *S3Service) accessibleFile(c *fiber.Ctx, id string, access string) (*entity.File, error) {
+	userID, err := s.getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var file entity.File
+	if err := s.FileRepo.FindByID(c.UserContext(), id, &file); err != nil || file.IsDeleted || file.Expired(time.Now()) {
+		return nil, ErrFileNotFound
+	}
+	if err := s.authorize(c, &file, userID, access); err != nil {
+		if errors.Is(err, ErrFileForbidden) {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GrantPermission gives a user or the whole tenant access to a file the
+// caller owns; granting the same grantee again replaces its access. A user
+// must be an active user of the caller's tenant.
+func (s *S3Service) GrantPermission(c *fiber.Ctx, fileID string, req *request.GrantFilePermissionRequest) (*entity.FilePermission, error) {
+	file, err := s.ownedFile(c, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	permission := &entity.FilePermission{
+		FileID:      file.ID,
+		GranteeType: entity.GranteeUser,
+		GranteeID:   req.UserID,
+		Access:      req.Access,
+		GrantedBy:   file.UserID,
+	}
+	if req.Org {
+		permission.GranteeType = entity.GranteeOrg
+		permission.GranteeID = 0
+	} else if req.UserID == file.UserID {
+		return nil, ErrFileGrant
+	}
+	// A grant to an ID nobody has yet would go to whoever gets it later
+	if permission.GranteeType == entity.GranteeUser {
+		grantee, err := s.UserRepo.GetById(c.UserContext(), req.UserID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("Failed to find grantee", zap.Uint("userID", req.UserID), zap.Error(err))
+			return nil, errors.New("failed to grant file permission")
+		}
+		if err != nil || !grantee.IsActive {
+			return nil, ErrGranteeNotFound
+		}
+	}
+
+	if err := s.PermissionRepo.Grant(c.UserContext(), permission); err != nil {
+		logger.Error("Failed to grant file permission", zap.String("fileID", fileID), zap.Error(err))
+		return nil, errors.New("failed to grant file permission")
+	}
+
+	if permission.GranteeType == entity.GranteeUser {
+		err := s.Notifier.Notify(c.UserContext(), InS.Notification{
+			UserID:   permission.GranteeID,
+			Category: entity.NotificationCategoryFile,
+			Title:    "File shared with you",
+			Body:     fmt.Sprintf("You were given %s access to %s.", permission.Access, file.FileName),
+		})
+		if err != nil {
+			logger.Warn("Failed to send share notification", zap.Uint("userID", permission.GranteeID), zap.Error(err))
+		}
+	}
+	return permission, nil
+}
+
+// ListPermissions returns the grants of a file the caller owns
+func (s *S3Service) ListPermissions(c *fiber.Ctx, fileID string) ([]entity.FilePermission, error) {
+	if _, err := s.ownedFile(c, fileID); err != nil {
+		return nil, err
+	}
+	permissions, err := s.PermissionRepo.ListByFile(c.UserContext(), fileID)
+	if err != nil {
+		logger.Error("Failed to list file permissions", zap.String("fileID", fileID), zap.Error(err))
+		return nil, errors.New("failed to list file permissions")
+	}
+	return permissions, nil
+}
+
+// RevokePermission removes a grant from a file the caller owns
+func (s *S3Service) RevokePermission(c *fiber.Ctx, fileID string, permissionID uint) error {
+	if _, err := s.ownedFile(c, fileID); err != nil {
+		return err
+	}
+	if err := s.PermissionRepo.Revoke(c.UserContext(), fileID, permissionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrFileNotFound
+		}
+		logger.Error("Failed to revoke file permission", zap.Uint("permissionID", permissionID), zap.Error(err))
+		return errors.New("failed to revoke file permission")
+	}
+	return nil
+}
+
+// ownedFile returns a live file the caller owns; grants are managed by the
+// owner alone
+func (s *S3Service) ownedFile(c *fiber.Ctx, fileID string) (*entity.File, error) {
+	userID, err := s.getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var file entity.File
+	if err := s.FileRepo.FindByID(c.UserContext(), fileID, &file); err != nil || file.UserID != userID || file.IsDeleted {
+		return nil, ErrFileNotFound
+	}
+	return &file, nil
+}
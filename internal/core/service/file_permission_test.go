@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// grantFixture holds what the fakes below hand to GrantPermission
+type grantFixture struct {
+	file    entity.File
+	users   map[uint]*entity.User
+	granted []entity.FilePermission
+}
+
+type grantFiles struct {
+	In.IFileRepository
+	*grantFixture
+}
+
+type grantUsers struct {
+	In.IUserRepository
+	*grantFixture
+}
+
+type grantPermissions struct {
+	In.IFilePermissionRepository
+	*grantFixture
+}
+
+type grantNotifier struct {
+	InS.INotificationService
+}
+
+func (f grantFiles) FindByID(ctx context.Context, id string, file *entity.File) error {
+	if id != f.file.ID.String() {
+		return gorm.ErrRecordNotFound
+	}
+	*file = f.file
+	return nil
+}
+
+func (f grantUsers) GetById(ctx context.Context, id uint) (*entity.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (f grantPermissions) Grant(ctx context.Context, permission *entity.FilePermission) error {
+	f.granted = append(f.granted, *permission)
+	return nil
+}
+
+func (grantNotifier) Notify(ctx context.Context, notification InS.Notification) error {
+	return nil
+}
+
+func TestGrantPermissionNeedsActiveGrantee(t *testing.T) {
+	f := &grantFixture{
+		file: entity.File{ID: uuid.New(), UserID: 1},
+		users: map[uint]*entity.User{
+			2: {Model: gorm.Model{ID: 2}, IsActive: true},
+			3: {Model: gorm.Model{ID: 3}, IsActive: false},
+		},
+	}
+	s := &S3Service{
+		FileRepo:       grantFiles{grantFixture: f},
+		UserRepo:       grantUsers{grantFixture: f},
+		PermissionRepo: grantPermissions{grantFixture: f},
+		Notifier:       grantNotifier{},
+	}
+
+	app := fiber.New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	ctx := utils.WithTenantID(context.Background(), "default")
+	c.SetUserContext(context.WithValue(ctx, utils.GetUserContextKey(), &utils.UserClaims{UserID: 1}))
+
+	tests := []struct {
+		name   string
+		userID uint
+		want   error
+	}{
+		{"unknown user", 9, ErrGranteeNotFound},
+		{"inactive user", 3, ErrGranteeNotFound},
+		{"owner", 1, ErrFileGrant},
+		{"active user", 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.granted = nil
+			req := &request.GrantFilePermissionRequest{UserID: tt.userID, Access: entity.FileAccessRead}
+			_, err := s.GrantPermission(c, f.file.ID.String(), req)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if granted := len(f.granted) == 1; granted != (tt.want == nil) {
+				t.Fatalf("granted = %v", f.granted)
+			}
+		})
+	}
+}
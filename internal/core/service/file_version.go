@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// UploadVersion stores new content for an existing file; the content it
+// replaces stays in the version history. It takes write access to the file.
+func (s *S3Service) UploadVersion(c *fiber.Ctx, fileID string, header *multipart.FileHeader) (*entity.File, error) {
+	file, err := s.accessibleFile(c, fileID, entity.FileAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	if file.Quarantined() {
+		return nil, ErrVersionUnscanned
+	}
+	contentType, err := s.admitMultipart(c, UploadRouteVersion, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every version counts against the owner until it is pruned
+	if err := s.Quota.Reserve(c.UserContext(), file.UserID, header.Size, 0); err != nil {
+		return nil, err
+	}
+	content, err := s.uploadToS3(c, file.UserID, header, contentType)
+	if err != nil {
+		s.Quota.Release(c.UserContext(), file.UserID, header.Size, 0)
+		return nil, err
+	}
+
+	updated, err := s.pushVersion(c, file, &entity.FileVersion{
+		FilePath:    content.Key,
+		UrlPath:     s.S3.FileURL(content.Key),
+		FileType:    contentType,
+		FileSize:    header.Size,
+		ContentHash: content.Hash,
+		Encryption:  content.Encryption,
+	})
+	if err != nil {
+		s.releaseContents(c, []*storedContent{content})
+		s.Quota.Release(c.UserContext(), file.UserID, header.Size, 0)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ListVersions returns a file, which holds the current version, with its
+// earlier versions newest first
+func (s *S3Service) ListVersions(c *fiber.Ctx, fileID string) (*entity.File, []entity.FileVersion, error) {
+	file, err := s.accessibleFile(c, fileID, entity.FileAccessRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions, err := s.VersionRepo.List(c.UserContext(), fileID)
+	if err != nil {
+		logger.Error("Failed to list file versions", zap.String("fileID", fileID), zap.Error(err))
+		return nil, nil, errors.New("failed to list file versions")
+	}
+	return file, versions, nil
+}
+
+// DownloadVersion opens a stream on one version of a file; the returned file
+// describes that version. The caller must close the stream. Only the current
+// version can be quarantined: content joins the history once it is clean.
+func (s *S3Service) DownloadVersion(c *fiber.Ctx, fileID string, version int) (io.ReadCloser, *entity.File, error) {
+	file, err := s.accessibleFile(c, fileID, entity.FileAccessRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version == file.Version && file.Quarantined() {
+		return nil, nil, ErrFileQuarantined
+	}
+	if version != file.Version {
+		found, err := s.findVersion(c, fileID, version)
+		if err != nil {
+			return nil, nil, err
+		}
+		file.Version = found.Version
+		file.FilePath = found.FilePath
+		file.UrlPath = found.UrlPath
+		file.FileType = found.FileType
+		file.FileSize = found.FileSize
+		file.ContentHash = found.ContentHash
+		file.Encryption = found.Encryption
+		file.UploadedAt = found.CreatedAt
+	}
+
+	body, err := s.OpenRange(c.UserContext(), file, 0, -1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, file, nil
+}
+
+// RestoreVersion makes an earlier version current again by copying it into a
+// new version, so the history is never rewritten. Restoring the current
+// version changes nothing.
+func (s *S3Service) RestoreVersion(c *fiber.Ctx, fileID string, version int) (*entity.File, error) {
+	file, err := s.accessibleFile(c, fileID, entity.FileAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	if version == file.Version {
+		return file, nil
+	}
+	if file.Quarantined() {
+		return nil, ErrVersionUnscanned
+	}
+	found, err := s.findVersion(c, fileID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Quota.Reserve(c.UserContext(), file.UserID, found.FileSize, 0); err != nil {
+		return nil, err
+	}
+	next, err := s.copyVersion(c, found)
+	if err != nil {
+		s.Quota.Release(c.UserContext(), file.UserID, found.FileSize, 0)
+		return nil, err
+	}
+
+	updated, err := s.pushVersion(c, file, next)
+	if err != nil {
+		if err := releaseContent(c.UserContext(), s.BlobRepo, s.S3, next.BlobHash(), next.FilePath); err != nil {
+			logger.Error("Failed to release restored version", zap.String("key", next.FilePath), zap.Error(err))
+		}
+		s.Quota.Release(c.UserContext(), file.UserID, found.FileSize, 0)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// PruneVersions applies the configured retention to the history of every
+// tenant and gives back the storage and quota of what it removed
+func (s *S3Service) PruneVersions(ctx context.Context) error {
+	keep, maxAge := config.Config.GetVersionRetention()
+	var olderThan time.Time
+	if maxAge > 0 {
+		olderThan = time.Now().Add(-maxAge)
+	}
+
+	pruned, err := s.VersionRepo.Prune(ctx, keep, olderThan)
+	if err != nil {
+		logger.Error("Failed to prune file versions", zap.Error(err))
+		return errors.New("failed to prune file versions")
+	}
+	for _, version := range pruned {
+		tenantCtx := utils.WithTenantID(ctx, version.TenantID)
+		s.Quota.Release(tenantCtx, version.OwnerID, version.FileSize, 0)
+		if err := releaseContent(tenantCtx, s.BlobRepo, s.S3, version.BlobHash(), version.FilePath); err != nil {
+			logger.Error("Failed to delete pruned file version",
+				zap.String("fileID", version.FileID.String()),
+				zap.Int("version", version.Version),
+				zap.Error(err))
+		}
+	}
+	logger.Info("Pruned file versions", zap.Int("count", len(pruned)))
+	return nil
+}
+
+func (s *S3Service) findVersion(c *fiber.Ctx, fileID string, version int) (*entity.FileVersion, error) {
+	found, err := s.VersionRepo.Find(c.UserContext(), fileID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVersionNotFound
+		}
+		logger.Error("Failed to load file version", zap.String("fileID", fileID), zap.Int("version", version), zap.Error(err))
+		return nil, errors.New("failed to load file version")
+	}
+	return found, nil
+}
+
+// copyVersion takes a new reference to the content of an earlier version.
+// Content stored before content addressing has no blob to share and is
+// copied to a key of its own, as is encrypted content, which keeps its data
+// key.
+func (s *S3Service) copyVersion(c *fiber.Ctx, version *entity.FileVersion) (*entity.FileVersion, error) {
+	next := &entity.FileVersion{
+		FileType:    version.FileType,
+		FileSize:    version.FileSize,
+		ContentHash: version.ContentHash,
+		Encryption:  version.Encryption,
+	}
+	copyTo := func(key string) error {
+		if err := s.S3.CopyFile(c.UserContext(), version.FilePath, key); err != nil {
+			logger.Error("Failed to copy file version",
+				zap.String("key", version.FilePath),
+				zap.Error(err))
+			return errors.New("failed to restore file version")
+		}
+		return nil
+	}
+
+	if version.BlobHash() != "" {
+		blob, err := storeBlob(c.UserContext(), s.BlobRepo, s.S3, version.ContentHash, version.FileSize, copyTo)
+		if err != nil {
+			return nil, err
+		}
+		next.FilePath = blob.Key
+	} else {
+		key, err := newUploadKey(c.UserContext())
+		if err != nil {
+			return nil, err
+		}
+		if err := copyTo(key); err != nil {
+			return nil, err
+		}
+		next.FilePath = key
+	}
+	next.UrlPath = s.S3.FileURL(next.FilePath)
+	return next, nil
+}
+
+// pushVersion makes next the current content of file and has thumbnails
+// made of it
+func (s *S3Service) pushVersion(c *fiber.Ctx, file *entity.File, next *entity.FileVersion) (*entity.File, error) {
+	updated, err := s.VersionRepo.Push(c.UserContext(), file.ID, next, initialScanStatus())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFileNotFound
+		}
+		if errors.Is(err, In.ErrContentUnscanned) {
+			return nil, ErrVersionUnscanned
+		}
+		logger.Error("Failed to save file version", zap.String("fileID", file.ID.String()), zap.Error(err))
+		return nil, errors.New("failed to save file version")
+	}
+	// Thumbnails only follow the current version
+	deleteThumbnails(c.UserContext(), s.S3, file.Thumbnails)
+	enqueueThumbnails(s.Server, updated)
+	return updated, nil
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FolderService keeps the folder tree of each user
+type FolderService struct {
+	FolderRepo In.IFolderRepository
+	FileRepo   In.IFileRepository
+}
+
+// NewFolderService creates a new FolderService instance
+func NewFolderService(folderRepo In.IFolderRepository, fileRepo In.IFileRepository) InS.IFolderService {
+	return &FolderService{
+		FolderRepo: folderRepo,
+		FileRepo:   fileRepo,
+	}
+}
+
+func (s *FolderService) CreateFolder(ctx context.Context, userID uint, name string, parentID *uuid.UUID) (*entity.Folder, error) {
+	name, err := normalizeEntryName(name)
+	if err != nil {
+		return nil, err
+	}
+	if parentID != nil {
+		if _, err := s.ownedFolder(ctx, userID, parentID.String()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkFreeName(ctx, userID, parentID, name, nil); err != nil {
+		return nil, err
+	}
+
+	folder := &entity.Folder{UserID: userID, ParentID: parentID, Name: name}
+	if err := s.FolderRepo.Create(ctx, folder); err != nil {
+		logger.Error("Failed to create folder", zap.String("name", name), zap.Error(err))
+		return nil, errors.New("failed to create folder")
+	}
+	return folder, nil
+}
+
+func (s *FolderService) RenameFolder(ctx context.Context, userID uint, id, name string) (*entity.Folder, error) {
+	name, err := normalizeEntryName(name)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := s.ownedFolder(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkFreeName(ctx, userID, folder.ParentID, name, &folder.ID); err != nil {
+		return nil, err
+	}
+
+	folder.Name = name
+	if err := s.saveFolder(ctx, folder, "name"); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+func (s *FolderService) MoveFolder(ctx context.Context, userID uint, id string, parentID *uuid.UUID) (*entity.Folder, error) {
+	folder, err := s.ownedFolder(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if _, err := s.ownedFolder(ctx, userID, parentID.String()); err != nil {
+			return nil, err
+		}
+		// The destination may not sit below the folder being moved
+		ancestors, err := s.FolderRepo.Ancestors(ctx, *parentID)
+		if err != nil {
+			logger.Error("Failed to load folder ancestors", zap.String("folderID", parentID.String()), zap.Error(err))
+			return nil, errors.New("failed to move folder")
+		}
+		for _, ancestor := range ancestors {
+			if ancestor.ID == folder.ID {
+				return nil, ErrFolderCycle
+			}
+		}
+	}
+	if err := s.checkFreeName(ctx, userID, parentID, folder.Name, &folder.ID); err != nil {
+		return nil, err
+	}
+
+	folder.ParentID = parentID
+	if err := s.saveFolder(ctx, folder, "parent_id"); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// DeleteFolder deletes the whole subtree and moves its files to the trash,
+// where they can be restored to the root until they are purged
+func (s *FolderService) DeleteFolder(ctx context.Context, userID uint, id string) error {
+	folder, err := s.ownedFolder(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.FolderRepo.DeleteTree(ctx, folder.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrFolderNotFound
+		}
+		logger.Error("Failed to delete folder", zap.String("folderID", id), zap.Error(err))
+		return errors.New("failed to delete folder")
+	}
+	return nil
+}
+
+// ListPath walks the path one name at a time from the root; empty segments
+// are ignored so "/", "" and "/docs/" all work
+func (s *FolderService) ListPath(ctx context.Context, userID uint, path string, query In.FileListQuery) (*InS.FolderContents, error) {
+	var folder *entity.Folder
+	var parentID *uuid.UUID
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		child, err := s.FolderRepo.FindChild(ctx, userID, parentID, name)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrFolderNotFound
+			}
+			logger.Error("Failed to resolve folder path", zap.String("path", path), zap.Error(err))
+			return nil, errors.New("failed to list folder")
+		}
+		folder, parentID = child, &child.ID
+	}
+	return s.list(ctx, userID, folder, query)
+}
+
+func (s *FolderService) ListFolder(ctx context.Context, userID uint, id string, query In.FileListQuery) (*InS.FolderContents, error) {
+	folder, err := s.ownedFolder(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.list(ctx, userID, folder, query)
+}
+
+func (s *FolderService) RenameFile(ctx context.Context, userID uint, fileID, name string) (*entity.File, error) {
+	name, err := normalizeEntryName(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.ownedFile(ctx, userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	file.FileName = name
+	if err := s.saveFile(ctx, file, "file_name"); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *FolderService) MoveFile(ctx context.Context, userID uint, fileID string, folderID *uuid.UUID) (*entity.File, error) {
+	file, err := s.ownedFile(ctx, userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if folderID != nil {
+		if _, err := s.ownedFolder(ctx, userID, folderID.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	file.FolderID = folderID
+	if err := s.saveFile(ctx, file, "folder_id"); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// list returns the subfolders and a page of files of folder, nil being the root
+func (s *FolderService) list(ctx context.Context, userID uint, folder *entity.Folder, query In.FileListQuery) (*InS.FolderContents, error) {
+	contents := &InS.FolderContents{Folder: folder, Breadcrumbs: []entity.Folder{}}
+	var parentID *uuid.UUID
+	fileFolder := uuid.Nil
+	if folder != nil {
+		ancestors, err := s.FolderRepo.Ancestors(ctx, folder.ID)
+		if err != nil {
+			logger.Error("Failed to load folder ancestors", zap.String("folderID", folder.ID.String()), zap.Error(err))
+			return nil, errors.New("failed to list folder")
+		}
+		contents.Breadcrumbs = ancestors
+		parentID = &folder.ID
+		fileFolder = folder.ID
+	}
+
+	folders, err := s.FolderRepo.ListChildren(ctx, userID, parentID)
+	if err != nil {
+		logger.Error("Failed to list folders", zap.Uint("userID", userID), zap.Error(err))
+		return nil, errors.New("failed to list folder")
+	}
+	contents.Folders = folders
+
+	query.UserID = userID
+	query.SharedWith = false
+	query.Folder = &fileFolder
+	if query.Sort == "" {
+		query.Sort = In.FileSortName
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultFileListLimit
+	}
+	if query.Limit > maxFileListLimit {
+		query.Limit = maxFileListLimit
+	}
+	contents.Files, err = s.FileRepo.List(ctx, query)
+	if err != nil {
+		if errors.Is(err, In.ErrInvalidCursor) || errors.Is(err, In.ErrInvalidSort) {
+			return nil, err
+		}
+		logger.Error("Failed to list folder files", zap.Uint("userID", userID), zap.Error(err))
+		return nil, errors.New("failed to list folder")
+	}
+	return contents, nil
+}
+
+// ownedFolder returns a live folder of userID
+func (s *FolderService) ownedFolder(ctx context.Context, userID uint, id string) (*entity.Folder, error) {
+	folder, err := s.FolderRepo.FindByID(ctx, id)
+	if err != nil || folder.UserID != userID {
+		return nil, ErrFolderNotFound
+	}
+	return folder, nil
+}
+
+// ownedFile returns a live file of userID; only owners rearrange their tree
+func (s *FolderService) ownedFile(ctx context.Context, userID uint, id string) (*entity.File, error) {
+	var file entity.File
+	if err := s.FileRepo.FindByID(ctx, id, &file); err != nil || file.UserID != userID || file.IsDeleted {
+		return nil, ErrFileNotFound
+	}
+	return &file, nil
+}
+
+// checkFreeName fails when another folder than self already uses name under
+// parentID; names are compared case-insensitively
+func (s *FolderService) checkFreeName(ctx context.Context, userID uint, parentID *uuid.UUID, name string, self *uuid.UUID) error {
+	existing, err := s.FolderRepo.FindChild(ctx, userID, parentID, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		logger.Error("Failed to check folder name", zap.String("name", name), zap.Error(err))
+		return errors.New("failed to check folder name")
+	}
+	if self != nil && existing.ID == *self {
+		return nil
+	}
+	return ErrFolderExists
+}
+
+// saveFolder writes the given columns of folder only, so concurrent changes
+// to the others are kept
+func (s *FolderService) saveFolder(ctx context.Context, folder *entity.Folder, columns ...string) error {
+	if err := s.FolderRepo.UpdateColumns(ctx, folder, columns...); err != nil {
+		logger.Error("Failed to update folder", zap.String("folderID", folder.ID.String()), zap.Error(err))
+		return errors.New("failed to update folder")
+	}
+	return nil
+}
+
+// saveFile writes the given columns of file only; a version push or scan
+// verdict landing meanwhile must not be undone
+func (s *FolderService) saveFile(ctx context.Context, file *entity.File, columns ...string) error {
+	if err := s.FileRepo.UpdateColumns(ctx, file, columns...); err != nil {
+		logger.Error("Failed to update file", zap.String("fileID", file.ID.String()), zap.Error(err))
+		return errors.New("failed to update file")
+	}
+	return nil
+}
+
+// normalizeEntryName trims a file or folder name and rejects names that
+// would break path lookups
+func normalizeEntryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") || utf8.RuneCountInString(name) > 255 {
+		return "", ErrFolderName
+	}
+	return name, nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"github.com/RichardKnop/machinery/v2"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+	notificationEmailTask    = "send_notification_email"
+)
+
+const (
+	EventNotificationCreated = "notification.created"
+	EventImportCompleted     = "import.completed"
+)
+
+var ErrNotificationCategory = errors.New("unknown notification category")
+
+// NotificationService is the single entry point for user communication. It
+// writes the in-app inbox and queues emails according to user preferences.
+type NotificationService struct {
+	repo           In.INotificationRepository
+	preferenceRepo In.INotificationPreferenceRepository
+	userRepo       In.IUserRepository
+	server         *machinery.Server
+	events         In.IEventBus
+}
+
+// NewNotificationService creates a new NotificationService instance
+func NewNotificationService(repo In.INotificationRepository, preferenceRepo In.INotificationPreferenceRepository, userRepo In.IUserRepository, server *machinery.Server, events In.IEventBus) InS.INotificationService {
+	return &NotificationService{
+		repo:           repo,
+		preferenceRepo: preferenceRepo,
+		userRepo:       userRepo,
+		server:         server,
+		events:         events,
+	}
+}
+
+// Notify delivers a notification to the inbox and by email as allowed by the
+// recipient's preferences for its category
+func (s *NotificationService) Notify(ctx context.Context, notification InS.Notification) error {
+	if _, ok := entity.DefaultNotificationChannels[notification.Category]; !ok {
+		return ErrNotificationCategory
+	}
+
+	user := notification.User
+	if user == nil {
+		var err error
+		if user, err = s.userRepo.GetById(ctx, notification.UserID); err != nil {
+			logger.Error("Failed to load notification recipient", zap.Uint("userID", notification.UserID), zap.Error(err))
+			return fmt.Errorf("notification recipient not found: %w", err)
+		}
+	}
+
+	channels, err := s.channelsFor(ctx, user.ID, notification.Category)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if channels.InApp {
+		inbox := &entity.Notification{
+			UserID:   user.ID,
+			Category: notification.Category,
+			Title:    notification.Title,
+			Body:     notification.Body,
+		}
+		if err := s.repo.Create(ctx, inbox); err != nil {
+			logger.Error("Failed to store notification", zap.Uint("userID", user.ID), zap.Error(err))
+			errs = append(errs, fmt.Errorf("failed to store notification: %w", err))
+		} else if err := s.events.Publish(ctx, user.ID, EventNotificationCreated, inbox); err != nil {
+			logger.Warn("Failed to publish notification event", zap.Uint("userID", user.ID), zap.Error(err))
+		}
+	}
+	if channels.Email {
+		if err := s.sendEmail(user, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *NotificationService) ListNotifications(ctx context.Context, userID uint, unreadOnly bool, beforeID uint, limit int) ([]entity.Notification, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	notifications, err := s.repo.ListByUser(ctx, userID, unreadOnly, beforeID, limit)
+	if err != nil {
+		return nil, wrapError(errors.New("failed to list notifications"), err)
+	}
+	return notifications, nil
+}
+
+func (s *NotificationService) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	count, err := s.repo.CountUnread(ctx, userID)
+	if err != nil {
+		return 0, wrapError(errors.New("failed to count notifications"), err)
+	}
+	return count, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID uint, id uint) error {
+	if err := s.repo.MarkRead(ctx, userID, id); err != nil {
+		return wrapError(errors.New("notification not found"), err)
+	}
+	return nil
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	count, err := s.repo.MarkAllRead(ctx, userID)
+	if err != nil {
+		return 0, wrapError(errors.New("failed to mark notifications as read"), err)
+	}
+	return count, nil
+}
+
+// GetPreferences returns the effective channels of every category for the user
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uint) (map[string]entity.NotificationChannels, error) {
+	preferences, err := s.preferenceRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, wrapError(errors.New("failed to load notification preferences"), err)
+	}
+
+	result := make(map[string]entity.NotificationChannels, len(entity.DefaultNotificationChannels))
+	for category, channels := range entity.DefaultNotificationChannels {
+		result[category] = channels
+	}
+	for _, preference := range preferences {
+		if _, ok := result[preference.Category]; ok {
+			result[preference.Category] = entity.NotificationChannels{Email: preference.Email, InApp: preference.InApp}
+		}
+	}
+	for category, channels := range result {
+		if entity.IsMandatoryEmail(category) {
+			channels.Email = true
+			result[category] = channels
+		}
+	}
+	return result, nil
+}
+
+// UpdatePreferences stores the channels of the given categories and returns the effective preferences
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uint, preferences map[string]entity.NotificationChannels) (map[string]entity.NotificationChannels, error) {
+	for category := range preferences {
+		if _, ok := entity.DefaultNotificationChannels[category]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotificationCategory, category)
+		}
+	}
+	for category, channels := range preferences {
+		preference := &entity.NotificationPreference{
+			UserID:   userID,
+			Category: category,
+			Email:    channels.Email || entity.IsMandatoryEmail(category),
+			InApp:    channels.InApp,
+		}
+		if err := s.preferenceRepo.Upsert(ctx, preference); err != nil {
+			logger.Error("Failed to save notification preference", zap.Uint("userID", userID), zap.String("category", category), zap.Error(err))
+			return nil, wrapError(errors.New("failed to save notification preferences"), err)
+		}
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// channelsFor returns the effective channels of one category for the user
+func (s *NotificationService) channelsFor(ctx context.Context, userID uint, category string) (entity.NotificationChannels, error) {
+	preferences, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return entity.NotificationChannels{}, err
+	}
+	return preferences[category], nil
+}
+
+// sendEmail queues the email task of the notification
+func (s *NotificationService) sendEmail(user *entity.User, notification InS.Notification) error {
+	signature := &tasks.Signature{
+		Name: notificationEmailTask,
+		Args: []tasks.Arg{
+			{Type: "string", Value: user.Email},
+			{Type: "string", Value: notification.Title},
+			{Type: "string", Value: user.FirstName},
+			{Type: "string", Value: notification.Body},
+		},
+	}
+	if notification.Email != nil {
+		signature = &tasks.Signature{
+			Name: notification.Email.Task,
+			Args: []tasks.Arg{
+				{Type: "string", Value: user.Email},
+				{Type: "string", Value: notification.Email.Token},
+				{Type: "string", Value: user.FirstName},
+				{Type: "string", Value: config.Config.GetServerURL()},
+				{Type: "string", Value: user.TenantID},
+			},
+		}
+	}
+
+	if _, err := s.server.SendTask(signature); err != nil {
+		logger.Error("Failed to queue notification email task",
+			zap.String("task", signature.Name),
+			zap.String("email", user.Email),
+			zap.Error(err))
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	logger.Info("Successfully queued notification email task", zap.String("task", signature.Name), zap.String("email", user.Email))
+	return nil
+}
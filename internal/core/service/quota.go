@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// QuotaService keeps per-user and per-tenant storage usage within quota plans
+type QuotaService struct {
+	repo In.IQuotaRepository
+}
+
+// NewQuotaService creates a new QuotaService instance
+func NewQuotaService(repo In.IQuotaRepository) InS.IQuotaService {
+	return &QuotaService{repo: repo}
+}
+
+func (s *QuotaService) Reserve(ctx context.Context, userID uint, bytes, files int64) error {
+	err := s.repo.Reserve(ctx, userID, bytes, files, config.Config.GetDefaultQuota())
+	if errors.Is(err, In.ErrQuotaExceeded) {
+		logger.Warn("Upload rejected by storage quota",
+			zap.Uint("userID", userID),
+			zap.Int64("bytes", bytes),
+			zap.Int64("files", files))
+		return ErrQuotaExceeded
+	}
+	if err != nil {
+		logger.Error("Failed to reserve storage quota", zap.Uint("userID", userID), zap.Error(err))
+		return errors.New("failed to reserve storage quota")
+	}
+	return nil
+}
+
+// Release only logs failures; the reconciliation job repairs the counters
+func (s *QuotaService) Release(ctx context.Context, userID uint, bytes, files int64) {
+	if err := s.repo.Release(ctx, userID, bytes, files); err != nil {
+		logger.Error("Failed to release storage quota",
+			zap.Uint("userID", userID),
+			zap.Int64("bytes", bytes),
+			zap.Error(err))
+	}
+}
+
+func (s *QuotaService) Check(ctx context.Context, userID uint, bytes int64) error {
+	for _, id := range []uint{userID, entity.OrgUsageUserID} {
+		usage, err := s.GetUsage(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !usage.Limits.Allows(usage.Bytes+bytes, usage.Files+1) {
+			return ErrQuotaExceeded
+		}
+	}
+	return nil
+}
+
+func (s *QuotaService) GetUsage(ctx context.Context, userID uint) (*InS.QuotaUsage, error) {
+	usage, err := s.repo.GetUsage(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to load storage usage", zap.Uint("userID", userID), zap.Error(err))
+		return nil, errors.New("failed to load storage usage")
+	}
+	return &InS.QuotaUsage{
+		Plan:   usage.Plan,
+		Bytes:  usage.Bytes,
+		Files:  usage.Files,
+		Limits: usage.Limits(config.Config.GetDefaultQuota()),
+	}, nil
+}
+
+func (s *QuotaService) CreatePlan(ctx context.Context, plan *entity.QuotaPlan) error {
+	if err := s.repo.CreatePlan(ctx, plan); err != nil {
+		logger.Error("Failed to create quota plan", zap.String("name", plan.Name), zap.Error(err))
+		return errors.New("failed to create quota plan")
+	}
+	return nil
+}
+
+func (s *QuotaService) ListPlans(ctx context.Context) ([]entity.QuotaPlan, error) {
+	plans, err := s.repo.ListPlans(ctx)
+	if err != nil {
+		logger.Error("Failed to list quota plans", zap.Error(err))
+		return nil, errors.New("failed to list quota plans")
+	}
+	return plans, nil
+}
+
+func (s *QuotaService) AssignPlan(ctx context.Context, userID uint, planID *uint) error {
+	if planID != nil {
+		if _, err := s.repo.GetPlan(ctx, *planID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrQuotaPlan
+			}
+			logger.Error("Failed to load quota plan", zap.Uint("planID", *planID), zap.Error(err))
+			return errors.New("failed to assign quota plan")
+		}
+	}
+	if err := s.repo.AssignPlan(ctx, userID, planID); err != nil {
+		logger.Error("Failed to assign quota plan", zap.Uint("userID", userID), zap.Error(err))
+		return errors.New("failed to assign quota plan")
+	}
+	return nil
+}
+
+func (s *QuotaService) Reconcile(ctx context.Context) error {
+	if err := s.repo.Reconcile(ctx); err != nil {
+		logger.Error("Failed to reconcile storage usage", zap.Error(err))
+		return errors.New("failed to reconcile storage usage")
+	}
+	return nil
+}
+
+func (s *QuotaService) ReconcileAll(ctx context.Context) error {
+	if err := s.repo.ReconcileAll(ctx); err != nil {
+		logger.Error("Failed to reconcile storage usage", zap.Error(err))
+		return errors.New("failed to reconcile storage usage")
+	}
+	logger.Info("Storage usage reconciled")
+	return nil
+}
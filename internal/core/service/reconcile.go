@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"github.com/RichardKnop/machinery/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxReportedItems caps each list of a reconcile report
+const maxReportedItems = 1000
+
+type ReconcileService struct {
+	RefRepo  In.IStorageRefRepository
+	FileRepo In.IFileRepository
+	S3       In.IS3Repository
+	// Server queues the thumbnails of files whose thumbnails went missing;
+	// nil leaves them to be made again on the next upload
+	Server *machinery.Server
+}
+
+func NewReconcileService(refRepo In.IStorageRefRepository, fileRepo In.IFileRepository, s3Repo In.IS3Repository, server *machinery.Server) InS.IReconcileService {
+	return &ReconcileService{
+		RefRepo:  refRepo,
+		FileRepo: fileRepo,
+		S3:       s3Repo,
+		Server:   server,
+	}
+}
+
+// listedObject is what the listing says of an object
+type listedObject struct {
+	size       int64
+	modified   time.Time
+	referenced bool
+}
+
+// Reconcile lists storage first and the rows second, so an object stored
+// during the run is either listed or not yet referenced, and the grace
+// period keeps the latter from being reported. Every finding is checked
+// again in storage before it is reported as missing or deleted as orphaned.
+//
+// A repair deletes orphaned objects and clears missing thumbnails so they
+// are made again. Content that has gone missing is only reported: the only
+// fix left would be deleting the file, which is for a person to decide.
+func (s *ReconcileService) Reconcile(ctx context.Context, options InS.ReconcileOptions) (*InS.ReconcileReport, error) {
+	report := &InS.ReconcileReport{
+		DryRun:    !options.Repair,
+		StartedAt: time.Now(),
+		Orphans:   []InS.OrphanObject{},
+		Missing:   []InS.MissingObject{},
+	}
+	cutoff := report.StartedAt.Add(-options.GracePeriod)
+
+	objects := make(map[string]*listedObject)
+	err := s.S3.ListObjects(ctx, options.Prefix, func(info In.ObjectInfo) error {
+		objects[info.Key] = &listedObject{size: info.Size, modified: info.LastModified}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to list stored objects", zap.Error(err))
+		return nil, errors.New("failed to list stored objects")
+	}
+	report.Objects = len(objects)
+
+	var missing []In.StorageRef
+	err = s.RefRepo.EachRef(ctx, func(ref In.StorageRef) error {
+		report.References++
+		if object, ok := objects[ref.Key]; ok {
+			object.referenced = true
+			return nil
+		}
+		// Uploads in progress may not have sent their object yet
+		if ref.Kind != In.StorageRefUpload && strings.HasPrefix(ref.Key, options.Prefix) {
+			missing = append(missing, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to load storage references", zap.Error(err))
+		return nil, errors.New("failed to load storage references")
+	}
+
+	missing = s.stillMissing(ctx, missing)
+	for _, ref := range missing {
+		report.MissingCount++
+		if len(report.Missing) < maxReportedItems {
+			report.Missing = append(report.Missing, InS.MissingObject{
+				Key:      ref.Key,
+				Kind:     ref.Kind,
+				TenantID: ref.TenantID,
+				ID:       ref.ID,
+			})
+		}
+	}
+
+	for key, object := range objects {
+		if object.referenced || !object.modified.Before(cutoff) {
+			continue
+		}
+		report.OrphanCount++
+		report.OrphanBytes += object.size
+		if len(report.Orphans) < maxReportedItems {
+			report.Orphans = append(report.Orphans, InS.OrphanObject{
+				Key:          key,
+				Size:         object.size,
+				LastModified: object.modified,
+			})
+		}
+		if options.Repair {
+			s.deleteOrphan(ctx, key, cutoff, report)
+		}
+	}
+
+	if options.Repair {
+		s.clearMissingThumbnails(ctx, missing, report)
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// ReconcileScheduled is the periodic job; whether it repairs is up to
+// configuration
+func (s *ReconcileService) ReconcileScheduled(ctx context.Context) error {
+	report, err := s.Reconcile(ctx, InS.ReconcileOptions{
+		Repair:      config.Config.Reconcile.Repair,
+		Prefix:      config.Config.Reconcile.Prefix,
+		GracePeriod: config.Config.GetReconcileGracePeriod(),
+	})
+	if err != nil {
+		return err
+	}
+
+	fields := []zap.Field{
+		zap.Bool("dryRun", report.DryRun),
+		zap.Int("objects", report.Objects),
+		zap.Int("references", report.References),
+		zap.Int("orphans", report.OrphanCount),
+		zap.Int64("orphanBytes", report.OrphanBytes),
+		zap.Int("missing", report.MissingCount),
+		zap.Int("deletedObjects", report.DeletedObjects),
+		zap.Int("clearedThumbnails", report.ClearedThumbnails),
+		zap.Int("repairFailures", report.RepairFailures),
+		zap.Duration("took", report.FinishedAt.Sub(report.StartedAt)),
+	}
+	if report.OrphanCount > 0 || report.MissingCount > 0 || report.RepairFailures > 0 {
+		logger.Warn("Storage and database disagree", fields...)
+		for _, ref := range report.Missing {
+			logger.Warn("Stored object is missing",
+				zap.String("key", ref.Key),
+				zap.String("kind", ref.Kind),
+				zap.String("tenantID", ref.TenantID),
+				zap.String("id", ref.ID))
+		}
+		return nil
+	}
+	logger.Info("Storage and database agree", fields...)
+	return nil
+}
+
+// stillMissing drops the references whose object has shown up since the
+// listing, asking storage once per key
+func (s *ReconcileService) stillMissing(ctx context.Context, refs []In.StorageRef) []In.StorageRef {
+	found := make(map[string]bool)
+	var missing []In.StorageRef
+	for _, ref := range refs {
+		exists, checked := found[ref.Key]
+		if !checked {
+			_, err := s.S3.StatFile(ctx, ref.Key)
+			if err != nil && !errors.Is(err, In.ErrObjectNotFound) {
+				// Not knowing is not the same as missing
+				logger.Warn("Failed to check stored object", zap.String("key", ref.Key), zap.Error(err))
+			}
+			exists = !errors.Is(err, In.ErrObjectNotFound)
+			found[ref.Key] = exists
+		}
+		if !exists {
+			missing = append(missing, ref)
+		}
+	}
+	return missing
+}
+
+// deleteOrphan deletes an orphaned object unless it was written again since
+// the listing, which content addressing does when the same content is
+// uploaded once more
+func (s *ReconcileService) deleteOrphan(ctx context.Context, key string, cutoff time.Time, report *InS.ReconcileReport) {
+	info, err := s.S3.StatFile(ctx, key)
+	if errors.Is(err, In.ErrObjectNotFound) {
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to check orphaned object", zap.String("key", key), zap.Error(err))
+		report.RepairFailures++
+		return
+	}
+	if !info.LastModified.Before(cutoff) {
+		return
+	}
+	if err := s.S3.DeleteFile(ctx, key); err != nil {
+		logger.Error("Failed to delete orphaned object", zap.String("key", key), zap.Error(err))
+		report.RepairFailures++
+		return
+	}
+	logger.Info("Deleted orphaned object", zap.String("key", key), zap.Int64("size", info.Size))
+	report.DeletedObjects++
+}
+
+// clearMissingThumbnails empties the thumbnails of every file with one
+// missing and asks the worker for new ones
+func (s *ReconcileService) clearMissingThumbnails(ctx context.Context, missing []In.StorageRef, report *InS.ReconcileReport) {
+	cleared := make(map[string]bool)
+	for _, ref := range missing {
+		if ref.Kind != In.StorageRefThumbnail || cleared[ref.ID] {
+			continue
+		}
+		cleared[ref.ID] = true
+
+		tenantCtx := utils.WithTenantID(ctx, ref.TenantID)
+		var file entity.File
+		if err := s.FileRepo.FindByID(tenantCtx, ref.ID, &file); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Error("Failed to load file with missing thumbnails", zap.String("fileID", ref.ID), zap.Error(err))
+				report.RepairFailures++
+			}
+			continue
+		}
+		err := s.FileRepo.SetThumbnails(tenantCtx, file.ID, file.Version, []entity.Thumbnail{})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Deleted, or moved to a new version with new thumbnails
+			continue
+		}
+		if err != nil {
+			logger.Error("Failed to clear missing thumbnails", zap.String("fileID", ref.ID), zap.Error(err))
+			report.RepairFailures++
+			continue
+		}
+		report.ClearedThumbnails++
+		if s.Server != nil {
+			enqueueThumbnails(s.Server, &file)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"io"
 	"mime/multipart"
 	"project-api/internal/core/entity"
 	In "project-api/internal/core/port/repository"
@@ -9,21 +11,45 @@ import (
 	"project-api/internal/infra/logger"
 	"time"
 
+	"github.com/RichardKnop/machinery/v2"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 )
 
 // S3Service handles file operations with S3 and database
 type S3Service struct {
-	FileRepo In.IFileRepository
-	S3       In.IS3Repository
+	FileRepo   In.IFileRepository
+	UploadRepo In.IUploadRepository
+	BlobRepo   In.IBlobRepository
+	// PermissionRepo holds the grants that open files to users other than the owner
+	PermissionRepo In.IFilePermissionRepository
+	// UserRepo finds the users files are granted to
+	UserRepo In.IUserRepository
+	// VersionRepo holds the earlier contents of files
+	VersionRepo In.IFileVersionRepository
+	S3          In.IS3Repository
+	Notifier    InS.INotificationService
+	Quota       InS.IQuotaService
+	// Encryption seals new content when it is on and opens whatever was sealed
+	Encryption InS.IEncryptionService
+	// Server queues the thumbnail task of image uploads
+	Server *machinery.Server
 }
 
 // NewS3Service creates a new S3Service instance
-func NewS3Service(fileRepo In.IFileRepository, s3Repo In.IS3Repository) InS.IS3Service {
+func NewS3Service(fileRepo In.IFileRepository, uploadRepo In.IUploadRepository, blobRepo In.IBlobRepository, permissionRepo In.IFilePermissionRepository, userRepo In.IUserRepository, versionRepo In.IFileVersionRepository, s3Repo In.IS3Repository, notifier InS.INotificationService, quota InS.IQuotaService, encryption InS.IEncryptionService, server *machinery.Server) InS.IS3Service {
 	return &S3Service{
-		S3:       s3Repo,
-		FileRepo: fileRepo,
+		S3:             s3Repo,
+		FileRepo:       fileRepo,
+		UploadRepo:     uploadRepo,
+		BlobRepo:       blobRepo,
+		PermissionRepo: permissionRepo,
+		UserRepo:       userRepo,
+		VersionRepo:    versionRepo,
+		Notifier:       notifier,
+		Quota:          quota,
+		Encryption:     encryption,
+		Server:         server,
 	}
 }
 
@@ -38,48 +64,63 @@ func (s *S3Service) UploadFile(c *fiber.Ctx, files []*multipart.FileHeader, expi
 		return nil, err
 	}
 
-	var urls []string
-	keys := make([]string, len(files))
+	var totalSize int64
+	contentTypes := make([]string, len(files))
 	for i, file := range files {
-		if err := s.validateUploadInput(file, expir); err != nil {
+		contentTypes[i], err = s.validateUploadInput(c, file, expir)
+		if err != nil {
 			return nil, err
 		}
+		totalSize += file.Size
+	}
+
+	var expiresAt *time.Time
+	if *expir > 0 {
+		at := time.Now().Add(*expir)
+		expiresAt = &at
+	}
+
+	// The whole batch is counted up front so concurrent uploads can't
+	// overshoot the quota together
+	fileCount := int64(len(files))
+	if err := s.Quota.Reserve(c.UserContext(), userID, totalSize, fileCount); err != nil {
+		return nil, err
+	}
 
-		key := s.generateKey(file)
-		url, err := s.uploadToS3(file, key, expir)
+	var urls []string
+	contents := make([]*storedContent, len(files))
+	for i, file := range files {
+		content, err := s.uploadToS3(c, userID, file, contentTypes[i])
 		if err != nil {
 			// Cleanup ไฟล์ที่อัปโหลดไปแล้ว
-			for _, uploadedKey := range keys[:i] {
-				s.cleanupS3File(uploadedKey)
-			}
+			s.releaseContents(c, contents[:i])
+			s.Quota.Release(c.UserContext(), userID, totalSize, fileCount)
 			return nil, err
 		}
-		urls = append(urls, url)
-		keys[i] = key
+		urls = append(urls, s.S3.FileURL(content.Key))
+		contents[i] = content
 	}
 
 	// บันทึก metadata ใน transaction เดียว
-	tx := s.FileRepo.BeginTransaction(c.Context())
+	tx := s.FileRepo.BeginTransaction(c.UserContext())
 	if tx.Error != nil {
 		logger.Error("Failed to start transaction", zap.Error(tx.Error))
-		s.cleanupS3Files(keys) // Cleanup ถ้าเริ่ม transaction ไม่ได้
+		s.releaseContents(c, contents) // Cleanup ถ้าเริ่ม transaction ไม่ได้
+		s.Quota.Release(c.UserContext(), userID, totalSize, fileCount)
 		return nil, errors.New("failed to start transaction")
 	}
 
+	created := make([]*entity.File, len(files))
 	for i, file := range files {
-		if err := s.checkFileExists(c, tx, keys[i]); err != nil {
-			tx.Rollback()
-			s.cleanupS3Files(keys)
-			return nil, err
-		}
-
-		newFile := s.createFileEntity(userID, file, keys[i], urls[i])
-		if err := s.FileRepo.Create(c.Context(), newFile); err != nil {
+		newFile := s.createFileEntity(userID, file, contentTypes[i], contents[i], urls[i], expiresAt)
+		created[i] = newFile
+		if err := s.FileRepo.Create(c.UserContext(), newFile); err != nil {
 			tx.Rollback()
 			logger.Error("Failed to save file metadata",
-				zap.String("key", keys[i]),
+				zap.String("key", contents[i].Key),
 				zap.Error(err))
-			s.cleanupS3Files(keys)
+			s.releaseContents(c, contents)
+			s.Quota.Release(c.UserContext(), userID, totalSize, fileCount)
 			return nil, errors.New("failed to save file metadata")
 		}
 	}
@@ -87,48 +128,65 @@ func (s *S3Service) UploadFile(c *fiber.Ctx, files []*multipart.FileHeader, expi
 	if err != nil {
 		tx.Rollback()
 		logger.Error("Failed to commit transaction", zap.Error(err))
-		s.cleanupS3Files(keys)
+		s.releaseContents(c, contents)
+		s.Quota.Release(c.UserContext(), userID, totalSize, fileCount)
 		return nil, errors.New("failed to commit transaction")
 	}
 
+	fileNames := make([]string, len(files))
+	for i, file := range files {
+		fileNames[i] = file.Filename
+		enqueueThumbnails(s.Server, created[i])
+	}
+	s.notifyUploaded(c, userID, fileNames)
 	return urls, nil
 }
 
-// DeleteFile marks a file as deleted in the database and removes it from S3
+// DeleteFile moves a file to the trash; it takes write access to the file.
+// The content stays in S3, and counts against the owner's quota, until the
+// file is restored or purged.
 func (s *S3Service) DeleteFile(c *fiber.Ctx, key string) error {
 	userID, err := s.getUserID(c)
 	if err != nil {
 		return err
 	}
 
-	file, err := s.markFileAsDeleted(c, key, userID)
-	if err != nil {
-		return err
-	}
-
-	if err := s.S3.DeleteFile(file.FilePath); err != nil {
-		return s.handleS3DeleteError(file.FilePath, err)
-	}
-
-	return nil
+	_, err = s.markFileAsDeleted(c, key, userID)
+	return err
 }
 
-// DownloadFile retrieves a file from S3 after verifying ownership
-func (s *S3Service) DownloadFile(c *fiber.Ctx, key string) ([]byte, *entity.File, error) {
-	userID, err := s.getUserID(c)
+// DownloadFile opens a stream on a file in S3 after verifying read access; the caller must close it
+func (s *S3Service) DownloadFile(c *fiber.Ctx, key string) (io.ReadCloser, *entity.File, error) {
+	file, err := s.StatDownload(c, key)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	file, err := s.verifyAndLockFile(c, key, userID)
+	body, err := s.OpenRange(c.UserContext(), file, 0, -1)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	data, err := s.S3.DownloadFile(file.FilePath)
+	return body, file, nil
+}
+
+// StatDownload verifies the caller may download a file and returns it
+// without opening its content
+func (s *S3Service) StatDownload(c *fiber.Ctx, key string) (*entity.File, error) {
+	userID, err := s.getUserID(c)
 	if err != nil {
-		return nil, nil, s.handleS3DownloadError(err)
+		return nil, err
 	}
+	return s.verifyAndLockFile(c, key, userID)
+}
 
-	return data, file, nil
+// OpenRange streams length bytes of a file returned by StatDownload,
+// starting at offset, or all of it for a negative length; the caller must
+// close it
+func (s *S3Service) OpenRange(ctx context.Context, file *entity.File, offset, length int64) (io.ReadCloser, error) {
+	body, err := s.Encryption.Open(ctx, file.FilePath, file.Encryption, file.FileSize, offset, length)
+	if err != nil {
+		return nil, s.handleS3DownloadError(err)
+	}
+	return body, nil
 }
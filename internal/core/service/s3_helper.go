@@ -1,34 +1,55 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"mime/multipart"
 	"project-api/internal/core/common/utils"
 	"project-api/internal/core/entity"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
 	"project-api/internal/infra/logger"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
-// validateUploadInput checks input constraints for file upload
-func (s *S3Service) validateUploadInput(file *multipart.FileHeader, expir *time.Duration) error {
+// MaxFileSize is the largest object a single presigned PUT can store and so
+// the ceiling of every upload rule; uploads are streamed so memory use
+// doesn't grow with size
+const MaxFileSize = 5 << 30 // 5GB
+
+// validateUploadInput checks input constraints for file upload and returns
+// the content type detected for the file; an expiry of zero keeps the file
+// until it is deleted
+func (s *S3Service) validateUploadInput(c *fiber.Ctx, file *multipart.FileHeader, expir *time.Duration) (string, error) {
 	if expir == nil {
 		logger.Error("Expiration duration is required")
-		return errors.New("expiration duration is required")
+		return "", errors.New("expiration duration is required")
+	}
+	if maxExpiry := config.Config.GetFileMaxExpiry(); *expir < 0 || (maxExpiry > 0 && *expir > maxExpiry) {
+		logger.Error("Expiration duration out of range",
+			zap.Duration("expiry", *expir),
+			zap.Duration("maxExpiry", maxExpiry))
+		return "", ErrFileExpiry
 	}
+	return s.admitMultipart(c, UploadRouteMultipart, file)
+}
 
-	const maxFileSize = 10 << 20 // 10MB
-	if file.Size > maxFileSize {
-		logger.Error("File size exceeds limit",
-			zap.Int64("size", file.Size),
-			zap.String("filename", file.Filename),
-			zap.Int64("maxSize", maxFileSize))
-		return errors.New("file size exceeds 10MB limit")
+// admitMultipart checks a multipart file against the upload rule of route
+// and returns its content type
+func (s *S3Service) admitMultipart(c *fiber.Ctx, route string, file *multipart.FileHeader) (string, error) {
+	head, err := readFileHead(file)
+	if err != nil {
+		return "", err
 	}
-	return nil
+	return admitUpload(c.UserContext(), route, file.Filename, file.Header.Get("Content-Type"), file.Size, head)
 }
 
 // getUserID retrieves the authenticated user's ID from context
@@ -41,98 +62,112 @@ func (s *S3Service) getUserID(c *fiber.Ctx) (uint, error) {
 	return userIDFromContext.UserID, nil
 }
 
-// generateKey creates a unique key for S3 storage
-func (s *S3Service) generateKey(file *multipart.FileHeader) string {
-	return "file/" + file.Header.Get("Content-Type") + "/" + file.Filename
+// newUploadKey creates a tenant-prefixed key for a file that arrives straight
+// at storage; the file name stays in the database only
+func newUploadKey(ctx context.Context) (string, error) {
+	key, err := utils.TenantKey(ctx, "file/upload/"+uuid.NewString())
+	if err != nil {
+		logger.Error("Failed to generate storage key", zap.Error(err))
+		return "", errors.New("tenant is required")
+	}
+	return key, nil
 }
 
-// uploadToS3 uploads a file to S3 and returns the URL
-func (s *S3Service) uploadToS3(file *multipart.FileHeader, key string, expir *time.Duration) (string, error) {
-	url, err := s.S3.UploadFile(file, expir)
+// uploadToS3 hashes a file and streams it to its content-addressed key,
+// unless the tenant already stores the same content. With encryption on, it
+// is sealed for userID, who owns the file, instead.
+func (s *S3Service) uploadToS3(c *fiber.Ctx, userID uint, file *multipart.FileHeader, contentType string) (*storedContent, error) {
+	src, err := file.Open()
 	if err != nil {
-		logger.Error("Failed to upload file to S3",
-			zap.String("key", key),
+		logger.Error("Failed to open uploaded file",
+			zap.String("filename", file.Filename),
 			zap.Error(err))
-		return "", errors.New("failed to upload file to S3")
+		return nil, errors.New("failed to open uploaded file")
 	}
-	return url, nil
-}
+	defer src.Close()
 
-// saveFileMetadata saves file metadata to the database within a transaction
-func (s *S3Service) saveFileMetadata(c *fiber.Ctx, userID uint, file *multipart.FileHeader, key, url string) error {
-	tx := s.FileRepo.BeginTransaction(c.Context())
-	if tx.Error != nil {
-		logger.Error("Failed to start transaction",
-			zap.Error(tx.Error))
-		return errors.New("failed to start transaction")
+	// Multipart files are on disk or in memory, so they can be read twice
+	hash := sha256.New()
+	if _, err := io.Copy(hash, src); err != nil {
+		logger.Error("Failed to hash uploaded file",
+			zap.String("filename", file.Filename),
+			zap.Error(err))
+		return nil, errors.New("failed to read uploaded file")
 	}
-
-	if err := s.checkFileExists(c, tx, key); err != nil {
-		tx.Rollback()
-		return err
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.New("failed to read uploaded file")
 	}
 
-	newFile := s.createFileEntity(userID, file, key, url)
-	if err := s.FileRepo.Create(c.Context(), newFile); err != nil {
-		tx.Rollback()
-		logger.Error("Failed to save file metadata",
-			zap.String("key", key),
-			zap.Error(err))
-		return errors.New("failed to save file metadata")
+	if s.Encryption.Enabled() {
+		return sealContent(c.UserContext(), s.S3, s.Encryption, userID, hex.EncodeToString(hash.Sum(nil)), src, file.Size)
 	}
-
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		logger.Error("Failed to commit transaction",
-			zap.String("key", key),
-			zap.Error(err))
-		return errors.New("failed to commit transaction")
+	blob, err := storeBlob(c.UserContext(), s.BlobRepo, s.S3, hex.EncodeToString(hash.Sum(nil)), file.Size, func(key string) error {
+		if _, err := s.S3.UploadFile(c.UserContext(), key, src, file.Size, contentType); err != nil {
+			logger.Error("Failed to upload file to S3",
+				zap.String("key", key),
+				zap.Error(err))
+			return errors.New("failed to upload file to S3")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return blobContent(blob), nil
 }
 
-// checkFileExists checks if a file already exists and is not deleted
-func (s *S3Service) checkFileExists(c *fiber.Ctx, tx *gorm.DB, key string) error {
-	var existingFile entity.File
-	if err := s.FileRepo.FindByKey(c.Context(), key, &existingFile); err == nil && !existingFile.IsDeleted {
-		logger.Error("File already exists",
-			zap.String("key", key))
-		return errors.New("file already exists")
+// initialScanStatus quarantines new content while a scanner is configured
+func initialScanStatus() string {
+	if config.Config.GetScanEnabled() {
+		return entity.FileScanPending
 	}
-	return nil
+	return entity.FileScanClean
 }
 
-// createFileEntity constructs a new File entity
-func (s *S3Service) createFileEntity(userID uint, file *multipart.FileHeader, key, url string) *entity.File {
+// createFileEntity constructs a new File entity; contentType is the detected
+// one, never the client's header alone
+func (s *S3Service) createFileEntity(userID uint, file *multipart.FileHeader, contentType string, content *storedContent, url string, expiresAt *time.Time) *entity.File {
 	return &entity.File{
-		UserID:   userID,
-		FileName: file.Filename,
-		FileSize: file.Size,
-		FileType: file.Header.Get("Content-Type"),
-		FilePath: key,
-		UrlPath:  url,
+		UserID:      userID,
+		FileName:    file.Filename,
+		FileSize:    file.Size,
+		FileType:    contentType,
+		ScanStatus:  initialScanStatus(),
+		FilePath:    content.Key,
+		UrlPath:     url,
+		ContentHash: content.Hash,
+		Encryption:  content.Encryption,
+		ExpiresAt:   expiresAt,
 	}
 }
 
 // cleanupS3File deletes a single file from S3 if an error occurs
-func (s *S3Service) cleanupS3File(key string) {
-	if err := s.S3.DeleteFile(key); err != nil {
+func (s *S3Service) cleanupS3File(c *fiber.Ctx, key string) {
+	if err := s.S3.DeleteFile(c.UserContext(), key); err != nil {
 		logger.Error("Failed to delete file from S3",
 			zap.String("key", key),
 			zap.Error(err))
 	}
 }
 
-// cleanupS3Files deletes multiple files from S3 if an error occurs
-func (s *S3Service) cleanupS3Files(keys []string) {
-	for _, key := range keys {
-		s.cleanupS3File(key)
+// releaseContents gives back what an upload that failed stored
+func (s *S3Service) releaseContents(c *fiber.Ctx, contents []*storedContent) {
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		if err := content.release(c.UserContext(), s.BlobRepo, s.S3); err != nil {
+			logger.Error("Failed to release stored content",
+				zap.String("key", content.Key),
+				zap.Error(err))
+		}
 	}
 }
 
-// markFileAsDeleted updates the file status to deleted within a transaction
+// markFileAsDeleted moves the file to the trash within a transaction
+// once userID is known to have write access
 func (s *S3Service) markFileAsDeleted(c *fiber.Ctx, key string, userID uint) (*entity.File, error) {
-	tx := s.FileRepo.BeginTransaction(c.Context())
+	tx := s.FileRepo.BeginTransaction(c.UserContext())
 	if tx.Error != nil {
 		logger.Error("Failed to start transaction",
 			zap.Error(tx.Error))
@@ -140,7 +175,7 @@ func (s *S3Service) markFileAsDeleted(c *fiber.Ctx, key string, userID uint) (*e
 	}
 
 	var file entity.File
-	if err := s.FileRepo.FindByKeyForUpdate(c.Context(), key, &file); err != nil {
+	if err := s.FileRepo.FindByKeyForUpdate(c.UserContext(), key, &file); err != nil {
 		tx.Rollback()
 		logger.Error("File not found or already deleted",
 			zap.String("key", key),
@@ -148,12 +183,9 @@ func (s *S3Service) markFileAsDeleted(c *fiber.Ctx, key string, userID uint) (*e
 		return nil, errors.New("file not found or already deleted")
 	}
 
-	if file.UserID != userID {
+	if err := s.authorize(c, &file, userID, entity.FileAccessWrite); err != nil {
 		tx.Rollback()
-		logger.Error("Unauthorized: user does not own this file",
-			zap.String("key", key),
-			zap.Uint("userID", userID))
-		return nil, errors.New("unauthorized: you do not own this file")
+		return nil, err
 	}
 
 	if file.IsDeleted {
@@ -161,8 +193,10 @@ func (s *S3Service) markFileAsDeleted(c *fiber.Ctx, key string, userID uint) (*e
 		return &file, nil // Idempotent: already deleted
 	}
 
+	now := time.Now()
 	file.IsDeleted = true
-	if err := s.FileRepo.Update(c.Context(), &file); err != nil {
+	file.TrashedAt = &now
+	if err := s.FileRepo.Update(c.UserContext(), &file); err != nil {
 		tx.Rollback()
 		logger.Error("Failed to mark file as deleted",
 			zap.String("key", key),
@@ -181,9 +215,9 @@ func (s *S3Service) markFileAsDeleted(c *fiber.Ctx, key string, userID uint) (*e
 	return &file, nil
 }
 
-// verifyAndLockFile locks and verifies the file for download
+// verifyAndLockFile locks the file and verifies userID may read it
 func (s *S3Service) verifyAndLockFile(c *fiber.Ctx, key string, userID uint) (*entity.File, error) {
-	tx := s.FileRepo.BeginTransaction(c.Context())
+	tx := s.FileRepo.BeginTransaction(c.UserContext())
 	if tx.Error != nil {
 		logger.Error("Failed to start transaction",
 			zap.Error(tx.Error))
@@ -192,18 +226,15 @@ func (s *S3Service) verifyAndLockFile(c *fiber.Ctx, key string, userID uint) (*e
 	defer tx.Rollback()
 
 	var file entity.File
-	if err := s.FileRepo.FindByKeyForUpdate(c.Context(), key, &file); err != nil {
+	if err := s.FileRepo.FindByKeyForUpdate(c.UserContext(), key, &file); err != nil {
 		logger.Error("File not found",
 			zap.String("key", key),
 			zap.Error(err))
 		return nil, errors.New("file not found")
 	}
 
-	if file.UserID != userID {
-		logger.Error("Unauthorized access attempt",
-			zap.String("key", key),
-			zap.Uint("userID", userID))
-		return nil, errors.New("unauthorized: you do not own this file")
+	if err := s.authorize(c, &file, userID, entity.FileAccessRead); err != nil {
+		return nil, err
 	}
 
 	if file.IsDeleted {
@@ -212,6 +243,14 @@ func (s *S3Service) verifyAndLockFile(c *fiber.Ctx, key string, userID uint) (*e
 		return nil, errors.New("file has been deleted")
 	}
 
+	// Expired files wait for the cleanup job but are already gone for users
+	if file.Expired(time.Now()) {
+		return nil, ErrFileNotFound
+	}
+	if file.Quarantined() {
+		return nil, ErrFileQuarantined
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		logger.Error("Failed to commit transaction",
 			zap.String("key", key),
@@ -222,12 +261,27 @@ func (s *S3Service) verifyAndLockFile(c *fiber.Ctx, key string, userID uint) (*e
 	return &file, nil
 }
 
-// handleS3DeleteError logs and formats S3 deletion errors
-func (s *S3Service) handleS3DeleteError(key string, err error) error {
-	logger.Error("Failed to delete file from S3",
-		zap.String("key", key),
-		zap.Error(err))
-	return errors.New("file marked as deleted in DB but failed to delete from S3")
+// authorize lets the owner of a file through, and anyone else whose user or
+// org grant covers access
+func (s *S3Service) authorize(c *fiber.Ctx, file *entity.File, userID uint, access string) error {
+	if file.UserID == userID {
+		return nil
+	}
+	granted, err := s.PermissionRepo.AccessFor(c.UserContext(), file.ID.String(), userID)
+	if err != nil {
+		logger.Error("Failed to check file permissions",
+			zap.String("fileID", file.ID.String()),
+			zap.Error(err))
+		return errors.New("failed to check file permissions")
+	}
+	if !entity.FileAccessAllows(granted, access) {
+		logger.Error("Unauthorized access attempt",
+			zap.String("fileID", file.ID.String()),
+			zap.Uint("userID", userID),
+			zap.String("access", access))
+		return ErrFileForbidden
+	}
+	return nil
 }
 
 // handleS3DownloadError logs and formats S3 download errors
@@ -236,3 +290,20 @@ func (s *S3Service) handleS3DownloadError(err error) error {
 		zap.Error(err))
 	return errors.New("failed to download file from S3")
 }
+
+// notifyUploaded tells the owner that an upload finished; failures are only logged
+func (s *S3Service) notifyUploaded(c *fiber.Ctx, userID uint, fileNames []string) {
+	body := fmt.Sprintf("%s has been uploaded.", fileNames[0])
+	if len(fileNames) > 1 {
+		body = fmt.Sprintf("%s and %d other file(s) have been uploaded.", fileNames[0], len(fileNames)-1)
+	}
+	err := s.Notifier.Notify(c.UserContext(), InS.Notification{
+		UserID:   userID,
+		Category: entity.NotificationCategoryFile,
+		Title:    "Upload complete",
+		Body:     body,
+	})
+	if err != nil {
+		logger.Warn("Failed to send upload notification", zap.Uint("userID", userID), zap.Error(err))
+	}
+}
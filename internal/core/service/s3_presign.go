@@ -0,0 +1,300 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+	In "project-api/internal/core/port/repository"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PresignUpload records a pending upload and signs a PUT, or a POST policy
+// for browser forms, that only accepts the declared size and checksum
+func (s *S3Service) PresignUpload(c *fiber.Ctx, req *request.PresignUploadRequest) (*entity.Upload, *In.PresignedRequest, error) {
+	userID, err := s.getUserID(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The content is only seen on completion; the presigned ContentType stays
+	// the declared one so the client's signed header still matches
+	if _, err := admitUpload(c.UserContext(), UploadRoutePresign, req.FileName, req.ContentType, req.Size, nil); err != nil {
+		return nil, nil, err
+	}
+	if err := s.Quota.Check(c.UserContext(), userID, req.Size); err != nil {
+		return nil, nil, err
+	}
+	checksum, err := normalizeChecksum(req.ChecksumSHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	method := req.Method
+	if method == "" {
+		method = entity.UploadMethodPut
+	}
+	key, err := newUploadKey(c.UserContext())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ttl := config.Config.GetPresignUploadTTL()
+	upload := &entity.Upload{
+		UserID:         userID,
+		Method:         method,
+		Status:         entity.UploadStatusPending,
+		FileName:       req.FileName,
+		ContentType:    req.ContentType,
+		Size:           req.Size,
+		ChecksumSHA256: checksum,
+		Key:            key,
+		ExpiresAt:      time.Now().Add(ttl),
+	}
+	if err := s.UploadRepo.Create(c.UserContext(), upload); err != nil {
+		logger.Error("Failed to save upload", zap.String("key", key), zap.Error(err))
+		return nil, nil, errors.New("failed to save upload")
+	}
+
+	input := In.PresignUploadInput{
+		Key:            key,
+		ContentType:    req.ContentType,
+		Size:           req.Size,
+		ChecksumSHA256: checksum,
+		TTL:            ttl,
+	}
+	var presigned *In.PresignedRequest
+	if method == entity.UploadMethodPost {
+		presigned, err = s.S3.PresignPost(c.UserContext(), input)
+	} else {
+		presigned, err = s.S3.PresignPut(c.UserContext(), input)
+	}
+	if errors.Is(err, In.ErrPresignUnsupported) {
+		return nil, nil, ErrPresignDisabled
+	}
+	if err != nil {
+		logger.Error("Failed to presign upload", zap.String("key", key), zap.Error(err))
+		return nil, nil, errors.New("failed to presign upload")
+	}
+
+	return upload, presigned, nil
+}
+
+// CompleteUpload checks the object the client uploaded against what it
+// declared and saves the File row. A mismatching object is deleted.
+func (s *S3Service) CompleteUpload(c *fiber.Ctx, uploadID uint) (*entity.File, error) {
+	userID, err := s.getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := s.UploadRepo.GetById(c.UserContext(), uploadID)
+	if err != nil || upload.UserID != userID {
+		return nil, ErrUploadNotFound
+	}
+
+	// Idempotent: a retried confirmation gets the file it already created
+	if upload.Status == entity.UploadStatusCompleted {
+		var file entity.File
+		if upload.FileID == nil {
+			return nil, ErrUploadNotFound
+		}
+		if err := s.FileRepo.FindByID(c.UserContext(), upload.FileID.String(), &file); err != nil {
+			return nil, ErrUploadNotFound
+		}
+		return &file, nil
+	}
+
+	if err := s.UploadRepo.Transition(c.UserContext(), upload.ID, entity.UploadStatusPending, entity.UploadStatusVerifying); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadState
+		}
+		logger.Error("Failed to lock upload", zap.Uint("uploadID", upload.ID), zap.Error(err))
+		return nil, errors.New("failed to complete upload")
+	}
+
+	info, err := s.S3.StatFile(c.UserContext(), upload.Key)
+	if errors.Is(err, In.ErrObjectNotFound) {
+		if upload.Expired(time.Now()) {
+			s.failUpload(c, upload)
+			return nil, ErrUploadExpired
+		}
+		// The client may still be uploading; let it confirm again later
+		s.releaseUpload(c, upload)
+		return nil, ErrUploadIncomplete
+	}
+	if err != nil {
+		logger.Error("Failed to stat uploaded file", zap.String("key", upload.Key), zap.Error(err))
+		s.releaseUpload(c, upload)
+		return nil, errors.New("failed to complete upload")
+	}
+
+	if err := s.verifyUpload(c, upload, info); err != nil {
+		s.cleanupS3File(c, upload.Key)
+		s.failUpload(c, upload)
+		return nil, err
+	}
+	contentType, err := s.admitStaged(c, upload)
+	if err != nil {
+		s.cleanupS3File(c, upload.Key)
+		s.failUpload(c, upload)
+		return nil, err
+	}
+
+	// Only the presign was checked against the quota, so the usage may
+	// have grown since
+	if err := s.Quota.Reserve(c.UserContext(), userID, info.Size, 1); err != nil {
+		s.cleanupS3File(c, upload.Key)
+		s.failUpload(c, upload)
+		return nil, err
+	}
+
+	// The declared checksum is now known to be the content's, so the
+	// upload moves from its staging key to the content-addressed blob
+	digest, _ := base64.StdEncoding.DecodeString(upload.ChecksumSHA256)
+	content, err := promoteStaged(c.UserContext(), s.BlobRepo, s.S3, s.Encryption, userID, upload.Key, hex.EncodeToString(digest), info.Size)
+	if err != nil {
+		s.Quota.Release(c.UserContext(), userID, info.Size, 1)
+		s.failUpload(c, upload)
+		return nil, err
+	}
+
+	file := &entity.File{
+		UserID:      userID,
+		FileName:    upload.FileName,
+		FileSize:    info.Size,
+		FileType:    contentType,
+		ScanStatus:  initialScanStatus(),
+		FilePath:    content.Key,
+		UrlPath:     s.S3.FileURL(content.Key),
+		ContentHash: content.Hash,
+		Encryption:  content.Encryption,
+	}
+	if err := s.FileRepo.Create(c.UserContext(), file); err != nil {
+		logger.Error("Failed to save file metadata", zap.String("key", content.Key), zap.Error(err))
+		s.releaseContents(c, []*storedContent{content})
+		s.Quota.Release(c.UserContext(), userID, info.Size, 1)
+		s.failUpload(c, upload)
+		return nil, errors.New("failed to save file metadata")
+	}
+
+	upload.Status = entity.UploadStatusCompleted
+	upload.FileID = &file.ID
+	if err := s.UploadRepo.Update(c.UserContext(), upload); err != nil {
+		logger.Error("Failed to mark upload as completed", zap.Uint("uploadID", upload.ID), zap.Error(err))
+	}
+
+	enqueueThumbnails(s.Server, file)
+	s.notifyUploaded(c, userID, []string{file.FileName})
+	return file, nil
+}
+
+// PresignDownload signs a GET for a file the user owns; ttl is capped by configuration
+func (s *S3Service) PresignDownload(c *fiber.Ctx, key string, ttl time.Duration) (*In.PresignedRequest, error) {
+	userID, err := s.getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.verifyAndLockFile(c, key, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Storage only holds the ciphertext
+	if file.Encryption != nil {
+		return nil, ErrPresignEncrypted
+	}
+
+	presigned, err := s.S3.PresignGet(c.UserContext(), file.FilePath, file.FileName, config.Config.GetPresignDownloadTTL(ttl))
+	if errors.Is(err, In.ErrPresignUnsupported) {
+		return nil, ErrPresignDisabled
+	}
+	if err != nil {
+		logger.Error("Failed to presign download", zap.String("key", file.FilePath), zap.Error(err))
+		return nil, errors.New("failed to presign download")
+	}
+	return presigned, nil
+}
+
+// verifyUpload compares the stored object with the declared size and checksum.
+// S3 reports the checksum when the client sent one; otherwise it is computed
+// by streaming the object.
+func (s *S3Service) verifyUpload(c *fiber.Ctx, upload *entity.Upload, info *In.ObjectInfo) error {
+	if info.Size != upload.Size {
+		logger.Warn("Uploaded file size mismatch",
+			zap.String("key", upload.Key),
+			zap.Int64("declared", upload.Size),
+			zap.Int64("stored", info.Size))
+		return ErrUploadMismatch
+	}
+
+	checksum := info.ChecksumSHA256
+	if checksum == "" {
+		body, _, err := s.S3.DownloadFile(c.UserContext(), upload.Key)
+		if err != nil {
+			return s.handleS3DownloadError(err)
+		}
+		defer body.Close()
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, body); err != nil {
+			return s.handleS3DownloadError(err)
+		}
+		checksum = base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	}
+
+	if checksum != upload.ChecksumSHA256 {
+		logger.Warn("Uploaded file checksum mismatch",
+			zap.String("key", upload.Key),
+			zap.String("declared", upload.ChecksumSHA256),
+			zap.String("stored", checksum))
+		return ErrUploadMismatch
+	}
+	return nil
+}
+
+// admitStaged checks the uploaded object's content against the upload rule
+// its presign was admitted under
+func (s *S3Service) admitStaged(c *fiber.Ctx, upload *entity.Upload) (string, error) {
+	head, err := readObjectHead(c.UserContext(), s.S3, upload.Key)
+	if err != nil {
+		return "", err
+	}
+	return admitUpload(c.UserContext(), UploadRoutePresign, upload.FileName, upload.ContentType, upload.Size, head)
+}
+
+// releaseUpload puts an upload back to pending so it can be confirmed again
+func (s *S3Service) releaseUpload(c *fiber.Ctx, upload *entity.Upload) {
+	if err := s.UploadRepo.Transition(c.UserContext(), upload.ID, entity.UploadStatusVerifying, entity.UploadStatusPending); err != nil {
+		logger.Error("Failed to release upload", zap.Uint("uploadID", upload.ID), zap.Error(err))
+	}
+}
+
+func (s *S3Service) failUpload(c *fiber.Ctx, upload *entity.Upload) {
+	if err := s.UploadRepo.Transition(c.UserContext(), upload.ID, entity.UploadStatusVerifying, entity.UploadStatusFailed); err != nil {
+		logger.Error("Failed to mark upload as failed", zap.Uint("uploadID", upload.ID), zap.Error(err))
+	}
+}
+
+// normalizeChecksum accepts a SHA-256 digest as hex or base64 and returns the
+// base64 form S3 uses
+func normalizeChecksum(value string) (string, error) {
+	if len(value) == hex.EncodedLen(sha256.Size) {
+		if digest, err := hex.DecodeString(value); err == nil {
+			return base64.StdEncoding.EncodeToString(digest), nil
+		}
+	}
+	digest, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(digest) != sha256.Size {
+		return "", ErrChecksumFormat
+	}
+	return value, nil
+}
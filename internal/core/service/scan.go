@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ScanService struct {
+	FileRepo    In.IFileRepository
+	VersionRepo In.IFileVersionRepository
+	BlobRepo    In.IBlobRepository
+	S3          In.IS3Repository
+	Scanner     In.IVirusScanner
+	Notifier    InS.INotificationService
+	Quota       InS.IQuotaService
+	// Encryption opens encrypted content, which is scanned decrypted
+	Encryption InS.IEncryptionService
+}
+
+func NewScanService(fileRepo In.IFileRepository, versionRepo In.IFileVersionRepository, blobRepo In.IBlobRepository, s3Repo In.IS3Repository, scanner In.IVirusScanner, notifier InS.INotificationService, quota InS.IQuotaService, encryption InS.IEncryptionService) InS.IScanService {
+	return &ScanService{
+		FileRepo:    fileRepo,
+		VersionRepo: versionRepo,
+		BlobRepo:    blobRepo,
+		S3:          s3Repo,
+		Scanner:     scanner,
+		Notifier:    notifier,
+		Quota:       quota,
+		Encryption:  encryption,
+	}
+}
+
+// ScanPending claims one file at a time so a slow scan never holds others
+// back from a concurrent run. An unreachable scanner ends the run with the
+// file back in the queue for the next one.
+func (s *ScanService) ScanPending(ctx context.Context) error {
+	timeout := config.Config.GetScanTimeout()
+	scanned := 0
+	for {
+		// A claim older than two scans belongs to a worker that died mid-scan
+		files, err := s.FileRepo.ClaimUnscanned(ctx, time.Now().Add(-2*timeout), 1)
+		if err != nil {
+			logger.Error("Failed to claim files to scan", zap.Int("scanned", scanned), zap.Error(err))
+			return errors.New("failed to claim files to scan")
+		}
+		if len(files) == 0 {
+			break
+		}
+
+		file := &files[0]
+		tenantCtx := utils.WithTenantID(ctx, file.TenantID)
+		if err := s.scanFile(tenantCtx, file, timeout); err != nil {
+			if err := s.FileRepo.FinishScan(tenantCtx, file, entity.FileScanPending, ""); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Error("Failed to requeue file scan", zap.String("fileID", file.ID.String()), zap.Error(err))
+			}
+			return err
+		}
+		scanned++
+	}
+	if scanned > 0 {
+		logger.Info("Scanned uploads", zap.Int("count", scanned))
+	}
+	return nil
+}
+
+// scanFile streams one file to the scanner and records the verdict; it only
+// fails when the file has to be scanned again
+func (s *ScanService) scanFile(ctx context.Context, file *entity.File, timeout time.Duration) error {
+	scanCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := s.Encryption.Open(scanCtx, file.FilePath, file.Encryption, file.FileSize, 0, -1)
+	if err != nil {
+		logger.Error("Failed to open file to scan", zap.String("key", file.FilePath), zap.Error(err))
+		return errors.New("failed to open file to scan")
+	}
+	defer body.Close()
+
+	status, signature := entity.FileScanClean, ""
+	result, err := s.Scanner.Scan(scanCtx, body)
+	switch {
+	case errors.Is(err, In.ErrScanRejected):
+		logger.Warn("Scanner rejected file",
+			zap.String("fileID", file.ID.String()),
+			zap.Error(err))
+		status = entity.FileScanFailed
+	case err != nil:
+		logger.Error("Failed to scan file", zap.String("fileID", file.ID.String()), zap.Error(err))
+		return errors.New("failed to scan file")
+	case result.Infected:
+		status, signature = entity.FileScanInfected, result.Signature
+	}
+
+	if err := s.FileRepo.FinishScan(ctx, file, status, signature); err != nil {
+		// New content replaced what was scanned and waits for its own scan
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		logger.Error("Failed to save scan result", zap.String("fileID", file.ID.String()), zap.Error(err))
+		return errors.New("failed to save scan result")
+	}
+	if status == entity.FileScanInfected {
+		s.removeInfected(ctx, file, signature)
+	}
+	return nil
+}
+
+// removeInfected deletes the content of a file FinishScan flagged, history
+// included, and tells its owner
+func (s *ScanService) removeInfected(ctx context.Context, file *entity.File, signature string) {
+	logger.Warn("Infected upload removed",
+		zap.String("fileID", file.ID.String()),
+		zap.Uint("userID", file.UserID),
+		zap.String("signature", signature))
+	if err := purgeFile(ctx, s.VersionRepo, s.BlobRepo, s.S3, s.Quota, file); err != nil {
+		logger.Error("Failed to delete infected file from S3",
+			zap.String("fileID", file.ID.String()),
+			zap.String("key", file.FilePath),
+			zap.Error(err))
+	}
+
+	err := s.Notifier.Notify(ctx, InS.Notification{
+		UserID:   file.UserID,
+		Category: entity.NotificationCategoryFile,
+		Title:    "Infected file removed",
+		Body:     fmt.Sprintf("%s was deleted because it contains %s.", file.FileName, signature),
+	})
+	if err != nil {
+		logger.Warn("Failed to send infected file notification", zap.Uint("userID", file.UserID), zap.Error(err))
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/logger"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	maxShareAccessLimit = 100
+	// A link takes no more password guesses once this many were wrong within
+	// the window, whoever made them
+	maxSharePasswordFailures = 10
+	sharePasswordWindow      = 15 * time.Minute
+)
+
+// ShareService hands out public links to files and serves downloads through them
+type ShareService struct {
+	FileRepo  In.IFileRepository
+	ShareRepo In.IShareLinkRepository
+	S3        In.IS3Repository
+	// Encryption opens the content of encrypted files
+	Encryption InS.IEncryptionService
+}
+
+// NewShareService creates a new ShareService instance
+func NewShareService(fileRepo In.IFileRepository, shareRepo In.IShareLinkRepository, s3Repo In.IS3Repository, encryption InS.IEncryptionService) InS.IShareService {
+	return &ShareService{
+		FileRepo:   fileRepo,
+		ShareRepo:  shareRepo,
+		S3:         s3Repo,
+		Encryption: encryption,
+	}
+}
+
+func (s *ShareService) CreateLink(ctx context.Context, userID uint, fileID string, req *request.CreateShareLinkRequest) (*entity.ShareLink, string, error) {
+	file, err := s.ownedFile(ctx, userID, fileID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", errors.New("failed to generate share token")
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	link := &entity.ShareLink{
+		FileID:       file.ID,
+		CreatedBy:    userID,
+		TokenHash:    hashShareToken(token),
+		MaxDownloads: req.MaxDownloads,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		link.ExpiresAt = &expiresAt
+	}
+	if req.Password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", errors.New("failed to hash share password")
+		}
+		link.PasswordHash = string(hashed)
+	}
+
+	if err := s.ShareRepo.Create(ctx, link); err != nil {
+		logger.Error("Failed to create share link", zap.String("fileID", fileID), zap.Error(err))
+		return nil, "", errors.New("failed to create share link")
+	}
+	return link, token, nil
+}
+
+func (s *ShareService) ListLinks(ctx context.Context, userID uint, fileID string) ([]entity.ShareLink, error) {
+	if _, err := s.ownedFile(ctx, userID, fileID); err != nil {
+		return nil, err
+	}
+	links, err := s.ShareRepo.ListByFile(ctx, fileID)
+	if err != nil {
+		logger.Error("Failed to list share links", zap.String("fileID", fileID), zap.Error(err))
+		return nil, errors.New("failed to list share links")
+	}
+	return links, nil
+}
+
+func (s *ShareService) RevokeLink(ctx context.Context, userID uint, fileID string, linkID uint) error {
+	if _, err := s.ownedFile(ctx, userID, fileID); err != nil {
+		return err
+	}
+	if err := s.ShareRepo.Revoke(ctx, fileID, linkID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrShareNotFound
+		}
+		logger.Error("Failed to revoke share link", zap.Uint("linkID", linkID), zap.Error(err))
+		return errors.New("failed to revoke share link")
+	}
+	return nil
+}
+
+func (s *ShareService) ListAccess(ctx context.Context, userID uint, fileID string, linkID uint) ([]entity.ShareLinkAccess, error) {
+	if _, err := s.ownedFile(ctx, userID, fileID); err != nil {
+		return nil, err
+	}
+	link, err := s.ShareRepo.GetById(ctx, linkID)
+	if err != nil || link.FileID.String() != fileID {
+		return nil, ErrShareNotFound
+	}
+	accesses, err := s.ShareRepo.ListAccess(ctx, link.ID, maxShareAccessLimit)
+	if err != nil {
+		logger.Error("Failed to list share link access", zap.Uint("linkID", linkID), zap.Error(err))
+		return nil, errors.New("failed to list share link access")
+	}
+	return accesses, nil
+}
+
+// Download checks the link before the download is counted, so a wrong
+// password or a failed read never uses up one of its downloads
+func (s *ShareService) Download(ctx context.Context, token, password string, client InS.ShareClient) (io.ReadCloser, *entity.File, error) {
+	link, err := s.ShareRepo.FindByTokenHash(ctx, hashShareToken(token))
+	if err != nil {
+		return nil, nil, ErrShareNotFound
+	}
+	// The visitor's request names no tenant; the link's is the one that counts
+	ctx = utils.WithTenantID(ctx, link.TenantID)
+
+	switch {
+	case link.RevokedAt != nil:
+		s.logAccess(ctx, link, entity.ShareAccessRevoked, client)
+		return nil, nil, ErrShareNotFound
+	case link.Expired(time.Now()):
+		s.logAccess(ctx, link, entity.ShareAccessExpired, client)
+		return nil, nil, ErrShareExpired
+	case link.Exhausted():
+		s.logAccess(ctx, link, entity.ShareAccessExhausted, client)
+		return nil, nil, ErrShareExpired
+	}
+	if link.PasswordHash != "" {
+		failures, err := s.ShareRepo.CountAccess(ctx, link.ID, entity.ShareAccessBadPassword, time.Now().Add(-sharePasswordWindow))
+		if err != nil {
+			logger.Error("Failed to count share link password failures", zap.Uint("linkID", link.ID), zap.Error(err))
+			return nil, nil, errors.New("failed to download shared file")
+		}
+		if failures >= maxSharePasswordFailures {
+			logger.Warn("Share link password attempts throttled", zap.Uint("linkID", link.ID), zap.String("ip", client.IP))
+			return nil, nil, ErrShareThrottled
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+			s.logAccess(ctx, link, entity.ShareAccessBadPassword, client)
+			return nil, nil, ErrSharePassword
+		}
+	}
+
+	var file entity.File
+	if err := s.FileRepo.FindByID(ctx, link.FileID.String(), &file); err != nil || file.IsDeleted || file.Expired(time.Now()) {
+		s.logAccess(ctx, link, entity.ShareAccessFileMissing, client)
+		return nil, nil, ErrShareNotFound
+	}
+	// Quarantined files keep the link's downloads for when the scan passes
+	if file.Quarantined() {
+		return nil, nil, ErrFileQuarantined
+	}
+
+	body, err := s.Encryption.Open(ctx, file.FilePath, file.Encryption, file.FileSize, 0, -1)
+	if err != nil {
+		logger.Error("Failed to download shared file", zap.String("key", file.FilePath), zap.Error(err))
+		return nil, nil, errors.New("failed to download file from S3")
+	}
+
+	// Another download may have taken the last slot since the link was read
+	if err := s.ShareRepo.ClaimDownload(ctx, link.ID); err != nil {
+		body.Close()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logAccess(ctx, link, entity.ShareAccessExhausted, client)
+			return nil, nil, ErrShareExpired
+		}
+		logger.Error("Failed to count share link download", zap.Uint("linkID", link.ID), zap.Error(err))
+		return nil, nil, errors.New("failed to download shared file")
+	}
+
+	s.logAccess(ctx, link, entity.ShareAccessDownloaded, client)
+	return body, &file, nil
+}
+
+// ownedFile returns a live file of userID
+func (s *ShareService) ownedFile(ctx context.Context, userID uint, fileID string) (*entity.File, error) {
+	var file entity.File
+	if err := s.FileRepo.FindByID(ctx, fileID, &file); err != nil || file.UserID != userID || file.IsDeleted {
+		return nil, ErrFileNotFound
+	}
+	return &file, nil
+}
+
+// logAccess records an attempt on a link; failures are only logged
+func (s *ShareService) logAccess(ctx context.Context, link *entity.ShareLink, outcome string, client InS.ShareClient) {
+	userAgent := client.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	err := s.ShareRepo.LogAccess(ctx, &entity.ShareLinkAccess{
+		ShareLinkID: link.ID,
+		Outcome:     outcome,
+		IP:          client.IP,
+		UserAgent:   userAgent,
+	})
+	if err != nil {
+		logger.Warn("Failed to log share link access", zap.Uint("linkID", link.ID), zap.Error(err))
+	}
+}
+
+// hashShareToken is what a share token is stored and looked up as
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newProtectedLink saves a link with the given password in tenant "acme"
+func newProtectedLink(t *testing.T, password string) (*ShareService, *entity.ShareLink, string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&entity.ShareLink{}, &entity.ShareLinkAccess{}); err != nil {
+		t.Fatal(err)
+	}
+	shares := repository.NewShareLinkRepository(db)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := "token"
+	link := &entity.ShareLink{
+		FileID:       uuid.New(),
+		CreatedBy:    1,
+		TokenHash:    hashShareToken(token),
+		PasswordHash: string(hash),
+	}
+	if err := shares.Create(utils.WithTenantID(context.Background(), "acme"), link); err != nil {
+		t.Fatal(err)
+	}
+	return &ShareService{ShareRepo: shares}, link, token
+}
+
+func TestShareLinkResolvesItsOwnTenant(t *testing.T) {
+	shares, link, token := newProtectedLink(t, "secret")
+
+	// A visitor without the tenant header lands in the default tenant
+	visitor := utils.WithTenantID(context.Background(), "default")
+	if _, _, err := shares.Download(visitor, token, "wrong", InS.ShareClient{IP: "192.0.2.1"}); !errors.Is(err, ErrSharePassword) {
+		t.Fatalf("got %v, want ErrSharePassword", err)
+	}
+	accesses, err := shares.ShareRepo.ListAccess(utils.WithTenantID(context.Background(), "acme"), link.ID, 10)
+	if err != nil || len(accesses) != 1 {
+		t.Fatalf("access log of the link's tenant = %v, %v", accesses, err)
+	}
+}
+
+func TestShareLinkThrottlesWrongPasswords(t *testing.T) {
+	shares, _, token := newProtectedLink(t, "secret")
+	ctx := utils.WithTenantID(context.Background(), "default")
+
+	for i := range maxSharePasswordFailures {
+		// Guesses from many addresses count against the same link
+		client := InS.ShareClient{IP: "192.0.2." + strconv.Itoa(i+1)}
+		if _, _, err := shares.Download(ctx, token, "wrong", client); !errors.Is(err, ErrSharePassword) {
+			t.Fatalf("guess %d: got %v, want ErrSharePassword", i, err)
+		}
+	}
+	if _, _, err := shares.Download(ctx, token, "secret", InS.ShareClient{IP: "198.51.100.1"}); !errors.Is(err, ErrShareThrottled) {
+		t.Fatalf("got %v, want ErrShareThrottled", err)
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/RichardKnop/machinery/v2"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+const thumbnailTask = "generate_thumbnails"
+
+// maxThumbnailSource bounds the images read into memory to be thumbnailed
+const maxThumbnailSource = 100 << 20 // 100MB
+
+// thumbnailTypes are the uploads thumbnails are made of
+var thumbnailTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// GenerateThumbnails makes the configured thumbnails of one version of an
+// image file. It does nothing once the file has moved past that version or
+// already has its thumbnails, so a late or repeated task is harmless.
+//
+// Thumbnails are re-encoded from pixels and carry no EXIF, XMP or GPS data.
+// The original is served untouched, metadata included: its bytes are what
+// the content hash, the ETag and deduplication are computed from.
+func (s *S3Service) GenerateThumbnails(ctx context.Context, tenantID, fileID string, version int) error {
+	ctx = utils.WithTenantID(ctx, tenantID)
+	var file entity.File
+	if err := s.FileRepo.FindByID(ctx, fileID, &file); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		logger.Error("Failed to load file to thumbnail", zap.String("fileID", fileID), zap.Error(err))
+		return errors.New("failed to load file")
+	}
+	if file.IsDeleted || file.Version != version || len(file.Thumbnails) > 0 || !thumbnailTypes[file.FileType] {
+		return nil
+	}
+
+	src, orientation, err := s.decodeImage(ctx, &file)
+	if err != nil || src == nil {
+		return err
+	}
+
+	var thumbs []entity.Thumbnail
+	for _, size := range config.Config.GetThumbnailSizes() {
+		thumb, err := s.storeThumbnail(ctx, &file, src, orientation, size)
+		if err != nil {
+			deleteThumbnails(ctx, s.S3, thumbs)
+			return err
+		}
+		if thumb != nil {
+			thumbs = append(thumbs, *thumb)
+		}
+	}
+	if len(thumbs) == 0 {
+		return nil
+	}
+
+	if err := s.FileRepo.SetThumbnails(ctx, file.ID, version, thumbs); err != nil {
+		deleteThumbnails(ctx, s.S3, thumbs)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		logger.Error("Failed to save thumbnails", zap.String("fileID", fileID), zap.Error(err))
+		return errors.New("failed to save thumbnails")
+	}
+	logger.Info("Generated thumbnails", zap.String("fileID", fileID), zap.Int("version", version), zap.Int("count", len(thumbs)))
+	return nil
+}
+
+// DownloadThumbnail opens a stream on one thumbnail of a file the caller may
+// read; the caller must close it
+func (s *S3Service) DownloadThumbnail(c *fiber.Ctx, fileID, name string) (io.ReadCloser, *entity.Thumbnail, error) {
+	file, err := s.accessibleFile(c, fileID, entity.FileAccessRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.Quarantined() {
+		return nil, nil, ErrFileQuarantined
+	}
+
+	for i := range file.Thumbnails {
+		thumb := &file.Thumbnails[i]
+		if thumb.Name != name {
+			continue
+		}
+		body, err := s.Encryption.Open(c.UserContext(), thumb.Key, thumb.Encryption, thumb.Size, 0, -1)
+		if err != nil {
+			return nil, nil, s.handleS3DownloadError(err)
+		}
+		return body, thumb, nil
+	}
+	return nil, nil, ErrThumbnailNotFound
+}
+
+// decodeImage reads the current content of file; a nil image without an
+// error means thumbnails can't be made of it
+func (s *S3Service) decodeImage(ctx context.Context, file *entity.File) (image.Image, int, error) {
+	if file.FileSize > maxThumbnailSource {
+		logger.Info("Image too large to thumbnail", zap.String("fileID", file.ID.String()), zap.Int64("size", file.FileSize))
+		return nil, 0, nil
+	}
+	body, err := s.Encryption.Open(ctx, file.FilePath, file.Encryption, file.FileSize, 0, -1)
+	if err != nil {
+		logger.Error("Failed to open image to thumbnail", zap.String("key", file.FilePath), zap.Error(err))
+		return nil, 0, errors.New("failed to open image")
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxThumbnailSource))
+	if err != nil {
+		logger.Error("Failed to read image to thumbnail", zap.String("key", file.FilePath), zap.Error(err))
+		return nil, 0, errors.New("failed to read image")
+	}
+
+	// The header gives the dimensions without decoding, so an image that
+	// would blow up in memory is turned down first
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		logger.Warn("Failed to read image header", zap.String("fileID", file.ID.String()), zap.Error(err))
+		return nil, 0, nil
+	}
+	if header.Width*header.Height > config.Config.GetThumbnailMaxPixels() {
+		logger.Info("Image has too many pixels to thumbnail",
+			zap.String("fileID", file.ID.String()),
+			zap.Int("width", header.Width),
+			zap.Int("height", header.Height))
+		return nil, 0, nil
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		logger.Warn("Failed to decode image", zap.String("fileID", file.ID.String()), zap.Error(err))
+		return nil, 0, nil
+	}
+
+	orientation := 1
+	if file.FileType == "image/jpeg" {
+		orientation = exifOrientation(data)
+	}
+	return src, orientation, nil
+}
+
+// storeThumbnail scales src to fit size, upright, and stores it next to the
+// file's other thumbnails; a misconfigured size is skipped with a nil result
+func (s *S3Service) storeThumbnail(ctx context.Context, file *entity.File, src image.Image, orientation int, size config.ThumbnailSize) (*entity.Thumbnail, error) {
+	if size.Name == "" || strings.Contains(size.Name, "/") || size.Width <= 0 || size.Height <= 0 {
+		logger.Warn("Skipping invalid thumbnail size", zap.String("name", size.Name))
+		return nil, nil
+	}
+
+	// The box applies to the upright image, which orientations 5 to 8 turn
+	// on its side
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	width, height = fitInside(width, height, size.Width, size.Height)
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+	thumbImage := orient(scaled, orientation)
+
+	var buf bytes.Buffer
+	contentType, ext, err := encodeThumbnail(&buf, thumbImage, size)
+	if err != nil {
+		logger.Warn("Skipping thumbnail size", zap.String("name", size.Name), zap.Error(err))
+		return nil, nil
+	}
+
+	key, err := utils.TenantKey(ctx, fmt.Sprintf("file/thumbnail/%s/%d/%s.%s", file.ID, file.Version, size.Name, ext))
+	if err != nil {
+		logger.Error("Failed to generate storage key", zap.Error(err))
+		return nil, errors.New("tenant is required")
+	}
+	thumb := &entity.Thumbnail{
+		Name:        size.Name,
+		Key:         key,
+		ContentType: contentType,
+		Width:       thumbImage.Bounds().Dx(),
+		Height:      thumbImage.Bounds().Dy(),
+		Size:        int64(buf.Len()),
+	}
+	// A thumbnail shows what the image holds, so it is sealed like the image
+	var body io.Reader = bytes.NewReader(buf.Bytes())
+	storedSize, storedType := thumb.Size, contentType
+	if file.Encryption != nil {
+		sealed, err := s.Encryption.Seal(ctx, file.UserID, body, thumb.Size)
+		if err != nil {
+			return nil, err
+		}
+		body, storedSize, storedType = sealed.Body, sealed.Size, "application/octet-stream"
+		thumb.Encryption = sealed.Encryption
+	}
+	if _, err := s.S3.UploadFile(ctx, key, body, storedSize, storedType); err != nil {
+		logger.Error("Failed to store thumbnail", zap.String("key", key), zap.Error(err))
+		return nil, errors.New("failed to store thumbnail")
+	}
+	return thumb, nil
+}
+
+// fitInside scales width x height down, keeping its ratio, until it fits
+// the box; smaller images keep their size
+func fitInside(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	scale := math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	return max(1, int(math.Round(float64(width)*scale))), max(1, int(math.Round(float64(height)*scale)))
+}
+
+// encodeThumbnail writes img in the format of size and returns its content
+// type and extension. Encoding from pixels is what drops the metadata.
+func encodeThumbnail(w io.Writer, img image.Image, size config.ThumbnailSize) (string, string, error) {
+	switch size.Format {
+	case config.ThumbnailJPEG:
+		// JPEG has no alpha, so transparency becomes white instead of black
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		return "image/jpeg", "jpg", jpeg.Encode(w, flat, &jpeg.Options{Quality: size.Quality})
+	case config.ThumbnailPNG:
+		return "image/png", "png", png.Encode(w, img)
+	case config.ThumbnailWebP:
+		return "image/webp", "webp", nativewebp.Encode(w, img, nil)
+	}
+	return "", "", fmt.Errorf("unknown thumbnail format %q", size.Format)
+}
+
+// enqueueThumbnails asks the worker for the thumbnails of an image file's
+// current version; failing to only costs the thumbnails
+func enqueueThumbnails(server *machinery.Server, file *entity.File) {
+	if !thumbnailTypes[file.FileType] {
+		return
+	}
+	signature := &tasks.Signature{
+		Name: thumbnailTask,
+		Args: []tasks.Arg{
+			{Type: "string", Value: file.TenantID},
+			{Type: "string", Value: file.ID.String()},
+			{Type: "int", Value: file.Version},
+		},
+	}
+	if _, err := server.SendTask(signature); err != nil {
+		logger.Error("Failed to queue thumbnail task", zap.String("fileID", file.ID.String()), zap.Error(err))
+	}
+}
+
+// deleteThumbnails removes thumbnails from storage, logging what it can't
+func deleteThumbnails(ctx context.Context, storage In.IS3Repository, thumbs []entity.Thumbnail) {
+	for _, thumb := range thumbs {
+		if err := storage.DeleteFile(ctx, thumb.Key); err != nil {
+			logger.Warn("Failed to delete thumbnail", zap.String("key", thumb.Key), zap.Error(err))
+		}
+	}
+}
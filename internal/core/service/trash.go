@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// purgeBatch bounds how many files one purge transaction removes
+const purgeBatch = 500
+
+// RestoreFile takes one of the caller's files out of the trash. Only the
+// owner may restore, even when a writer deleted the file.
+func (s *S3Service) RestoreFile(c *fiber.Ctx, id string) (*entity.File, error) {
+	userID, err := s.getUserID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var file entity.File
+	if err := s.FileRepo.FindByID(c.UserContext(), id, &file); err != nil || file.UserID != userID || file.TrashedAt == nil || file.Expired(time.Now()) {
+		return nil, ErrFileNotFound
+	}
+
+	restored, err := s.FileRepo.Restore(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFileNotFound
+		}
+		logger.Error("Failed to restore file", zap.String("fileID", id), zap.Error(err))
+		return nil, errors.New("failed to restore file")
+	}
+	return restored, nil
+}
+
+// PurgeTrash permanently removes the files of every tenant that stayed in
+// the trash past the retention window, then releases their content and quota
+func (s *S3Service) PurgeTrash(ctx context.Context) error {
+	before := time.Now().Add(-config.Config.GetTrashRetention())
+	return s.purgeFiles(ctx, "trash", func() ([]entity.File, error) {
+		return s.FileRepo.PurgeTrashed(ctx, before, purgeBatch)
+	})
+}
+
+// DeleteExpiredFiles permanently removes the files of every tenant whose
+// expiry has passed, the way PurgeTrash does
+func (s *S3Service) DeleteExpiredFiles(ctx context.Context) error {
+	now := time.Now()
+	return s.purgeFiles(ctx, "expired files", func() ([]entity.File, error) {
+		return s.FileRepo.PurgeExpired(ctx, now, purgeBatch)
+	})
+}
+
+// purgeFiles calls next until it returns a short batch and releases the
+// content of every file it removed
+func (s *S3Service) purgeFiles(ctx context.Context, what string, next func() ([]entity.File, error)) error {
+	purged := 0
+	for {
+		files, err := next()
+		if err != nil {
+			logger.Error("Failed to purge "+what, zap.Int("purged", purged), zap.Error(err))
+			return errors.New("failed to purge " + what)
+		}
+		for i := range files {
+			tenantCtx := utils.WithTenantID(ctx, files[i].TenantID)
+			if err := purgeFile(tenantCtx, s.VersionRepo, s.BlobRepo, s.S3, s.Quota, &files[i]); err != nil {
+				logger.Error("Failed to delete purged file from S3",
+					zap.String("fileID", files[i].ID.String()),
+					zap.String("key", files[i].FilePath),
+					zap.Error(err))
+			}
+		}
+		purged += len(files)
+		if len(files) < purgeBatch {
+			break
+		}
+	}
+	logger.Info("Purged "+what, zap.Int("count", purged))
+	return nil
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"github.com/RichardKnop/machinery/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// tusPartSize is the size of the multipart parts chunks are cut into. Chunks
+// rarely line up with it, so the bytes left over after a chunk are kept in a
+// separate object until the next chunk fills the part.
+const tusPartSize = 8 << 20 // 8MB
+
+type TusService struct {
+	FileRepo   In.IFileRepository
+	UploadRepo In.IUploadRepository
+	BlobRepo   In.IBlobRepository
+	S3         In.IS3Repository
+	Notifier   InS.INotificationService
+	Quota      InS.IQuotaService
+	// Encryption seals finished uploads when it is on
+	Encryption InS.IEncryptionService
+	// Server queues the thumbnail task of image uploads
+	Server *machinery.Server
+}
+
+func NewTusService(fileRepo In.IFileRepository, uploadRepo In.IUploadRepository, blobRepo In.IBlobRepository, s3Repo In.IS3Repository, notifier InS.INotificationService, quota InS.IQuotaService, encryption InS.IEncryptionService, server *machinery.Server) InS.ITusService {
+	return &TusService{
+		FileRepo:   fileRepo,
+		UploadRepo: uploadRepo,
+		BlobRepo:   blobRepo,
+		S3:         s3Repo,
+		Notifier:   notifier,
+		Quota:      quota,
+		Encryption: encryption,
+		Server:     server,
+	}
+}
+
+func (s *TusService) CreateUpload(ctx context.Context, userID uint, fileName, contentType string, size int64) (*entity.Upload, error) {
+	if _, err := admitUpload(ctx, UploadRouteTus, fileName, contentType, size, nil); err != nil {
+		return nil, err
+	}
+	if err := s.Quota.Check(ctx, userID, size); err != nil {
+		return nil, err
+	}
+
+	key, err := newUploadKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &entity.Upload{
+		UserID:      userID,
+		Method:      entity.UploadMethodTus,
+		Status:      entity.UploadStatusPending,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		Key:         key,
+		ExpiresAt:   time.Now().Add(config.Config.GetTusExpiry()),
+	}
+
+	// S3 needs at least one part, so an empty file is finished right away
+	if size == 0 {
+		if err := s.UploadRepo.Create(ctx, upload); err != nil {
+			logger.Error("Failed to save upload", zap.String("key", key), zap.Error(err))
+			return nil, errors.New("failed to create upload")
+		}
+		if err := s.finish(ctx, upload); err != nil {
+			return nil, err
+		}
+		return upload, nil
+	}
+
+	uploadID, err := s.S3.CreateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		logger.Error("Failed to start multipart upload", zap.String("key", key), zap.Error(err))
+		return nil, errors.New("failed to create upload")
+	}
+	upload.S3UploadID = uploadID
+	if err := s.UploadRepo.Create(ctx, upload); err != nil {
+		logger.Error("Failed to save upload", zap.String("key", key), zap.Error(err))
+		if err := s.S3.AbortMultipartUpload(ctx, key, uploadID); err != nil {
+			logger.Error("Failed to abort multipart upload", zap.String("key", key), zap.Error(err))
+		}
+		return nil, errors.New("failed to create upload")
+	}
+	return upload, nil
+}
+
+func (s *TusService) GetUpload(ctx context.Context, userID, id uint) (*entity.Upload, error) {
+	upload, err := s.UploadRepo.GetById(ctx, id)
+	if err != nil || upload.UserID != userID || upload.Method != entity.UploadMethodTus {
+		return nil, ErrUploadNotFound
+	}
+	switch upload.Status {
+	case entity.UploadStatusFailed, entity.UploadStatusTerminated:
+		return nil, ErrUploadNotFound
+	case entity.UploadStatusCompleted:
+		return upload, nil
+	}
+	if upload.Expired(time.Now()) {
+		return nil, ErrUploadExpired
+	}
+	return upload, nil
+}
+
+func (s *TusService) WriteChunk(ctx context.Context, userID, id uint, offset int64, body io.Reader) (*entity.Upload, error) {
+	upload, err := s.GetUpload(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status == entity.UploadStatusCompleted {
+		if offset != upload.Offset {
+			return nil, ErrUploadOffset
+		}
+		return upload, nil
+	}
+	if offset != upload.Offset {
+		return nil, ErrUploadOffset
+	}
+
+	if err := s.UploadRepo.Claim(ctx, upload.ID, entity.UploadStatusReceiving, receiveStaleBefore()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadBusy
+		}
+		logger.Error("Failed to lock upload", zap.Uint("uploadID", upload.ID), zap.Error(err))
+		return nil, errors.New("failed to write chunk")
+	}
+	upload.Status = entity.UploadStatusReceiving
+	// The request may be cancelled at any point from here, but what was
+	// stored still has to be saved or the upload stays claimed
+	saveCtx := context.WithoutCancel(ctx)
+
+	var writeErr error
+	if upload.Offset < upload.Size {
+		writeErr = s.writeParts(ctx, upload, io.LimitReader(body, upload.Size-upload.Offset))
+	}
+	if writeErr == nil && upload.Offset == upload.Size {
+		// Bytes past Upload-Length mean the client and server disagree on the
+		// file; the upload stays open so an empty PATCH can still finish it
+		if n, _ := body.Read(make([]byte, 1)); n > 0 {
+			writeErr = ErrUploadOverflow
+		}
+	}
+	if writeErr == nil && upload.Offset == upload.Size {
+		if err := s.S3.CompleteMultipartUpload(ctx, upload.Key, upload.S3UploadID); err != nil {
+			logger.Error("Failed to complete multipart upload", zap.String("key", upload.Key), zap.Error(err))
+			writeErr = errors.New("failed to complete upload")
+		} else {
+			return upload, s.finish(saveCtx, upload)
+		}
+	}
+
+	// Keep whatever arrived so the client can resume from the new offset
+	upload.Status = entity.UploadStatusPending
+	upload.ExpiresAt = time.Now().Add(config.Config.GetTusExpiry())
+	if err := s.UploadRepo.Update(saveCtx, upload); err != nil {
+		logger.Error("Failed to save upload progress", zap.Uint("uploadID", upload.ID), zap.Error(err))
+		return nil, errors.New("failed to write chunk")
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	return upload, nil
+}
+
+func (s *TusService) TerminateUpload(ctx context.Context, userID, id uint) error {
+	// Expired uploads can still be terminated to free their parts
+	upload, err := s.UploadRepo.GetById(ctx, id)
+	if err != nil || upload.UserID != userID || upload.Method != entity.UploadMethodTus ||
+		upload.Status == entity.UploadStatusFailed || upload.Status == entity.UploadStatusTerminated {
+		return ErrUploadNotFound
+	}
+	if upload.Status == entity.UploadStatusCompleted {
+		return ErrUploadState
+	}
+
+	if err := s.UploadRepo.Claim(ctx, upload.ID, entity.UploadStatusTerminated, receiveStaleBefore()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUploadBusy
+		}
+		logger.Error("Failed to terminate upload", zap.Uint("uploadID", upload.ID), zap.Error(err))
+		return errors.New("failed to terminate upload")
+	}
+
+	s.discardParts(ctx, upload)
+	return nil
+}
+
+// PurgeExpiredUploads terminates the tus uploads of every tenant that expired
+// unfinished and frees the parts they stored
+func (s *TusService) PurgeExpiredUploads(ctx context.Context) error {
+	purged := 0
+	for {
+		uploads, err := s.UploadRepo.TerminateExpired(ctx, time.Now(), receiveStaleBefore(), purgeBatch)
+		if err != nil {
+			logger.Error("Failed to purge expired uploads", zap.Int("purged", purged), zap.Error(err))
+			return errors.New("failed to purge expired uploads")
+		}
+		for i := range uploads {
+			s.discardParts(utils.WithTenantID(ctx, uploads[i].TenantID), &uploads[i])
+		}
+		purged += len(uploads)
+		if len(uploads) < purgeBatch {
+			break
+		}
+	}
+	logger.Info("Purged expired uploads", zap.Int("count", purged))
+	return nil
+}
+
+// receiveStaleBefore is the time before which a receiving upload last saved
+// its progress when its writer is taken for gone
+func receiveStaleBefore() time.Time {
+	return time.Now().Add(-config.Config.GetTusReceiveTimeout())
+}
+
+// discardParts aborts the multipart upload of a terminated upload and
+// deletes its pending part. A writer that died may have left a pending part
+// its row doesn't know about, so that part is deleted regardless.
+func (s *TusService) discardParts(ctx context.Context, upload *entity.Upload) {
+	if err := s.S3.AbortMultipartUpload(ctx, upload.Key, upload.S3UploadID); err != nil {
+		logger.Error("Failed to abort multipart upload", zap.String("key", upload.Key), zap.Error(err))
+	}
+	s.deletePendingPart(ctx, upload)
+}
+
+// writeParts cuts the pending bytes and body into parts and uploads every
+// full part, or the last one. Progress is saved after each part, and bytes
+// that don't fill a part are kept as the pending part even when body fails.
+func (s *TusService) writeParts(ctx context.Context, upload *entity.Upload, body io.Reader) error {
+	buf := make([]byte, tusPartSize)
+	filled := 0
+	hadPending := upload.PendingPartSize > 0
+	if hadPending {
+		pending, _, err := s.S3.DownloadFile(ctx, upload.PendingPartKey())
+		if err != nil {
+			logger.Error("Failed to read pending part", zap.String("key", upload.PendingPartKey()), zap.Error(err))
+			return errors.New("failed to write chunk")
+		}
+		n, err := io.ReadFull(pending, buf[:upload.PendingPartSize])
+		pending.Close()
+		if err != nil {
+			logger.Error("Failed to read pending part", zap.String("key", upload.PendingPartKey()), zap.Error(err))
+			return errors.New("failed to write chunk")
+		}
+		filled = n
+	}
+	partBytes := upload.Offset - upload.PendingPartSize
+
+	hash := sha256.New()
+	if len(upload.HashState) > 0 {
+		if err := hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(upload.HashState); err != nil {
+			logger.Error("Failed to restore upload hash", zap.Uint("uploadID", upload.ID), zap.Error(err))
+			return errors.New("failed to write chunk")
+		}
+	}
+
+	var readErr error
+	for {
+		var n int
+		n, readErr = io.ReadFull(body, buf[filled:])
+		filled += n
+		final := partBytes+int64(filled) == upload.Size
+		if (filled < len(buf) && !final) || filled == 0 {
+			break
+		}
+
+		if err := s.S3.UploadPart(ctx, upload.Key, upload.S3UploadID, upload.PartCount+1, bytes.NewReader(buf[:filled]), int64(filled)); err != nil {
+			// upload still points at the last stored part and pending part
+			logger.Error("Failed to upload part", zap.String("key", upload.Key), zap.Error(err))
+			return errors.New("failed to write chunk")
+		}
+		hash.Write(buf[:filled])
+		state, err := hash.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			logger.Error("Failed to save upload hash", zap.Uint("uploadID", upload.ID), zap.Error(err))
+			return errors.New("failed to write chunk")
+		}
+		upload.HashState = state
+		partBytes += int64(filled)
+		filled = 0
+		upload.PartCount++
+		upload.PendingPartSize = 0
+		upload.Offset = partBytes
+		if err := s.UploadRepo.Update(context.WithoutCancel(ctx), upload); err != nil {
+			logger.Error("Failed to save upload progress", zap.Uint("uploadID", upload.ID), zap.Error(err))
+			return errors.New("failed to write chunk")
+		}
+		if hadPending {
+			s.deletePendingPart(ctx, upload)
+			hadPending = false
+		}
+		if final {
+			break
+		}
+	}
+
+	if filled > 0 {
+		if _, err := s.S3.UploadFile(ctx, upload.PendingPartKey(), bytes.NewReader(buf[:filled]), int64(filled), upload.ContentType); err != nil {
+			logger.Error("Failed to store pending part", zap.String("key", upload.PendingPartKey()), zap.Error(err))
+			return errors.New("failed to write chunk")
+		}
+		upload.PendingPartSize = int64(filled)
+		upload.Offset = partBytes + int64(filled)
+	}
+
+	if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+		logger.Warn("Chunk ended early", zap.Uint("uploadID", upload.ID), zap.Int64("offset", upload.Offset), zap.Error(readErr))
+	}
+	return nil
+}
+
+// failUpload gives up on an upload whose content can no longer be stored
+func (s *TusService) failUpload(ctx context.Context, upload *entity.Upload) {
+	upload.Status = entity.UploadStatusFailed
+	if err := s.UploadRepo.Update(ctx, upload); err != nil {
+		logger.Error("Failed to mark upload as failed", zap.Uint("uploadID", upload.ID), zap.Error(err))
+	}
+}
+
+func (s *TusService) deletePendingPart(ctx context.Context, upload *entity.Upload) {
+	if err := s.S3.DeleteFile(ctx, upload.PendingPartKey()); err != nil {
+		logger.Warn("Failed to delete pending part", zap.String("key", upload.PendingPartKey()), zap.Error(err))
+	}
+}
+
+// storeEmpty stores the content of an upload of zero bytes, which never had
+// a staged object
+func (s *TusService) storeEmpty(ctx context.Context, userID uint, contentType string) (*storedContent, error) {
+	digest := sha256.Sum256(nil)
+	hash := hex.EncodeToString(digest[:])
+	if s.Encryption.Enabled() {
+		return sealContent(ctx, s.S3, s.Encryption, userID, hash, bytes.NewReader(nil), 0)
+	}
+	blob, err := storeBlob(ctx, s.BlobRepo, s.S3, hash, 0, func(key string) error {
+		if _, err := s.S3.UploadFile(ctx, key, bytes.NewReader(nil), 0, contentType); err != nil {
+			logger.Error("Failed to store empty upload", zap.String("key", key), zap.Error(err))
+			return errors.New("failed to store file")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blobContent(blob), nil
+}
+
+// finish moves a complete upload to its content-addressed blob and saves
+// the File row
+func (s *TusService) finish(ctx context.Context, upload *entity.Upload) error {
+	discard := func(err error) error {
+		if upload.Size > 0 {
+			if err := s.S3.DeleteFile(ctx, upload.Key); err != nil {
+				logger.Warn("Failed to delete staged upload", zap.String("key", upload.Key), zap.Error(err))
+			}
+		}
+		s.failUpload(ctx, upload)
+		return err
+	}
+
+	// Creation only saw the name and declared type; now the content is known
+	var head []byte
+	if upload.Size > 0 {
+		var err error
+		if head, err = readObjectHead(ctx, s.S3, upload.Key); err != nil {
+			return discard(err)
+		}
+	}
+	contentType, err := admitUpload(ctx, UploadRouteTus, upload.FileName, upload.ContentType, upload.Size, head)
+	if err != nil {
+		return discard(err)
+	}
+	if err := s.Quota.Reserve(ctx, upload.UserID, upload.Size, 1); err != nil {
+		return discard(err)
+	}
+
+	var content *storedContent
+	if upload.Size == 0 {
+		content, err = s.storeEmpty(ctx, upload.UserID, contentType)
+	} else {
+		hash := sha256.New()
+		if err := hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(upload.HashState); err != nil {
+			logger.Error("Failed to restore upload hash", zap.Uint("uploadID", upload.ID), zap.Error(err))
+			return errors.New("failed to complete upload")
+		}
+		content, err = promoteStaged(ctx, s.BlobRepo, s.S3, s.Encryption, upload.UserID, upload.Key, hex.EncodeToString(hash.Sum(nil)), upload.Size)
+	}
+	if err != nil {
+		s.Quota.Release(ctx, upload.UserID, upload.Size, 1)
+		s.failUpload(ctx, upload)
+		return err
+	}
+
+	file := &entity.File{
+		UserID:      upload.UserID,
+		FileName:    upload.FileName,
+		FileSize:    upload.Size,
+		FileType:    contentType,
+		ScanStatus:  initialScanStatus(),
+		FilePath:    content.Key,
+		UrlPath:     s.S3.FileURL(content.Key),
+		ContentHash: content.Hash,
+		Encryption:  content.Encryption,
+	}
+	if err := s.FileRepo.Create(ctx, file); err != nil {
+		logger.Error("Failed to save file metadata", zap.String("key", content.Key), zap.Error(err))
+		if err := content.release(ctx, s.BlobRepo, s.S3); err != nil {
+			logger.Error("Failed to release stored content", zap.String("key", content.Key), zap.Error(err))
+		}
+		s.Quota.Release(ctx, upload.UserID, upload.Size, 1)
+		s.failUpload(ctx, upload)
+		return errors.New("failed to save file metadata")
+	}
+
+	upload.Status = entity.UploadStatusCompleted
+	upload.Offset = upload.Size
+	upload.FileID = &file.ID
+	if err := s.UploadRepo.Update(ctx, upload); err != nil {
+		logger.Error("Failed to mark upload as completed", zap.Uint("uploadID", upload.ID), zap.Error(err))
+		return errors.New("failed to complete upload")
+	}
+	enqueueThumbnails(s.Server, file)
+
+	err = s.Notifier.Notify(ctx, InS.Notification{
+		UserID:   upload.UserID,
+		Category: entity.NotificationCategoryFile,
+		Title:    "Upload complete",
+		Body:     fmt.Sprintf("%s has been uploaded.", upload.FileName),
+	})
+	if err != nil {
+		logger.Warn("Failed to send upload notification", zap.Uint("userID", upload.UserID), zap.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"project-api/internal/core/common/utils"
+	In "project-api/internal/core/port/repository"
+	"project-api/internal/infra/config"
+	"project-api/internal/infra/logger"
+
+	"go.uber.org/zap"
+)
+
+// Upload routes a rule can be configured for
+const (
+	UploadRouteMultipart = "upload"
+	UploadRouteVersion   = "version"
+	UploadRoutePresign   = "presign"
+	UploadRouteTus       = "tus"
+)
+
+// sniffLen is how much of the content type detection looks at
+const sniffLen = 512
+
+const octetStream = "application/octet-stream"
+
+// executableSignatures catch programs http.DetectContentType only knows as
+// octet streams
+var executableSignatures = []struct {
+	prefix      string
+	contentType string
+}{
+	{"MZ", "application/x-msdownload"},
+	{"\x7fELF", "application/x-executable"},
+	{"\xcf\xfa\xed\xfe", "application/x-mach-binary"},
+}
+
+// zipContainers are formats stored as zip archives, so they sniff as one
+var zipContainers = []string{
+	"application/vnd.openxmlformats-officedocument.",
+	"application/vnd.oasis.opendocument.",
+	"application/vnd.android.package-archive",
+	"application/epub+zip",
+	"application/java-archive",
+	"application/x-zip-compressed",
+}
+
+// textualTypes are the non-text/ types whose content sniffs as plain text
+var textualTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/javascript",
+	"application/x-yaml",
+	"application/yaml",
+	"application/sql",
+}
+
+// sameTypes pairs the names detection uses with their registered aliases
+var sameTypes = map[string]string{
+	"application/x-gzip": "application/gzip",
+	"audio/wave":         "audio/wav",
+	"audio/mpeg":         "audio/mp3",
+	// Debian's mime.types name for .exe
+	"application/x-msdos-program": "application/x-msdownload",
+}
+
+// admitUpload checks a file against the upload rule of route and the
+// caller's role and returns the content type to store. head is the start of
+// the content; without it only the name, declared type and size are checked.
+func admitUpload(ctx context.Context, route, fileName, declared string, size int64, head []byte) (string, error) {
+	contentType, sniffed, err := resolveContentType(fileName, declared, head)
+	if err != nil {
+		logger.Warn("Upload content does not match its type",
+			zap.String("filename", fileName),
+			zap.String("declared", declared),
+			zap.String("sniffed", sniffed))
+		return "", err
+	}
+
+	role := ""
+	if claims, ok := utils.GetUserIDFromContext(ctx); ok {
+		role = claims.Role
+	}
+	if err := checkUploadRule(config.Config.GetUploadRule(route, role), contentType, sniffed, size); err != nil {
+		logger.Warn("Upload rejected by upload rule",
+			zap.String("route", route),
+			zap.String("role", role),
+			zap.String("filename", fileName),
+			zap.String("contentType", contentType),
+			zap.Int64("size", size),
+			zap.Error(err))
+		return "", err
+	}
+	return contentType, nil
+}
+
+// resolveContentType names the content from its extension and declared type,
+// both of which must agree with what the content sniffs as; a generic sniff
+// takes the more precise claimed name. The sniffed type is empty without head.
+func resolveContentType(fileName, declared string, head []byte) (string, string, error) {
+	extType := mediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))))
+	declared = mediaType(declared)
+	if declared == octetStream {
+		declared = ""
+	}
+
+	sniffed := ""
+	if len(head) > 0 {
+		sniffed = sniffContentType(head)
+		for _, claimed := range []string{extType, declared} {
+			if claimed != "" && !compatibleTypes(sniffed, claimed) {
+				return "", sniffed, ErrFileTypeMismatch
+			}
+		}
+	}
+
+	for _, contentType := range []string{declared, extType, sniffed} {
+		if contentType != "" {
+			return contentType, sniffed, nil
+		}
+	}
+	return octetStream, sniffed, nil
+}
+
+func sniffContentType(head []byte) string {
+	for _, signature := range executableSignatures {
+		if bytes.HasPrefix(head, []byte(signature.prefix)) {
+			return signature.contentType
+		}
+	}
+	return mediaType(http.DetectContentType(head))
+}
+
+// compatibleTypes reports whether content sniffed as sniffed may be claimed
+// to be of type claimed
+func compatibleTypes(sniffed, claimed string) bool {
+	if claimed == sniffed || sameTypes[sniffed] == claimed || sameTypes[claimed] == sniffed {
+		return true
+	}
+	switch sniffed {
+	case octetStream:
+		// Detection knows every text, so only binary formats it can't name are left
+		return !strings.HasPrefix(claimed, "text/")
+	case "text/plain":
+		return strings.HasPrefix(claimed, "text/") && claimed != "text/html" ||
+			hasAnyPrefix(textualTypes, claimed) ||
+			strings.HasSuffix(claimed, "+json") || strings.HasSuffix(claimed, "+xml")
+	case "text/xml":
+		return claimed == "application/xml" || strings.HasSuffix(claimed, "+xml")
+	case "application/zip":
+		return hasAnyPrefix(zipContainers, claimed)
+	}
+	return false
+}
+
+// checkUploadRule enforces rule on a file; sniffed may be empty
+func checkUploadRule(rule config.UploadRule, contentType, sniffed string, size int64) error {
+	for _, name := range []string{contentType, sniffed} {
+		if name != "" && matchesType(rule.Deny, name) != "" {
+			return ErrFileType
+		}
+	}
+	if len(rule.Allow) > 0 && matchesType(rule.Allow, contentType) == "" {
+		return ErrFileType
+	}
+
+	limit := rule.MaxSize
+	longest := ""
+	for pattern, typeLimit := range rule.TypeMaxSize {
+		if matchesType([]string{pattern}, contentType) != "" && len(pattern) > len(longest) {
+			longest, limit = pattern, typeLimit
+		}
+	}
+	if limit <= 0 || limit > MaxFileSize {
+		limit = MaxFileSize
+	}
+	if size > limit {
+		return ErrFileTooLarge
+	}
+	return nil
+}
+
+// matchesType returns the first pattern naming contentType, where patterns
+// ending in "/" match the whole family
+func matchesType(patterns []string, contentType string) string {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == contentType || strings.HasSuffix(pattern, "/") && strings.HasPrefix(contentType, pattern) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+func hasAnyPrefix(prefixes []string, value string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaType drops the parameters of a content type; invalid ones are empty
+func mediaType(value string) string {
+	if value == "" {
+		return ""
+	}
+	parsed, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return parsed
+}
+
+// readFileHead returns the start of a multipart file for type detection
+func readFileHead(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		logger.Error("Failed to open uploaded file", zap.String("filename", file.Filename), zap.Error(err))
+		return nil, errors.New("failed to open uploaded file")
+	}
+	defer src.Close()
+	return readHead(src)
+}
+
+// readObjectHead returns the start of a stored object for type detection
+func readObjectHead(ctx context.Context, storage In.IS3Repository, key string) ([]byte, error) {
+	body, _, err := storage.DownloadFile(ctx, key)
+	if err != nil {
+		logger.Error("Failed to open uploaded object", zap.String("key", key), zap.Error(err))
+		return nil, errors.New("failed to read uploaded file")
+	}
+	defer body.Close()
+	return readHead(body)
+}
+
+func readHead(r io.Reader) ([]byte, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, errors.New("failed to read uploaded file")
+	}
+	return head[:n], nil
+}
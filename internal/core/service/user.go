@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"project-api/internal/core/entity"
 	In "project-api/internal/core/port/repository"
@@ -15,6 +17,13 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	minSearchQuery     = 2
+	maxSearchQuery     = 100
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
 type UserService struct {
 	repo  In.IUserRepository
 	redis *redis.RedisClient
@@ -149,8 +158,10 @@ func (u *UserService) ConfirmResetPassword(ctx context.Context, token string, ne
 	}
 
 	// อัปเดต user: ลบ reset token และตั้งรหัสผ่านใหม่
+	// the token was delivered by email, so using it also proves the address (invited users)
 	user.ResetPasswordToken = ""
 	user.Password = string(hashedPassword)
+	user.IsActive = true
 	if err := u.repo.Update(ctx, user); err != nil {
 		logger.Error("Failed to update user with new password", zap.String("email", user.Email), zap.Error(err))
 		return fmt.Errorf("failed to update user: %w", err)
@@ -160,6 +171,30 @@ func (u *UserService) ConfirmResetPassword(ctx context.Context, token string, ne
 	return nil
 }
 
+// SearchUsers runs a ranked user search, clamping the page size to sane bounds
+func (u *UserService) SearchUsers(ctx context.Context, query In.UserSearchQuery) (*In.UserSearchResult, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if length := utf8.RuneCountInString(query.Query); length < minSearchQuery || length > maxSearchQuery {
+		return nil, ErrSearchQuery
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+
+	result, err := u.repo.Search(ctx, query)
+	if err != nil {
+		if errors.Is(err, In.ErrInvalidCursor) {
+			return nil, err
+		}
+		logger.Error("Failed to search users", zap.String("query", query.Query), zap.Error(err))
+		return nil, wrapError(errors.New("failed to search users"), err)
+	}
+	return result, nil
+}
+
 func (u *UserService) invalidateCache(ctx context.Context, user *entity.User) {
 	keys := []string{
 		fmt.Sprintf("user:id:%d", user.ID),
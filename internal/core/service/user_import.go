@@ -0,0 +1,438 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+	"project-api/internal/core/model/request"
+	In "project-api/internal/core/port/repository"
+	InS "project-api/internal/core/port/service"
+	"project-api/internal/infra/logger"
+
+	"github.com/RichardKnop/machinery/v2"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	MaxImportPayload       = 4 << 20 // 4MB, the default Fiber body limit
+	maxImportRows          = 10000
+	importProgressInterval = 50
+	exportBatchSize        = 500
+)
+
+var (
+	ErrImportFormat    = errors.New("unsupported import format, expected csv or jsonl")
+	ErrImportTooLarge  = errors.New("import file exceeds 4MB limit")
+	ErrImportEmpty     = errors.New("import file is empty")
+	errImportMalformed = errors.New("malformed row")
+)
+
+var importCSVColumns = []string{"username", "email", "first_name", "last_name"}
+
+// UserImportService handles bulk user import and export
+type UserImportService struct {
+	userRepo In.IUserRepository
+	jobRepo  In.IImportJobRepository
+	server   *machinery.Server
+	notifier InS.INotificationService
+	events   In.IEventBus
+}
+
+// NewUserImportService creates a new UserImportService instance
+func NewUserImportService(userRepo In.IUserRepository, jobRepo In.IImportJobRepository, server *machinery.Server, notifier InS.INotificationService, events In.IEventBus) InS.IUserImportService {
+	return &UserImportService{
+		userRepo: userRepo,
+		jobRepo:  jobRepo,
+		server:   server,
+		notifier: notifier,
+		events:   events,
+	}
+}
+
+// CreateImportJob stores the uploaded rows and queues them for the worker
+func (s *UserImportService) CreateImportJob(ctx context.Context, createdBy uint, fileName, format string, payload []byte, sendInvites bool) (*entity.ImportJob, error) {
+	format = detectImportFormat(fileName, format)
+	if format == "" {
+		return nil, ErrImportFormat
+	}
+	if len(payload) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(payload) > MaxImportPayload {
+		return nil, ErrImportTooLarge
+	}
+
+	tenantID, ok := utils.GetTenantIDFromContext(ctx)
+	if !ok {
+		return nil, errors.New("tenant is required")
+	}
+
+	job := &entity.ImportJob{
+		CreatedBy:   createdBy,
+		Status:      entity.ImportStatusPending,
+		Format:      format,
+		FileName:    fileName,
+		SendInvites: sendInvites,
+		Payload:     payload,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		logger.Error("Failed to create import job", zap.Error(err))
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	signature := &tasks.Signature{
+		Name: "import_users",
+		Args: []tasks.Arg{
+			{Type: "string", Value: tenantID},
+			{Type: "uint", Value: job.ID},
+		},
+	}
+	if _, err := s.server.SendTask(signature); err != nil {
+		logger.Error("Failed to queue import job", zap.Uint("jobID", job.ID), zap.Error(err))
+		s.failJob(ctx, job, "failed to queue import job")
+		return nil, fmt.Errorf("failed to queue import job: %w", err)
+	}
+
+	logger.Info("Queued user import job", zap.Uint("jobID", job.ID), zap.String("format", format))
+	return job, nil
+}
+
+// GetImportJob returns an import job with its per-row errors
+func (s *UserImportService) GetImportJob(ctx context.Context, id uint) (*entity.ImportJob, error) {
+	job, err := s.jobRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("import job not found: %w", err)
+	}
+	return job, nil
+}
+
+// ProcessImportJob creates a user for every valid row of the job. Rows that
+// fail validation or insertion are recorded on the job and skipped.
+func (s *UserImportService) ProcessImportJob(ctx context.Context, id uint) error {
+	job, err := s.jobRepo.GetById(ctx, id)
+	if err != nil {
+		logger.Error("Import job not found", zap.Uint("jobID", id), zap.Error(err))
+		return fmt.Errorf("import job not found: %w", err)
+	}
+
+	// A redelivered task finds the job claimed by the first delivery
+	err = s.jobRepo.Transition(ctx, id, entity.ImportStatusPending, entity.ImportStatusProcessing)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info("Import job already handled", zap.Uint("jobID", id))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+	job.Status = entity.ImportStatusProcessing
+
+	next, err := newImportRowReader(job.Format, job.Payload)
+	if err != nil {
+		s.failJob(ctx, job, err.Error())
+		return nil
+	}
+
+	for row := 1; ; row++ {
+		record, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if row > maxImportRows {
+			job.Message = fmt.Sprintf("import stopped after %d rows", maxImportRows)
+			break
+		}
+
+		job.TotalRows++
+		if err == nil {
+			err = s.importRow(ctx, job, record)
+		}
+		if err != nil {
+			job.FailedRows++
+			rowErr := entity.ImportRowError{Row: row, Message: err.Error()}
+			if record != nil {
+				rowErr.Email = record.Email
+			}
+			job.RowErrors = append(job.RowErrors, rowErr)
+		} else {
+			job.SucceededRows++
+		}
+
+		if job.TotalRows%importProgressInterval == 0 {
+			if err := s.jobRepo.Update(ctx, job); err != nil {
+				logger.Warn("Failed to save import progress", zap.Uint("jobID", job.ID), zap.Error(err))
+			}
+		}
+	}
+
+	job.Status = entity.ImportStatusCompleted
+	job.Payload = nil // rows may contain passwords, drop them once processed
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		logger.Error("Failed to complete import job", zap.Uint("jobID", job.ID), zap.Error(err))
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	logger.Info("User import completed",
+		zap.Uint("jobID", job.ID),
+		zap.Int("total", job.TotalRows),
+		zap.Int("succeeded", job.SucceededRows),
+		zap.Int("failed", job.FailedRows))
+	s.notifyCompleted(ctx, job)
+	return nil
+}
+
+// ExportUsers writes every user of the tenant to w as CSV, flushing after each batch
+func (s *UserImportService) ExportUsers(ctx context.Context, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "username", "email", "first_name", "last_name", "role", "is_active", "created_at"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write export header: %w", err)
+	}
+
+	err := s.userRepo.FindInBatches(ctx, exportBatchSize, func(users []entity.User) error {
+		for _, user := range users {
+			record := []string{
+				strconv.FormatUint(uint64(user.ID), 10),
+				user.UserName,
+				user.Email,
+				user.FirstName,
+				user.LastName,
+				user.Role,
+				strconv.FormatBool(user.IsActive),
+				user.CreatedAt.UTC().Format(time.RFC3339),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(interface{ Flush() error }); ok {
+			return flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to export users", zap.Error(err))
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// importRow validates a single row with the registration rules and creates the user
+func (s *UserImportService) importRow(ctx context.Context, job *entity.ImportJob, row *request.ImportUserRow) error {
+	if row.Password == "" && job.SendInvites {
+		password, err := generatePassword()
+		if err != nil {
+			return err
+		}
+		row.Password = password
+	}
+
+	if err := row.ToRegisterRequest().Validate(); err != nil {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(row.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	user := request.UserRequest{
+		FirstName:    row.FirstName,
+		LastName:     row.LastName,
+		Username:     row.UserName,
+		Email:        row.Email,
+		Password:     string(hashed),
+		IsActive:     false,
+		ConfirmToken: uuid.New().String(),
+	}
+	userEntity, err := user.ToEntity()
+	if err != nil {
+		return err
+	}
+	userEntity.Role = entity.RoleUser
+	if job.SendInvites {
+		userEntity.ResetPasswordToken = uuid.New().String()
+	}
+
+	if err := s.userRepo.Create(ctx, userEntity); err != nil {
+		logger.Warn("Failed to import user", zap.String("email", row.Email), zap.Error(err))
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if job.SendInvites {
+		s.sendInvitation(ctx, userEntity)
+	}
+	return nil
+}
+
+// sendInvitation sends the invitation email that lets an imported user set a password
+func (s *UserImportService) sendInvitation(ctx context.Context, user *entity.User) {
+	err := s.notifier.Notify(ctx, InS.Notification{
+		User:     user,
+		Category: entity.NotificationCategoryAccount,
+		Title:    "Welcome! Your account is ready",
+		Body:     "Choose a password with the link we emailed you to start using your account.",
+		Email:    &InS.EmailNotification{Task: "send_invitation_email", Token: user.ResetPasswordToken},
+	})
+	if err != nil {
+		logger.Error("Failed to send invitation", zap.String("email", user.Email), zap.Error(err))
+	}
+}
+
+// notifyCompleted tells the admin who started the import how it went
+func (s *UserImportService) notifyCompleted(ctx context.Context, job *entity.ImportJob) {
+	if err := s.events.Publish(ctx, job.CreatedBy, EventImportCompleted, job); err != nil {
+		logger.Warn("Failed to publish import event", zap.Uint("jobID", job.ID), zap.Error(err))
+	}
+	err := s.notifier.Notify(ctx, InS.Notification{
+		UserID:   job.CreatedBy,
+		Category: entity.NotificationCategoryAdmin,
+		Title:    "User import finished",
+		Body: fmt.Sprintf("Import #%d of %s finished: %d of %d row(s) imported, %d failed.",
+			job.ID, job.FileName, job.SucceededRows, job.TotalRows, job.FailedRows),
+	})
+	if err != nil {
+		logger.Warn("Failed to notify import completion", zap.Uint("jobID", job.ID), zap.Error(err))
+	}
+}
+
+// failJob marks the job as failed with the given reason
+func (s *UserImportService) failJob(ctx context.Context, job *entity.ImportJob, message string) {
+	job.Status = entity.ImportStatusFailed
+	job.Message = message
+	job.Payload = nil
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		logger.Error("Failed to mark import job as failed", zap.Uint("jobID", job.ID), zap.Error(err))
+	}
+}
+
+// detectImportFormat returns the normalized format, falling back to the file extension
+func detectImportFormat(fileName, format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	}
+	switch format {
+	case entity.ImportFormatCSV:
+		return entity.ImportFormatCSV
+	case entity.ImportFormatJSONL, "ndjson", "json":
+		return entity.ImportFormatJSONL
+	}
+	return ""
+}
+
+// newImportRowReader returns a function yielding one row per call and io.EOF at the end
+func newImportRowReader(format string, payload []byte) (func() (*request.ImportUserRow, error), error) {
+	switch format {
+	case entity.ImportFormatCSV:
+		return newCSVRowReader(payload)
+	case entity.ImportFormatJSONL:
+		return newJSONLRowReader(payload), nil
+	}
+	return nil, ErrImportFormat
+}
+
+func newCSVRowReader(payload []byte) (func() (*request.ImportUserRow, error), error) {
+	reader := csv.NewReader(bytes.NewReader(payload))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %q", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	return func() (*request.ImportUserRow, error) {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errImportMalformed, err)
+		}
+		return &request.ImportUserRow{
+			UserName:  field(record, "username"),
+			Email:     field(record, "email"),
+			FirstName: field(record, "first_name"),
+			LastName:  field(record, "last_name"),
+			Password:  field(record, "password"),
+		}, nil
+	}, nil
+}
+
+func newJSONLRowReader(payload []byte) func() (*request.ImportUserRow, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), MaxImportPayload)
+
+	done := false
+	return func() (*request.ImportUserRow, error) {
+		if done {
+			return nil, io.EOF
+		}
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			row := &request.ImportUserRow{}
+			if err := json.Unmarshal(line, row); err != nil {
+				return nil, fmt.Errorf("%w: %v", errImportMalformed, err)
+			}
+			return row, nil
+		}
+		done = true
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %v", errImportMalformed, err)
+		}
+		return nil, io.EOF
+	}
+}
+
+// generatePassword returns a random password for invited users, who replace it
+// through the invitation link
+func generatePassword() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.New("failed to generate password")
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
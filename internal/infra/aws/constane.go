@@ -1,5 +0,0 @@
-package aws
-
-import "time"
-
-var DefaultExpiry time.Duration = 0
@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// CreateMultipartUpload starts a multipart upload and returns its S3 upload ID
+func (s *StorageWrapper) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	output, err := s.Conn().CreateMultipartUpload(ctx, &awss3.CreateMultipartUploadInput{
+		Bucket:      awsv2.String(s.bucket),
+		Key:         awsv2.String(key),
+		ContentType: awsv2.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	return awsv2.ToString(output.UploadId), nil
+}
+
+// UploadPart stores one part; body is seekable so the SDK can sign it
+func (s *StorageWrapper) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.ReadSeeker, size int64) error {
+	_, err := s.Conn().UploadPart(ctx, &awss3.UploadPartInput{
+		Bucket:        awsv2.String(s.bucket),
+		Key:           awsv2.String(key),
+		UploadId:      awsv2.String(uploadID),
+		PartNumber:    awsv2.Int32(partNumber),
+		Body:          body,
+		ContentLength: awsv2.Int64(size),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return nil
+}
+
+// CompleteMultipartUpload joins every uploaded part, as listed by S3, into the object
+func (s *StorageWrapper) CompleteMultipartUpload(ctx context.Context, key, uploadID string) error {
+	var parts []types.CompletedPart
+	paginator := awss3.NewListPartsPaginator(s.Conn(), &awss3.ListPartsInput{
+		Bucket:   awsv2.String(s.bucket),
+		Key:      awsv2.String(key),
+		UploadId: awsv2.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list uploaded parts: %v", err)
+		}
+		for _, part := range page.Parts {
+			parts = append(parts, types.CompletedPart{
+				ETag:       part.ETag,
+				PartNumber: part.PartNumber,
+			})
+		}
+	}
+
+	_, err := s.Conn().CompleteMultipartUpload(ctx, &awss3.CompleteMultipartUploadInput{
+		Bucket:          awsv2.String(s.bucket),
+		Key:             awsv2.String(key),
+		UploadId:        awsv2.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards an unfinished upload and its parts
+func (s *StorageWrapper) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.Conn().AbortMultipartUpload(ctx, &awss3.AbortMultipartUploadInput{
+		Bucket:   awsv2.String(s.bucket),
+		Key:      awsv2.String(key),
+		UploadId: awsv2.String(uploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
+	}
+	return nil
+}
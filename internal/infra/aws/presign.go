@@ -0,0 +1,160 @@
+package aws
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-api/internal/core/port/repository"
+	"project-api/internal/infra/config"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat  = "20060102T150405Z"
+	amzDayFormat   = "20060102"
+)
+
+// PresignPut signs a PutObject that only accepts the declared content type,
+// length and checksum
+func (s *StorageWrapper) PresignPut(ctx context.Context, input repository.PresignUploadInput) (*repository.PresignedRequest, error) {
+	putInput := &awss3.PutObjectInput{
+		Bucket:        awsv2.String(s.bucket),
+		Key:           awsv2.String(input.Key),
+		ContentType:   awsv2.String(input.ContentType),
+		ContentLength: awsv2.Int64(input.Size),
+	}
+	if input.ChecksumSHA256 != "" {
+		putInput.ChecksumSHA256 = awsv2.String(input.ChecksumSHA256)
+	}
+
+	signed, err := awss3.NewPresignClient(s.Conn()).PresignPutObject(ctx, putInput, awss3.WithPresignExpires(input.TTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %v", err)
+	}
+	return &repository.PresignedRequest{
+		Method:    signed.Method,
+		URL:       signed.URL,
+		Headers:   signedHeaders(signed.SignedHeader),
+		ExpiresAt: time.Now().Add(input.TTL),
+	}, nil
+}
+
+// PresignPost builds a browser form upload. The SDK has no POST policy
+// support, so the policy is signed here with SigV4.
+func (s *StorageWrapper) PresignPost(ctx context.Context, input repository.PresignUploadInput) (*repository.PresignedRequest, error) {
+	options := s.Conn().Options()
+	credentials, err := options.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load S3 credentials: %v", err)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(input.TTL)
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format(amzDayFormat), options.Region)
+
+	fields := map[string]string{
+		"key":              input.Key,
+		"Content-Type":     input.ContentType,
+		"x-amz-algorithm":  sigV4Algorithm,
+		"x-amz-credential": credentials.AccessKeyID + "/" + scope,
+		"x-amz-date":       now.Format(amzDateFormat),
+	}
+	if credentials.SessionToken != "" {
+		fields["x-amz-security-token"] = credentials.SessionToken
+	}
+	if input.ChecksumSHA256 != "" {
+		fields["x-amz-checksum-sha256"] = input.ChecksumSHA256
+	}
+
+	conditions := []interface{}{
+		map[string]string{"bucket": s.bucket},
+		[]interface{}{"content-length-range", input.Size, input.Size},
+	}
+	for name, value := range fields {
+		conditions = append(conditions, []string{"eq", "$" + name, value})
+	}
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": expiresAt.Format(time.RFC3339),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload policy: %v", err)
+	}
+
+	encodedPolicy := base64.StdEncoding.EncodeToString(policy)
+	fields["policy"] = encodedPolicy
+	fields["x-amz-signature"] = signPolicy(credentials.SecretAccessKey, now, options.Region, encodedPolicy)
+
+	return &repository.PresignedRequest{
+		Method:    http.MethodPost,
+		URL:       s.bucketURL(),
+		Fields:    fields,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PresignGet signs a GetObject that downloads as an attachment named fileName
+func (s *StorageWrapper) PresignGet(ctx context.Context, key, fileName string, ttl time.Duration) (*repository.PresignedRequest, error) {
+	signed, err := awss3.NewPresignClient(s.Conn()).PresignGetObject(ctx, &awss3.GetObjectInput{
+		Bucket:                     awsv2.String(s.bucket),
+		Key:                        awsv2.String(key),
+		ResponseContentDisposition: awsv2.String(mime.FormatMediaType("attachment", map[string]string{"filename": fileName})),
+	}, awss3.WithPresignExpires(ttl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign download: %v", err)
+	}
+	return &repository.PresignedRequest{
+		Method:    signed.Method,
+		URL:       signed.URL,
+		Headers:   signedHeaders(signed.SignedHeader),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// bucketURL is where POST uploads go; custom endpoints use path-style addressing
+func (s *StorageWrapper) bucketURL() string {
+	config := config.Config.GetS3Config()
+	if config.Endpoint != "" {
+		return strings.TrimRight(config.Endpoint, "/") + "/" + s.bucket
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", s.bucket, config.Region)
+}
+
+// signedHeaders flattens the headers the client has to send with a presigned
+// request; Host is set by the client itself
+func signedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		if strings.EqualFold(name, "Host") {
+			continue
+		}
+		headers[name] = header.Get(name)
+	}
+	return headers
+}
+
+func signPolicy(secretKey string, now time.Time, region, policy string) string {
+	key := hmacSHA256([]byte("AWS4"+secretKey), now.Format(amzDayFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, policy))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
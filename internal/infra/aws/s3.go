@@ -1,113 +1,189 @@
 package aws
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"project-api/internal/infra/config"
-	"sync"
-	"time"
 
 	"project-api/internal/core/port/repository"
 
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/gofiber/storage/s3/v2"
 )
 
+const (
+	// uploadPartSize is the size of each part of a multipart upload. Bodies
+	// smaller than one part are sent with a single PutObject.
+	uploadPartSize = 8 << 20 // 8MB
+	// uploadConcurrency bounds the parts in flight, so an upload buffers at
+	// most uploadPartSize * uploadConcurrency bytes whatever the file size
+	uploadConcurrency = 4
+)
+
 type StorageWrapper struct {
 	*s3.Storage
+	bucket   string
+	uploader *manager.Uploader
 }
 
 func New(config s3.Config) repository.IS3Repository {
+	storage := s3.New(config)
 	return &StorageWrapper{
-		s3.New(config),
+		Storage: storage,
+		bucket:  config.Bucket,
+		uploader: manager.NewUploader(storage.Conn(), func(u *manager.Uploader) {
+			u.PartSize = uploadPartSize
+			u.Concurrency = uploadConcurrency
+		}),
 	}
 }
 
-func (s *StorageWrapper) UploadFile(file *multipart.FileHeader, expir *time.Duration) (string, error) {
-	// เปิดไฟล์
-	config := config.Config.GetS3Config()
-
-	src, err := file.Open()
-	if err != nil {
-		return "", fmt.Errorf("failed to open file: %v", err)
+// UploadFile streams body to S3 under key, switching to a multipart upload
+// for bodies larger than one part
+func (s *StorageWrapper) UploadFile(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	input := &awss3.PutObjectInput{
+		Bucket:      awsv2.String(s.bucket),
+		Key:         awsv2.String(key),
+		Body:        body,
+		ContentType: awsv2.String(contentType),
 	}
-	defer src.Close()
-
-	// อ่านไฟล์เป็น []byte
-	buf := new(bytes.Buffer)
-	if _, err := io.Copy(buf, src); err != nil {
-		return "", fmt.Errorf("failed to read file: %v", err)
+	if size >= 0 && size < uploadPartSize {
+		input.ContentLength = awsv2.Int64(size)
 	}
-	data := buf.Bytes()
-
-	// สร้าง key สำหรับจัดเก็บไฟล์ใน S3
-	key := fmt.Sprintf("file/%s/%s", file.Header.Get("Content-Type"), file.Filename)
 
 	// อัปโหลดไฟล์ไปยัง S3
-	if expir == nil {
-		expir = &DefaultExpiry
-	}
-	err = s.Set(key, data, *expir) // TTL = 0 (ไฟล์จะไม่หมดอายุ)
-	if err != nil {
+	if _, err := s.uploader.Upload(ctx, input); err != nil {
 		return "", fmt.Errorf("failed to upload file to S3: %v", err)
 	}
 
-	// URL ของไฟล์ที่อัปโหลด
-	fileURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", config.Bucket, config.Region, key)
+	return s.FileURL(key), nil
+}
 
-	return fileURL, nil
+// FileURL คืน URL ของไฟล์ที่อัปโหลด
+func (s *StorageWrapper) FileURL(key string) string {
+	config := config.Config.GetS3Config()
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", config.Bucket, config.Region, key)
 }
 
-func (s *StorageWrapper) UploadMultipleFiles(files []*multipart.FileHeader, expir *time.Duration) ([]string, error) {
-	var (
-		fileURLs     []string
-		uploadErrors []error
-		mu           sync.Mutex
-		wg           sync.WaitGroup
-	)
-
-	for _, file := range files {
-		wg.Add(1)
-		go func(file *multipart.FileHeader) {
-			defer wg.Done()
-			fileURL, err := s.UploadFile(file, expir)
-			mu.Lock()
-			if err != nil {
-				uploadErrors = append(uploadErrors, err)
-			} else {
-				fileURLs = append(fileURLs, fileURL)
-			}
-			mu.Unlock()
-		}(file)
+// DeleteFile ลบไฟล์จาก S3
+func (s *StorageWrapper) DeleteFile(ctx context.Context, key string) error {
+	_, err := s.Conn().DeleteObject(ctx, &awss3.DeleteObjectInput{
+		Bucket: awsv2.String(s.bucket),
+		Key:    awsv2.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete file from S3: %v", err)
 	}
-	wg.Wait()
+	return nil
+}
 
-	if len(uploadErrors) > 0 {
-		return fileURLs, fmt.Errorf("failed to upload %d file(s): %v", len(uploadErrors), uploadErrors)
+// CopyFile copies an object inside the bucket without passing it through the API
+func (s *StorageWrapper) CopyFile(ctx context.Context, srcKey, dstKey string) error {
+	_, err := s.Conn().CopyObject(ctx, &awss3.CopyObjectInput{
+		Bucket:     awsv2.String(s.bucket),
+		Key:        awsv2.String(dstKey),
+		CopySource: awsv2.String(s.bucket + "/" + srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy file in S3: %v", err)
 	}
-	return fileURLs, nil
+	return nil
 }
 
-// Download ดึงไฟล์จาก S3
-func (s *StorageWrapper) DeleteFile(key string) error {
-	err := s.Delete(key)
+// DownloadFile opens a stream on the object; the caller must close it
+func (s *StorageWrapper) DownloadFile(ctx context.Context, key string) (io.ReadCloser, *repository.ObjectInfo, error) {
+	output, err := s.Conn().GetObject(ctx, &awss3.GetObjectInput{
+		Bucket: awsv2.String(s.bucket),
+		Key:    awsv2.String(key),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to download file from S3: %v", err)
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil, repository.ErrObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to download file from S3: %v", err)
 	}
-	return nil
+
+	info := &repository.ObjectInfo{
+		Key:          key,
+		Size:         awsv2.ToInt64(output.ContentLength),
+		ContentType:  awsv2.ToString(output.ContentType),
+		ETag:         awsv2.ToString(output.ETag),
+		LastModified: awsv2.ToTime(output.LastModified),
+	}
+	return output.Body, info, nil
 }
 
-func (s *StorageWrapper) DownloadFile(key string) ([]byte, error) {
-	// Get file from S3
-	data, err := s.Get(key)
+// DownloadRange streams part of an object with a ranged GET
+func (s *StorageWrapper) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	output, err := s.Conn().GetObject(ctx, &awss3.GetObjectInput{
+		Bucket: awsv2.String(s.bucket),
+		Key:    awsv2.String(key),
+		Range:  awsv2.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to download file from S3: %v", err)
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, repository.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to download file range from S3: %v", err)
 	}
+	return output.Body, nil
+}
 
-	if data == nil {
-		return nil, fmt.Errorf("file not found in S3")
+// StatFile reads the metadata of an object, including its SHA-256 checksum
+// when it was uploaded with one
+func (s *StorageWrapper) StatFile(ctx context.Context, key string) (*repository.ObjectInfo, error) {
+	output, err := s.Conn().HeadObject(ctx, &awss3.HeadObjectInput{
+		Bucket:       awsv2.String(s.bucket),
+		Key:          awsv2.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, repository.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat file in S3: %v", err)
 	}
 
-	return data, nil
+	return &repository.ObjectInfo{
+		Key:            key,
+		Size:           awsv2.ToInt64(output.ContentLength),
+		ContentType:    awsv2.ToString(output.ContentType),
+		ETag:           awsv2.ToString(output.ETag),
+		ChecksumSHA256: awsv2.ToString(output.ChecksumSHA256),
+		LastModified:   awsv2.ToTime(output.LastModified),
+	}, nil
+}
+
+// ListObjects pages through the bucket with ListObjectsV2
+func (s *StorageWrapper) ListObjects(ctx context.Context, prefix string, fn func(repository.ObjectInfo) error) error {
+	paginator := awss3.NewListObjectsV2Paginator(s.Conn(), &awss3.ListObjectsV2Input{
+		Bucket: awsv2.String(s.bucket),
+		Prefix: awsv2.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list objects in S3: %v", err)
+		}
+		for _, object := range page.Contents {
+			err := fn(repository.ObjectInfo{
+				Key:          awsv2.ToString(object.Key),
+				Size:         awsv2.ToInt64(object.Size),
+				ETag:         awsv2.ToString(object.ETag),
+				LastModified: awsv2.ToTime(object.LastModified),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
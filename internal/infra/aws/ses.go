@@ -15,74 +15,45 @@ import (
 	"go.uber.org/zap"
 )
 
-func SendConfirmationEmail(toEmail string, token string, name string, host string) error {
-	awsConfig := config.Config.GetSESConfig()
-	awsCredential := config.Config.GetCredentialSES()
-	// สร้าง AWS session
-	sess, err := session.NewSession(&aws.Config{
-		Region:      awsConfig.Region,
-		Credentials: awsCredential,
+func SendConfirmationEmail(toEmail string, token string, name string, host string, tenant string) error {
+	return sendTemplateEmail(toEmail, "Confirm Your Email Address", "templates/email_confirmation.html", infra.EmailData{
+		Name:   name,
+		Token:  token,
+		Host:   host,
+		Tenant: tenant,
 	})
-	if err != nil {
-		logger.Error("Failed to create AWS session for SES", zap.Error(err))
-		return fmt.Errorf("failed to create AWS session: %w", err)
-	}
+}
 
-	// สร้าง SES client
-	sesClient := ses.New(sess)
+func SendResetPasswordEmail(toEmail string, token string, name string, host string, tenant string) error {
+	return sendTemplateEmail(toEmail, "Reset Your Password", "templates/email_reset_password.html", infra.EmailData{
+		Name:   name,
+		Token:  token,
+		Host:   host,
+		Tenant: tenant,
+	})
+}
 
-	// โหลดและ render เทมเพลต HTML
-	tmpl, err := template.ParseFiles("templates/email_confirmation.html")
-	if err != nil {
-		logger.Error("Failed to parse email template", zap.Error(err))
-		return fmt.Errorf("failed to parse email template: %w", err)
-	}
+func SendInvitationEmail(toEmail string, token string, name string, host string, tenant string) error {
+	return sendTemplateEmail(toEmail, "You Have Been Invited", "templates/email_invitation.html", infra.EmailData{
+		Name:   name,
+		Token:  token,
+		Host:   host,
+		Tenant: tenant,
+	})
+}
 
-	data := infra.EmailData{
+func SendNotificationEmail(toEmail string, title string, name string, body string) error {
+	return sendTemplateEmail(toEmail, title, "templates/email_notification.html", infra.EmailData{
 		Name:  name,
-		Token: token,
-		Host:  host,
-	}
-	var body bytes.Buffer
-	if err := tmpl.Execute(&body, data); err != nil {
-		logger.Error("Failed to render email template", zap.Error(err))
-		return fmt.Errorf("failed to render email template: %w", err)
-	}
-
-	// ส่งอีเมลผ่าน SES
-	input := &ses.SendEmailInput{
-		Destination: &ses.Destination{
-			ToAddresses: []*string{aws.String(toEmail)},
-		},
-		Message: &ses.Message{
-			Body: &ses.Body{
-				Html: &ses.Content{
-					Charset: aws.String("UTF-8"),
-					Data:    aws.String(body.String()),
-				},
-			},
-			Subject: &ses.Content{
-				Charset: aws.String("UTF-8"),
-				Data:    aws.String("Confirm Your Email Address"),
-			},
-		},
-		Source: aws.String(config.Config.SES.From),
-	}
-
-	_, err = sesClient.SendEmail(input)
-	if err != nil {
-		logger.Error("Failed to send email via SES", zap.Error(err), zap.String("to", toEmail))
-		return fmt.Errorf("failed to send email via SES: %w", err)
-	}
-
-	logger.Info("Confirmation email sent via SES", zap.String("to", toEmail))
-	return nil
+		Title: title,
+		Body:  body,
+	})
 }
 
-func SendResetPasswordEmail(toEmail string, token string, name string, host string) error {
+// sendTemplateEmail renders the HTML template at templatePath with data and sends it through SES
+func sendTemplateEmail(toEmail string, subject string, templatePath string, data infra.EmailData) error {
 	awsConfig := config.Config.GetSESConfig()
 	awsCredential := config.Config.GetCredentialSES()
-
 	// สร้าง AWS session
 	sess, err := session.NewSession(&aws.Config{
 		Region:      awsConfig.Region,
@@ -97,20 +68,15 @@ func SendResetPasswordEmail(toEmail string, token string, name string, host stri
 	sesClient := ses.New(sess)
 
 	// โหลดและ render เทมเพลต HTML
-	tmpl, err := template.ParseFiles("templates/email_reset_password.html")
+	tmpl, err := template.ParseFiles(templatePath)
 	if err != nil {
-		logger.Error("Failed to parse reset password email template", zap.Error(err))
+		logger.Error("Failed to parse email template", zap.String("template", templatePath), zap.Error(err))
 		return fmt.Errorf("failed to parse email template: %w", err)
 	}
 
-	data := infra.EmailData{
-		Name:  name,
-		Token: token,
-		Host:  host,
-	}
 	var body bytes.Buffer
 	if err := tmpl.Execute(&body, data); err != nil {
-		logger.Error("Failed to render reset password email template", zap.Error(err))
+		logger.Error("Failed to render email template", zap.String("template", templatePath), zap.Error(err))
 		return fmt.Errorf("failed to render email template: %w", err)
 	}
 
@@ -128,7 +94,7 @@ func SendResetPasswordEmail(toEmail string, token string, name string, host stri
 			},
 			Subject: &ses.Content{
 				Charset: aws.String("UTF-8"),
-				Data:    aws.String("Reset Your Password"),
+				Data:    aws.String(subject),
 			},
 		},
 		Source: aws.String(config.Config.SES.From),
@@ -136,10 +102,10 @@ func SendResetPasswordEmail(toEmail string, token string, name string, host stri
 
 	_, err = sesClient.SendEmail(input)
 	if err != nil {
-		logger.Error("Failed to send reset password email via SES", zap.Error(err), zap.String("to", toEmail))
+		logger.Error("Failed to send email via SES", zap.Error(err), zap.String("to", toEmail), zap.String("subject", subject))
 		return fmt.Errorf("failed to send email via SES: %w", err)
 	}
 
-	logger.Info("Reset password email sent via SES", zap.String("to", toEmail))
+	logger.Info("Email sent via SES", zap.String("to", toEmail), zap.String("subject", subject))
 	return nil
 }
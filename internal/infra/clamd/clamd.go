@@ -0,0 +1,138 @@
+package clamd
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"project-api/internal/core/port/repository"
+)
+
+const (
+	// chunkSize stays well under clamd's default StreamMaxLength so a single
+	// chunk is never the one that trips it
+	chunkSize   = 64 << 10
+	dialTimeout = 10 * time.Second
+)
+
+// Client scans streams with a clamd-compatible daemon through the INSTREAM
+// command of its TCP protocol, one connection per scan
+type Client struct {
+	address string
+	timeout time.Duration
+}
+
+// New returns a scanner for the daemon at address (host:port); timeout
+// bounds a whole scan, connection included
+func New(address string, timeout time.Duration) repository.IVirusScanner {
+	return &Client{
+		address: address,
+		timeout: timeout,
+	}
+}
+
+// Scan sends r as length-prefixed chunks ended by an empty one and reads
+// clamd's single reply: "stream: OK", "stream: <signature> FOUND" or
+// "<reason> ERROR"
+func (c *Client) Scan(ctx context.Context, r io.Reader) (*repository.ScanResult, error) {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set clamd deadline: %v", err)
+	}
+	// A cancelled context unblocks whatever read or write is in progress
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := c.send(conn, r); err != nil {
+		// clamd answers and hangs up as soon as the stream goes over its
+		// limit, so a failed write may still have a verdict behind it
+		if result, replyErr := readReply(conn); replyErr == nil || errors.Is(replyErr, repository.ErrScanRejected) {
+			return result, replyErr
+		}
+		return nil, err
+	}
+	return readReply(conn)
+}
+
+func (c *Client) send(conn net.Conn, r io.Reader) error {
+	writer := bufio.NewWriterSize(conn, chunkSize+4)
+	if _, err := writer.WriteString("zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("failed to send clamd command: %v", err)
+	}
+
+	chunk := make([]byte, chunkSize)
+	var length [4]byte
+	for {
+		n, readErr := r.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(length[:], uint32(n))
+			if _, err := writer.Write(length[:]); err != nil {
+				return fmt.Errorf("failed to stream to clamd: %v", err)
+			}
+			if _, err := writer.Write(chunk[:n]); err != nil {
+				return fmt.Errorf("failed to stream to clamd: %v", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read content to scan: %v", readErr)
+		}
+	}
+
+	binary.BigEndian.PutUint32(length[:], 0)
+	if _, err := writer.Write(length[:]); err != nil {
+		return fmt.Errorf("failed to stream to clamd: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to stream to clamd: %v", err)
+	}
+	return nil
+}
+
+// readReply parses the NUL-terminated reply to a z-prefixed command
+func readReply(conn net.Conn) (*repository.ScanResult, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && (err != io.EOF || reply == "") {
+		return nil, fmt.Errorf("failed to read clamd reply: %v", err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+
+	switch {
+	case strings.HasSuffix(reply, " ERROR"):
+		return nil, fmt.Errorf("%w: %s", repository.ErrScanRejected, strings.TrimSuffix(reply, " ERROR"))
+	case !strings.HasPrefix(reply, "stream: "):
+		return nil, fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return &repository.ScanResult{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &repository.ScanResult{
+			Infected:  true,
+			Signature: strings.TrimSuffix(verdict, " FOUND"),
+		}, nil
+	}
+	return nil, fmt.Errorf("unexpected clamd reply: %q", reply)
+}
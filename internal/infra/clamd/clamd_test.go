@@ -0,0 +1,139 @@
+package clamd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"project-api/internal/core/port/repository"
+)
+
+// fakeClamd answers every INSTREAM with reply and records what was streamed
+type fakeClamd struct {
+	listener net.Listener
+	reply    string
+	received chan []byte
+}
+
+func newFakeClamd(t *testing.T, reply string) *fakeClamd {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeClamd{listener: listener, reply: reply, received: make(chan []byte, 1)}
+	t.Cleanup(func() { listener.Close() })
+	go fake.serve()
+	return fake
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	command, err := reader.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+	var data bytes.Buffer
+	var length [4]byte
+	for {
+		if _, err := io.ReadFull(reader, length[:]); err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint32(length[:])
+		if n == 0 {
+			break
+		}
+		if _, err := io.CopyN(&data, reader, int64(n)); err != nil {
+			return
+		}
+	}
+	f.received <- data.Bytes()
+	conn.Write([]byte(f.reply + "\x00"))
+}
+
+func (f *fakeClamd) address() string {
+	return f.listener.Addr().String()
+}
+
+func TestScanClean(t *testing.T) {
+	fake := newFakeClamd(t, "stream: OK")
+	// More than one chunk, so the framing is exercised
+	content := bytes.Repeat([]byte("clean content "), chunkSize/7)
+
+	result, err := New(fake.address(), 5*time.Second).Scan(context.Background(), bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if result.Infected || result.Signature != "" {
+		t.Fatalf("expected a clean verdict, got %+v", result)
+	}
+	if got := <-fake.received; !bytes.Equal(got, content) {
+		t.Fatalf("clamd received %d bytes, expected %d", len(got), len(content))
+	}
+}
+
+func TestScanFound(t *testing.T) {
+	fake := newFakeClamd(t, "stream: Eicar-Test-Signature FOUND")
+
+	result, err := New(fake.address(), 5*time.Second).Scan(context.Background(), strings.NewReader("X5O!P%@AP"))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !result.Infected || result.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("expected the EICAR signature, got %+v", result)
+	}
+}
+
+func TestScanError(t *testing.T) {
+	fake := newFakeClamd(t, "INSTREAM size limit exceeded. ERROR")
+
+	_, err := New(fake.address(), 5*time.Second).Scan(context.Background(), strings.NewReader("too large"))
+	if !errors.Is(err, repository.ErrScanRejected) {
+		t.Fatalf("expected ErrScanRejected, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "INSTREAM size limit exceeded.") {
+		t.Fatalf("expected the reason in the error, got %v", err)
+	}
+}
+
+func TestScanUnexpectedReply(t *testing.T) {
+	fake := newFakeClamd(t, "PONG")
+
+	_, err := New(fake.address(), 5*time.Second).Scan(context.Background(), strings.NewReader("content"))
+	if err == nil || errors.Is(err, repository.ErrScanRejected) {
+		t.Fatalf("expected an unexpected reply error, got %v", err)
+	}
+}
+
+func TestScanUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = New(address, 5*time.Second).Scan(context.Background(), strings.NewReader("content"))
+	if err == nil || errors.Is(err, repository.ErrScanRejected) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+}
@@ -28,10 +28,22 @@ func (g *GormDB) Connect() error {
 	models := []interface{}{
 		&entity.User{},
 		&entity.Address{},
+		&entity.Folder{},
 		&entity.File{},
+		&entity.Blob{},
+		&entity.ImportJob{},
+		&entity.Notification{},
+		&entity.NotificationPreference{},
+		&entity.Upload{},
+		&entity.QuotaPlan{},
+		&entity.StorageUsage{},
+		&entity.ShareLink{},
+		&entity.ShareLinkAccess{},
+		&entity.FilePermission{},
+		&entity.FileVersion{},
 	}
 	if err := db.AutoMigrate(models...); err != nil {
 		return nil
 	}
-	return nil
+	return runMigrations(db)
 }
@@ -0,0 +1,43 @@
+package config
+
+// Key providers
+const (
+	KeyProviderLocal = "local"
+)
+
+// Key scopes
+const (
+	KeyScopeUser   = "user"
+	KeyScopeMaster = "master"
+)
+
+const (
+	defaultEncryptionChunkSize = 64 << 10
+	maxEncryptionChunkSize     = 16 << 20
+)
+
+// GetEncryptionProvider returns the configured key provider, the local key
+// file by default
+func (s *AppConfig) GetEncryptionProvider() string {
+	if s.Encryption.Provider == "" {
+		return KeyProviderLocal
+	}
+	return s.Encryption.Provider
+}
+
+// GetEncryptionKeyScope returns whose key wraps data keys, each user's own by
+// default
+func (s *AppConfig) GetEncryptionKeyScope() string {
+	if s.Encryption.KeyScope == "" {
+		return KeyScopeUser
+	}
+	return s.Encryption.KeyScope
+}
+
+// GetEncryptionChunkSize returns the plaintext each encrypted chunk holds
+func (s *AppConfig) GetEncryptionChunkSize() int {
+	if s.Encryption.ChunkSize <= 0 || s.Encryption.ChunkSize > maxEncryptionChunkSize {
+		return defaultEncryptionChunkSize
+	}
+	return s.Encryption.ChunkSize
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// migration is a schema change AutoMigrate can't express (extensions, expression
+// indexes, ...). Migrations run once, in order, and are recorded in schema_migrations.
+type migration struct {
+	ID         string
+	Statements []string
+}
+
+var migrations = []migration{
+	{
+		ID: "0001_user_search_indexes",
+		Statements: []string{
+			`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+			`CREATE INDEX IF NOT EXISTS idx_user_user_name_trgm ON "user" USING gin (user_name gin_trgm_ops)`,
+			`CREATE INDEX IF NOT EXISTS idx_user_email_trgm ON "user" USING gin (email gin_trgm_ops)`,
+			`CREATE INDEX IF NOT EXISTS idx_user_full_name_trgm ON "user" USING gin ((first_name || ' ' || last_name) gin_trgm_ops)`,
+			`CREATE INDEX IF NOT EXISTS idx_user_search_fts ON "user" USING gin (to_tsvector('simple', user_name || ' ' || first_name || ' ' || last_name || ' ' || email))`,
+		},
+	},
+	{
+		ID: "0002_file_list_indexes",
+		Statements: []string{
+			`CREATE INDEX IF NOT EXISTS idx_files_tags ON files USING gin (tags jsonb_path_ops)`,
+			`CREATE INDEX IF NOT EXISTS idx_files_file_name_trgm ON files USING gin (file_name gin_trgm_ops)`,
+			`CREATE INDEX IF NOT EXISTS idx_files_user_created ON files (tenant_id, user_id, created_at DESC, id DESC) WHERE NOT is_deleted`,
+		},
+	},
+	{
+		ID: "0003_folder_sibling_names",
+		Statements: []string{
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_sibling_name ON folders (tenant_id, user_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), lower(name)) WHERE NOT is_deleted`,
+		},
+	},
+	{
+		// User names, emails and identities became unique per tenant; the
+		// global indexes from before tenants would still keep a second tenant
+		// from reusing them
+		ID: "0004_drop_global_user_indexes",
+		Statements: []string{
+			`DROP INDEX IF EXISTS idx_user_user_name`,
+			`DROP INDEX IF EXISTS idx_user_email`,
+			`DROP INDEX IF EXISTS idx_user_identity`,
+		},
+	},
+	{
+		ID: "0005_user_directory_index",
+		Statements: []string{
+			`CREATE INDEX IF NOT EXISTS idx_user_directory_fts ON "user" USING gin (to_tsvector('simple', user_name || ' ' || first_name || ' ' || last_name))`,
+		},
+	},
+}
+
+// runMigrations applies every migration that has not been recorded yet
+func runMigrations(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		id varchar(255) PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`).Error; err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		var count int64
+		if err := db.Table("schema_migrations").Where("id = ?", m.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", m.ID, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, statement := range m.Statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Exec("INSERT INTO schema_migrations (id) VALUES (?)", m.ID).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.ID, err)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ type AppConfig struct {
 	Server struct {
 		Host string `yaml:"host" env:"HOST" envDefault:"localhost"`
 		Port string `yaml:"port" env:"PORT" envDefault:"8000"`
+		// BodyLimitMB caps request bodies; uploads stream to disk so it can exceed RAM
+		BodyLimitMB int `yaml:"body_limit_mb" env:"BODY_LIMIT_MB" envDefault:"5120"`
 	} `yaml:"server"`
 	Database struct {
 		Host     string `yaml:"host" env:"POSTGRES_HOST"`
@@ -20,7 +22,29 @@ type AppConfig struct {
 		Region   string `yaml:"region" env:"AWS_REGION"`
 		Bucket   string `yaml:"bucket" env:"AWS_BUCKET"`
 		Endpoint string `yaml:"endpoint" env:"AWS_ENDPOINT"`
+		// Presigned URL lifetimes in seconds; clients may ask for a download TTL up to PresignMaxTTL
+		PresignUploadTTL   int `yaml:"presign_upload_ttl" env:"S3_PRESIGN_UPLOAD_TTL" envDefault:"900"`
+		PresignDownloadTTL int `yaml:"presign_download_ttl" env:"S3_PRESIGN_DOWNLOAD_TTL" envDefault:"300"`
+		PresignMaxTTL      int `yaml:"presign_max_ttl" env:"S3_PRESIGN_MAX_TTL" envDefault:"86400"`
+		// TusExpiry is how many seconds an unfinished tus upload is kept after its last chunk
+		TusExpiry int `yaml:"tus_expiry" env:"S3_TUS_EXPIRY" envDefault:"86400"`
+		// TusReceiveTimeout is how many seconds a tus upload may go without saving
+		// progress while a chunk is written before another request may take it over
+		TusReceiveTimeout int `yaml:"tus_receive_timeout" env:"S3_TUS_RECEIVE_TIMEOUT" envDefault:"1800"`
+		// TusCleanupSchedule is the cron spec of the job that removes expired tus uploads
+		TusCleanupSchedule string `yaml:"tus_cleanup_schedule" env:"S3_TUS_CLEANUP_SCHEDULE" envDefault:"0 * * * *"`
+		// FileMaxExpiry caps in seconds the expiry a client may ask for on an upload; zero is no cap
+		FileMaxExpiry int `yaml:"file_max_expiry" env:"S3_FILE_MAX_EXPIRY" envDefault:"2592000"`
+		// ExpirySchedule is the cron spec of the job that deletes expired files
+		ExpirySchedule string `yaml:"expiry_schedule" env:"S3_EXPIRY_SCHEDULE" envDefault:"*/15 * * * *"`
 	} `yaml:"s3"`
+	Storage struct {
+		// Backend is where objects live: s3, local (a directory) or memory,
+		// which is lost on restart and not shared between processes
+		Backend string `yaml:"backend" env:"STORAGE_BACKEND" envDefault:"s3"`
+		// LocalRoot is the directory of the local backend
+		LocalRoot string `yaml:"local_root" env:"STORAGE_LOCAL_ROOT" envDefault:"data/storage"`
+	} `yaml:"storage"`
 	Credentials struct {
 		AccessKey string `yaml:"access_key" env:"AWS_ACCESS_KEY_ID"`
 		SecretKey string `yaml:"secret_key" env:"AWS_SECRET_ACCESS_KEY"`
@@ -42,6 +66,83 @@ type AppConfig struct {
 		SecretKey string `yaml:"secret_key_sqs" env:"SECRET_SQS"`
 		Endpoint  string `yaml:"endpoint_sqs" env:"ENDPOINT_SQS"`
 	} `yaml:"sqs"`
+	Tenant struct {
+		Default    string `yaml:"default" env:"TENANT_DEFAULT" envDefault:"default"`
+		Header     string `yaml:"header" env:"TENANT_HEADER" envDefault:"X-Tenant-ID"`
+		HostSuffix string `yaml:"host_suffix" env:"TENANT_HOST_SUFFIX"`
+	} `yaml:"tenant"`
+	Quota struct {
+		// Limits of users without a quota plan; zero is unlimited
+		DefaultMaxBytes int64 `yaml:"default_max_bytes" env:"QUOTA_DEFAULT_MAX_BYTES" envDefault:"10737418240"`
+		DefaultMaxFiles int64 `yaml:"default_max_files" env:"QUOTA_DEFAULT_MAX_FILES" envDefault:"0"`
+		// ReconcileSchedule is the cron spec of the job that recomputes usage from the files table
+		ReconcileSchedule string `yaml:"reconcile_schedule" env:"QUOTA_RECONCILE_SCHEDULE" envDefault:"0 3 * * *"`
+	} `yaml:"quota"`
+	Versions struct {
+		// Keep is how many earlier versions of each file survive pruning; zero keeps them all
+		Keep int `yaml:"keep" env:"VERSIONS_KEEP" envDefault:"10"`
+		// MaxAgeDays prunes earlier versions uploaded longer ago; zero keeps them regardless of age
+		MaxAgeDays int `yaml:"max_age_days" env:"VERSIONS_MAX_AGE_DAYS" envDefault:"0"`
+		// PruneSchedule is the cron spec of the job that applies the retention above
+		PruneSchedule string `yaml:"prune_schedule" env:"VERSIONS_PRUNE_SCHEDULE" envDefault:"30 3 * * *"`
+	} `yaml:"versions"`
+	Upload struct {
+		Default UploadRule `yaml:"default" envPrefix:"UPLOAD_"`
+		// Routes (upload, version, presign, tus) and Roles lay their rule over
+		// the default one; the role's rule goes on last
+		Routes map[string]UploadRule `yaml:"routes"`
+		Roles  map[string]UploadRule `yaml:"roles"`
+	} `yaml:"upload"`
+	Trash struct {
+		// RetentionDays is how long deleted files can be restored before they are purged
+		RetentionDays int `yaml:"retention_days" env:"TRASH_RETENTION_DAYS" envDefault:"30"`
+		// PurgeSchedule is the cron spec of the job that purges expired trash
+		PurgeSchedule string `yaml:"purge_schedule" env:"TRASH_PURGE_SCHEDULE" envDefault:"0 4 * * *"`
+	} `yaml:"trash"`
+	Thumbnails struct {
+		// Sizes are the derivatives made of every image upload; see GetThumbnailSizes
+		Sizes []ThumbnailSize `yaml:"sizes"`
+		// MaxPixels skips images larger than this once decoded
+		MaxPixels int `yaml:"max_pixels" env:"THUMBNAIL_MAX_PIXELS" envDefault:"40000000"`
+	} `yaml:"thumbnails"`
+	Scan struct {
+		// ClamdAddress is the host:port of a clamd-compatible daemon; empty
+		// turns scanning off and new uploads are downloadable right away
+		ClamdAddress string `yaml:"clamd_address" env:"SCAN_CLAMD_ADDRESS"`
+		// TimeoutSeconds bounds the scan of one file
+		TimeoutSeconds int `yaml:"timeout_seconds" env:"SCAN_TIMEOUT_SECONDS" envDefault:"300"`
+		// Schedule is the cron spec of the job that scans quarantined uploads
+		Schedule string `yaml:"schedule" env:"SCAN_SCHEDULE" envDefault:"* * * * *"`
+	} `yaml:"scan"`
+	Reconcile struct {
+		// Schedule is the cron spec of the job that checks storage against the database
+		Schedule string `yaml:"schedule" env:"RECONCILE_SCHEDULE" envDefault:"30 4 * * *"`
+		// Repair lets the scheduled job delete orphaned objects and clear
+		// missing thumbnails; without it the job only reports
+		Repair bool `yaml:"repair" env:"RECONCILE_REPAIR" envDefault:"false"`
+		// GracePeriodSeconds keeps newer objects from being taken for orphans
+		// while the rows that will point at them are still being written
+		GracePeriodSeconds int `yaml:"grace_period_seconds" env:"RECONCILE_GRACE_PERIOD_SECONDS" envDefault:"86400"`
+		// Prefix limits the objects checked; empty checks the whole bucket
+		Prefix string `yaml:"prefix" env:"RECONCILE_PREFIX"`
+	} `yaml:"reconcile"`
+	Encryption struct {
+		// Enabled stores new content encrypted. Content already stored stays
+		// as it is, and encrypted content stays readable while the keys are
+		// configured, whether or not this is on.
+		Enabled bool `yaml:"enabled" env:"ENCRYPTION_ENABLED" envDefault:"false"`
+		// Provider holds the keys that wrap data keys; only local, a key
+		// file, exists for now
+		Provider string `yaml:"provider" env:"ENCRYPTION_PROVIDER" envDefault:"local"`
+		// KeyFile holds the base64 of the 32-byte master key of the local
+		// provider, such as the output of openssl rand -base64 32
+		KeyFile string `yaml:"key_file" env:"ENCRYPTION_KEY_FILE"`
+		// KeyScope picks the key that wraps data keys: user gives every user
+		// a key of their own, master wraps them all with one
+		KeyScope string `yaml:"key_scope" env:"ENCRYPTION_KEY_SCOPE" envDefault:"user"`
+		// ChunkSize is the plaintext each encrypted chunk holds
+		ChunkSize int `yaml:"chunk_size" env:"ENCRYPTION_CHUNK_SIZE" envDefault:"65536"`
+	} `yaml:"encryption"`
 	Redis struct {
 		Endpoint string `yaml:"endpoint" env:"REDIS_ENDPOINT"`
 		Password string `yaml:"password" env:"REDIS_PASSWORD"`
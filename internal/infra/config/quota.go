@@ -0,0 +1,21 @@
+package config
+
+import "project-api/internal/core/entity"
+
+const defaultQuotaReconcileSchedule = "0 3 * * *"
+
+// GetDefaultQuota returns the limits of users that have no quota plan
+func (s *AppConfig) GetDefaultQuota() entity.QuotaLimits {
+	return entity.QuotaLimits{
+		MaxBytes: s.Quota.DefaultMaxBytes,
+		MaxFiles: s.Quota.DefaultMaxFiles,
+	}
+}
+
+// GetQuotaReconcileSchedule returns the cron spec of the usage reconciliation job
+func (s *AppConfig) GetQuotaReconcileSchedule() string {
+	if s.Quota.ReconcileSchedule == "" {
+		return defaultQuotaReconcileSchedule
+	}
+	return s.Quota.ReconcileSchedule
+}
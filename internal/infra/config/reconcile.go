@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+const (
+	defaultReconcileSchedule    = "30 4 * * *"
+	defaultReconcileGracePeriod = 24 * time.Hour
+)
+
+// GetReconcileSchedule returns the cron spec of the storage reconciliation job
+func (s *AppConfig) GetReconcileSchedule() string {
+	if s.Reconcile.Schedule == "" {
+		return defaultReconcileSchedule
+	}
+	return s.Reconcile.Schedule
+}
+
+// GetReconcileGracePeriod returns how old an unreferenced object must be to
+// count as orphaned
+func (s *AppConfig) GetReconcileGracePeriod() time.Duration {
+	if s.Reconcile.GracePeriodSeconds <= 0 {
+		return defaultReconcileGracePeriod
+	}
+	return time.Duration(s.Reconcile.GracePeriodSeconds) * time.Second
+}
@@ -1,9 +1,21 @@
 package config
 
 import (
+	"time"
+
 	"github.com/gofiber/storage/s3/v2"
 )
 
+const (
+	defaultPresignUploadTTL   = 15 * time.Minute
+	defaultPresignDownloadTTL = 5 * time.Minute
+	defaultPresignMaxTTL      = 24 * time.Hour
+	defaultTusExpiry          = 24 * time.Hour
+	defaultTusReceiveTimeout  = 30 * time.Minute
+	defaultTusCleanupSchedule = "0 * * * *"
+	defaultFileExpirySchedule = "*/15 * * * *"
+)
+
 func (s *AppConfig) GetS3Config() *s3.Config {
 	return &s3.Config{
 		Bucket:   s.S3.Bucket,
@@ -18,3 +30,73 @@ func (s *AppConfig) GetCredentials() s3.Credentials {
 		SecretAccessKey: s.Credentials.SecretKey,
 	}
 }
+
+// GetPresignUploadTTL returns how long a presigned upload stays valid
+func (s *AppConfig) GetPresignUploadTTL() time.Duration {
+	if s.S3.PresignUploadTTL <= 0 {
+		return defaultPresignUploadTTL
+	}
+	return time.Duration(s.S3.PresignUploadTTL) * time.Second
+}
+
+// GetPresignDownloadTTL returns the lifetime of a presigned download; a
+// requested TTL of zero means the default and larger ones are capped
+func (s *AppConfig) GetPresignDownloadTTL(requested time.Duration) time.Duration {
+	maxTTL := defaultPresignMaxTTL
+	if s.S3.PresignMaxTTL > 0 {
+		maxTTL = time.Duration(s.S3.PresignMaxTTL) * time.Second
+	}
+
+	ttl := requested
+	if ttl <= 0 {
+		ttl = defaultPresignDownloadTTL
+		if s.S3.PresignDownloadTTL > 0 {
+			ttl = time.Duration(s.S3.PresignDownloadTTL) * time.Second
+		}
+	}
+	if ttl > maxTTL {
+		ttl = maxTTL
+	}
+	return ttl
+}
+
+// GetTusExpiry returns how long an unfinished tus upload is kept after its last chunk
+func (s *AppConfig) GetTusExpiry() time.Duration {
+	if s.S3.TusExpiry <= 0 {
+		return defaultTusExpiry
+	}
+	return time.Duration(s.S3.TusExpiry) * time.Second
+}
+
+// GetTusReceiveTimeout returns how long a chunk may be written without saving
+// progress before its upload is taken for abandoned
+func (s *AppConfig) GetTusReceiveTimeout() time.Duration {
+	if s.S3.TusReceiveTimeout <= 0 {
+		return defaultTusReceiveTimeout
+	}
+	return time.Duration(s.S3.TusReceiveTimeout) * time.Second
+}
+
+// GetTusCleanupSchedule returns the cron spec of the expired tus upload cleanup job
+func (s *AppConfig) GetTusCleanupSchedule() string {
+	if s.S3.TusCleanupSchedule == "" {
+		return defaultTusCleanupSchedule
+	}
+	return s.S3.TusCleanupSchedule
+}
+
+// GetFileMaxExpiry returns the longest expiry an upload may ask for; zero means no cap
+func (s *AppConfig) GetFileMaxExpiry() time.Duration {
+	if s.S3.FileMaxExpiry <= 0 {
+		return 0
+	}
+	return time.Duration(s.S3.FileMaxExpiry) * time.Second
+}
+
+// GetFileExpirySchedule returns the cron spec of the expired file cleanup job
+func (s *AppConfig) GetFileExpirySchedule() string {
+	if s.S3.ExpirySchedule == "" {
+		return defaultFileExpirySchedule
+	}
+	return s.S3.ExpirySchedule
+}
@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+const (
+	defaultScanTimeout  = 5 * time.Minute
+	defaultScanSchedule = "* * * * *"
+)
+
+// GetScanEnabled reports whether new uploads are quarantined until scanned
+func (s *AppConfig) GetScanEnabled() bool {
+	return s.Scan.ClamdAddress != ""
+}
+
+// GetScanTimeout returns how long the scan of one file may take
+func (s *AppConfig) GetScanTimeout() time.Duration {
+	if s.Scan.TimeoutSeconds <= 0 {
+		return defaultScanTimeout
+	}
+	return time.Duration(s.Scan.TimeoutSeconds) * time.Second
+}
+
+// GetScanSchedule returns the cron spec of the scan job
+func (s *AppConfig) GetScanSchedule() string {
+	if s.Scan.Schedule == "" {
+		return defaultScanSchedule
+	}
+	return s.Scan.Schedule
+}
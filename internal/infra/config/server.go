@@ -0,0 +1,18 @@
+package config
+
+import "fmt"
+
+const defaultBodyLimitMB = 5120 // 5GB
+
+// GetServerURL returns the base URL used for links sent to users
+func (s *AppConfig) GetServerURL() string {
+	return fmt.Sprintf("http://%s:%s", s.Server.Host, s.Server.Port)
+}
+
+// GetBodyLimit returns the maximum request body size in bytes
+func (s *AppConfig) GetBodyLimit() int {
+	if s.Server.BodyLimitMB <= 0 {
+		return defaultBodyLimitMB << 20
+	}
+	return s.Server.BodyLimitMB << 20
+}
@@ -0,0 +1,26 @@
+package config
+
+// Storage backends
+const (
+	StorageS3     = "s3"
+	StorageLocal  = "local"
+	StorageMemory = "memory"
+)
+
+const defaultStorageLocalRoot = "data/storage"
+
+// GetStorageBackend returns the configured storage backend, S3 by default
+func (s *AppConfig) GetStorageBackend() string {
+	if s.Storage.Backend == "" {
+		return StorageS3
+	}
+	return s.Storage.Backend
+}
+
+// GetStorageLocalRoot returns the directory the local backend stores objects in
+func (s *AppConfig) GetStorageLocalRoot() string {
+	if s.Storage.LocalRoot == "" {
+		return defaultStorageLocalRoot
+	}
+	return s.Storage.LocalRoot
+}
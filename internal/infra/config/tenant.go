@@ -0,0 +1,22 @@
+package config
+
+const (
+	defaultTenantID     = "default"
+	defaultTenantHeader = "X-Tenant-ID"
+)
+
+// GetDefaultTenant returns the tenant used when a request does not name one
+func (s *AppConfig) GetDefaultTenant() string {
+	if s.Tenant.Default == "" {
+		return defaultTenantID
+	}
+	return s.Tenant.Default
+}
+
+// GetTenantHeader returns the request header that carries the tenant identifier
+func (s *AppConfig) GetTenantHeader() string {
+	if s.Tenant.Header == "" {
+		return defaultTenantHeader
+	}
+	return s.Tenant.Header
+}
@@ -0,0 +1,56 @@
+package config
+
+const (
+	ThumbnailJPEG = "jpeg"
+	ThumbnailPNG  = "png"
+	ThumbnailWebP = "webp"
+
+	defaultThumbnailQuality   = 85
+	defaultThumbnailMaxPixels = 40_000_000
+)
+
+// ThumbnailSize is one derivative of an image, fitted inside Width x Height
+// without being enlarged. Quality only applies to JPEG; WebP is lossless.
+type ThumbnailSize struct {
+	Name    string `yaml:"name"`
+	Width   int    `yaml:"width"`
+	Height  int    `yaml:"height"`
+	Format  string `yaml:"format"`
+	Quality int    `yaml:"quality"`
+}
+
+var defaultThumbnailSizes = []ThumbnailSize{
+	{Name: "small", Width: 256, Height: 256, Format: ThumbnailJPEG},
+	{Name: "preview", Width: 1280, Height: 1280, Format: ThumbnailJPEG},
+}
+
+// GetThumbnailSizes returns the configured sizes with their defaults filled
+// in, or the built-in small and preview sizes when none is configured
+func (s *AppConfig) GetThumbnailSizes() []ThumbnailSize {
+	if len(s.Thumbnails.Sizes) == 0 {
+		return applyThumbnailDefaults(defaultThumbnailSizes)
+	}
+	return applyThumbnailDefaults(s.Thumbnails.Sizes)
+}
+
+// GetThumbnailMaxPixels returns the largest image, in pixels, thumbnails are made of
+func (s *AppConfig) GetThumbnailMaxPixels() int {
+	if s.Thumbnails.MaxPixels <= 0 {
+		return defaultThumbnailMaxPixels
+	}
+	return s.Thumbnails.MaxPixels
+}
+
+func applyThumbnailDefaults(sizes []ThumbnailSize) []ThumbnailSize {
+	applied := make([]ThumbnailSize, len(sizes))
+	for i, size := range sizes {
+		if size.Format == "" {
+			size.Format = ThumbnailJPEG
+		}
+		if size.Quality <= 0 || size.Quality > 100 {
+			size.Quality = defaultThumbnailQuality
+		}
+		applied[i] = size
+	}
+	return applied
+}
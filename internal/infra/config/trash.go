@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+const (
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeSchedule = "0 4 * * *"
+)
+
+// GetTrashRetention returns how long deleted files stay restorable
+func (s *AppConfig) GetTrashRetention() time.Duration {
+	if s.Trash.RetentionDays <= 0 {
+		return defaultTrashRetention
+	}
+	return time.Duration(s.Trash.RetentionDays) * 24 * time.Hour
+}
+
+// GetTrashPurgeSchedule returns the cron spec of the trash purge job
+func (s *AppConfig) GetTrashPurgeSchedule() string {
+	if s.Trash.PurgeSchedule == "" {
+		return defaultTrashPurgeSchedule
+	}
+	return s.Trash.PurgeSchedule
+}
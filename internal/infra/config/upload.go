@@ -0,0 +1,63 @@
+package config
+
+// defaultUploadDeny keeps native executables out when no deny list is set
+var defaultUploadDeny = []string{
+	"application/x-msdownload",
+	"application/x-executable",
+	"application/x-mach-binary",
+	"application/x-sh",
+}
+
+// UploadRule limits what may be uploaded. Types are MIME types or prefixes
+// such as "image/". Unset fields leave the decision to the rule underneath.
+type UploadRule struct {
+	// Allow lists the only types accepted; empty accepts every type not denied
+	Allow []string `yaml:"allow" env:"ALLOW" envSeparator:","`
+	// Deny is checked against both the named and the detected type; unset
+	// it falls back to defaultUploadDeny
+	Deny []string `yaml:"deny" env:"DENY" envSeparator:","`
+	// MaxSize caps every upload in bytes and TypeMaxSize the types it names,
+	// the longest matching type winning; neither can go past the storage limit
+	MaxSize     int64            `yaml:"max_size" env:"MAX_SIZE"`
+	TypeMaxSize map[string]int64 `yaml:"type_max_size" env:"TYPE_MAX_SIZE" envSeparator:"," envKeyValSeparator:":"`
+}
+
+// overlay lays other over r: its lists and size replace r's when set, and
+// its per-type sizes are added to r's
+func (r UploadRule) overlay(other UploadRule) UploadRule {
+	if len(other.Allow) > 0 {
+		r.Allow = other.Allow
+	}
+	if len(other.Deny) > 0 {
+		r.Deny = other.Deny
+	}
+	if other.MaxSize > 0 {
+		r.MaxSize = other.MaxSize
+	}
+	if len(other.TypeMaxSize) > 0 {
+		sizes := make(map[string]int64, len(r.TypeMaxSize)+len(other.TypeMaxSize))
+		for contentType, size := range r.TypeMaxSize {
+			sizes[contentType] = size
+		}
+		for contentType, size := range other.TypeMaxSize {
+			sizes[contentType] = size
+		}
+		r.TypeMaxSize = sizes
+	}
+	return r
+}
+
+// GetUploadRule returns the rule for an upload through route by a user of role
+func (s *AppConfig) GetUploadRule(route, role string) UploadRule {
+	rule := s.Upload.Default
+	if rule.Deny == nil {
+		rule.Deny = defaultUploadDeny
+	}
+	if routeRule, ok := s.Upload.Routes[route]; ok {
+		rule = rule.overlay(routeRule)
+	}
+	if roleRule, ok := s.Upload.Roles[role]; ok {
+		rule = rule.overlay(roleRule)
+	}
+	return rule
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+const defaultVersionPruneSchedule = "30 3 * * *"
+
+// GetVersionRetention returns how many earlier versions of a file are kept
+// and for how long; zero disables either rule
+func (s *AppConfig) GetVersionRetention() (int, time.Duration) {
+	return s.Versions.Keep, time.Duration(s.Versions.MaxAgeDays) * 24 * time.Hour
+}
+
+// GetVersionPruneSchedule returns the cron spec of the version pruning job
+func (s *AppConfig) GetVersionPruneSchedule() string {
+	if s.Versions.PruneSchedule == "" {
+		return defaultVersionPruneSchedule
+	}
+	return s.Versions.PruneSchedule
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/port/repository"
+	"project-api/internal/infra/logger"
+
+	"go.uber.org/zap"
+)
+
+// subscriberBuffer is how many events a slow connection may lag behind before
+// new events are dropped for it
+const subscriberBuffer = 16
+
+// Hub fans events out to the subscribers of this process. onFirst and onLast
+// are called when a channel gains its first or loses its last subscriber.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan repository.Event]struct{}
+	onFirst     func(channel string) error
+	onLast      func(channel string)
+}
+
+func NewHub(onFirst func(channel string) error, onLast func(channel string)) *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[chan repository.Event]struct{}),
+		onFirst:     onFirst,
+		onLast:      onLast,
+	}
+}
+
+// Subscribe registers a new subscriber of channel; the returned func unregisters it
+func (h *Hub) Subscribe(channel string) (<-chan repository.Event, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribers, ok := h.subscribers[channel]
+	if !ok {
+		if h.onFirst != nil {
+			if err := h.onFirst(channel); err != nil {
+				return nil, nil, err
+			}
+		}
+		subscribers = make(map[chan repository.Event]struct{})
+		h.subscribers[channel] = subscribers
+	}
+
+	ch := make(chan repository.Event, subscriberBuffer)
+	subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { h.unsubscribe(channel, ch) })
+	}
+	return ch, cancel, nil
+}
+
+func (h *Hub) unsubscribe(channel string, ch chan repository.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribers := h.subscribers[channel]
+	if _, ok := subscribers[ch]; !ok {
+		return // already closed by Close
+	}
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(h.subscribers, channel)
+		if h.onLast != nil {
+			h.onLast(channel)
+		}
+	}
+}
+
+// Dispatch hands event to every subscriber of channel without blocking
+func (h *Hub) Dispatch(channel string, event repository.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[channel] {
+		select {
+		case ch <- event:
+		default:
+			logger.Warn("Dropping event for slow subscriber", zap.String("channel", channel), zap.String("type", event.Type))
+		}
+	}
+}
+
+// Close unregisters every subscriber, closing their channels
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for channel, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(h.subscribers, channel)
+	}
+}
+
+// userChannel names the channel carrying the events of one user of the tenant in ctx
+func userChannel(ctx context.Context, userID uint) (string, error) {
+	tenantID, ok := utils.GetTenantIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("tenant is required to address events")
+	}
+	return fmt.Sprintf("events:%s:%d", tenantID, userID), nil
+}
+
+// newEvent encodes data as the payload of an event
+func newEvent(eventType string, data interface{}) (repository.Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return repository.Event{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return repository.Event{Type: eventType, Data: payload}, nil
+}
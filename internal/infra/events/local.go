@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+
+	"project-api/internal/core/port/repository"
+)
+
+// LocalBus delivers events inside a single process. It is used when Redis is
+// not configured, so events published by the worker don't reach the API.
+type LocalBus struct {
+	hub *Hub
+}
+
+func NewLocalBus() repository.IEventBus {
+	return &LocalBus{hub: NewHub(nil, nil)}
+}
+
+func (b *LocalBus) Publish(ctx context.Context, userID uint, eventType string, data interface{}) error {
+	channel, err := userChannel(ctx, userID)
+	if err != nil {
+		return err
+	}
+	event, err := newEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+	b.hub.Dispatch(channel, event)
+	return nil
+}
+
+func (b *LocalBus) Subscribe(ctx context.Context, userID uint) (<-chan repository.Event, func(), error) {
+	channel, err := userChannel(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.hub.Subscribe(channel)
+}
+
+func (b *LocalBus) Close() error {
+	b.hub.Close()
+	return nil
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"project-api/internal/core/port/repository"
+	"project-api/internal/infra/logger"
+	"project-api/internal/infra/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// RedisBus publishes events through Redis pub/sub so that any process, such as
+// cmd/worker, can reach the API instance holding a user's connection. Each API
+// instance only subscribes to the channels of users connected to it.
+type RedisBus struct {
+	client *redis.RedisClient
+	hub    *Hub
+
+	once   sync.Once
+	pubsub *goredis.PubSub
+}
+
+func NewRedisBus(client *redis.RedisClient) repository.IEventBus {
+	b := &RedisBus{client: client}
+	b.hub = NewHub(b.subscribeChannel, b.unsubscribeChannel)
+	return b
+}
+
+func (b *RedisBus) Publish(ctx context.Context, userID uint, eventType string, data interface{}) error {
+	channel, err := userChannel(ctx, userID)
+	if err != nil {
+		return err
+	}
+	event, err := newEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if err := b.client.Publish(ctx, channel, payload).Err(); err != nil {
+		logger.Error("Failed to publish event", zap.String("channel", channel), zap.Error(err))
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+func (b *RedisBus) Subscribe(ctx context.Context, userID uint) (<-chan repository.Event, func(), error) {
+	channel, err := userChannel(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.once.Do(b.start)
+	return b.hub.Subscribe(channel)
+}
+
+func (b *RedisBus) Close() error {
+	b.hub.Close()
+	if b.pubsub != nil {
+		return b.pubsub.Close()
+	}
+	return nil
+}
+
+// start opens the shared subscription and forwards its messages to the hub.
+// Publishers that never subscribe, like the worker, never open it.
+func (b *RedisBus) start() {
+	b.pubsub = b.client.Subscribe(context.Background())
+	go func() {
+		for msg := range b.pubsub.Channel() {
+			var event repository.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				logger.Warn("Discarding malformed event", zap.String("channel", msg.Channel), zap.Error(err))
+				continue
+			}
+			b.hub.Dispatch(msg.Channel, event)
+		}
+	}()
+}
+
+func (b *RedisBus) subscribeChannel(channel string) error {
+	if err := b.pubsub.Subscribe(context.Background(), channel); err != nil {
+		logger.Error("Failed to subscribe to event channel", zap.String("channel", channel), zap.Error(err))
+		return fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+	return nil
+}
+
+func (b *RedisBus) unsubscribeChannel(channel string) {
+	if err := b.pubsub.Unsubscribe(context.Background(), channel); err != nil {
+		logger.Warn("Failed to unsubscribe from event channel", zap.String("channel", channel), zap.Error(err))
+	}
+}
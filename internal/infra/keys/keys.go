@@ -0,0 +1,26 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+
+	"project-api/internal/core/port/repository"
+	"project-api/internal/infra/config"
+)
+
+// New returns the key provider picked by configuration, or nil when no keys
+// are configured, which leaves encrypted content unreadable
+func New() (repository.IKeyProvider, error) {
+	switch provider := config.Config.GetEncryptionProvider(); provider {
+	case config.KeyProviderLocal:
+		if config.Config.Encryption.KeyFile == "" {
+			if config.Config.Encryption.Enabled {
+				return nil, errors.New("encryption is enabled but no key file is configured")
+			}
+			return nil, nil
+		}
+		return NewKeyFile(config.Config.Encryption.KeyFile)
+	default:
+		return nil, fmt.Errorf("unknown key provider %q", provider)
+	}
+}
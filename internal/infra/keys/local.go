@@ -0,0 +1,86 @@
+package keys
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"project-api/internal/core/port/repository"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const masterKeySize = 32
+
+// KeyFile derives every key encryption key from one master key kept in a
+// file, so a key ID needs no setup before it is used. Losing the file loses
+// every encrypted file with it.
+type KeyFile struct {
+	master []byte
+}
+
+// NewKeyFile reads the base64 master key at path
+func NewKeyFile(path string) (repository.IKeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	master, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key file is not base64: %w", err)
+	}
+	if len(master) != masterKeySize {
+		return nil, fmt.Errorf("key file holds %d bytes, expected %d", len(master), masterKeySize)
+	}
+	return &KeyFile{master: master}, nil
+}
+
+// aead returns the cipher of the key encryption key named keyID
+func (k *KeyFile) aead(keyID string) (cipher.AEAD, error) {
+	kek := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, k.master, nil, []byte("key-encryption-key:"+keyID)), kek); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WrapKey seals the data key with a random nonce, which leads the result;
+// the key ID is bound in so a wrapped key can't be passed off as another's
+func (k *KeyFile) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	aead, err := k.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(dataKey)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, dataKey, []byte(keyID)), nil
+}
+
+func (k *KeyFile) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, err := k.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, repository.ErrKeyUnwrap
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, repository.ErrKeyUnwrap
+	}
+	return dataKey, nil
+}
@@ -0,0 +1,142 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"project-api/internal/core/port/repository"
+)
+
+func writeKeyFile(t *testing.T, master []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(master)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestKeyFile(t *testing.T) repository.IKeyProvider {
+	t.Helper()
+	master := make([]byte, masterKeySize)
+	if _, err := rand.Read(master); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeyFile(writeKeyFile(t, master))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestWrapRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keys := newTestKeyFile(t)
+	dataKey := bytes.Repeat([]byte{7}, 32)
+
+	wrapped, err := keys.WrapKey(ctx, "tenants/a/users/1", dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(wrapped, dataKey) {
+		t.Fatal("wrapped key holds the data key")
+	}
+	again, err := keys.WrapKey(ctx, "tenants/a/users/1", dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(wrapped, again) {
+		t.Fatal("wrapping twice gave the same result")
+	}
+
+	unwrapped, err := keys.UnwrapKey(ctx, "tenants/a/users/1", wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, dataKey) {
+		t.Fatal("unwrapped key differs")
+	}
+}
+
+func TestUnwrapFails(t *testing.T) {
+	ctx := context.Background()
+	const keyID = "tenants/a/users/1"
+	keys := newTestKeyFile(t)
+	wrapped, err := keys.WrapKey(ctx, keyID, bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sealed under the right key encryption key, but with another key ID
+	// bound in
+	aead, err := keys.(*KeyFile).aead(keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	otherAAD := aead.Seal(bytes.Clone(nonce), nonce, bytes.Repeat([]byte{7}, 32), []byte("tenants/b/users/1"))
+
+	tamper := func(i int) []byte {
+		damaged := bytes.Clone(wrapped)
+		damaged[i] ^= 1
+		return damaged
+	}
+	tests := []struct {
+		name    string
+		keys    repository.IKeyProvider
+		keyID   string
+		wrapped []byte
+	}{
+		{"another key ID", keys, "tenants/a/users/2", wrapped},
+		{"another tenant", keys, "tenants/b/users/1", wrapped},
+		{"another AAD", keys, keyID, otherAAD},
+		{"another master key", newTestKeyFile(t), keyID, wrapped},
+		{"a tampered nonce", keys, keyID, tamper(0)},
+		{"a tampered key", keys, keyID, tamper(len(wrapped) - 20)},
+		{"a tampered tag", keys, keyID, tamper(len(wrapped) - 1)},
+		{"a truncated key", keys, keyID, wrapped[:len(wrapped)-1]},
+		{"less than a nonce", keys, keyID, wrapped[:4]},
+		{"nothing", keys, keyID, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.keys.UnwrapKey(ctx, tt.keyID, tt.wrapped); !errors.Is(err, repository.ErrKeyUnwrap) {
+				t.Fatalf("got %v, want ErrKeyUnwrap", err)
+			}
+		})
+	}
+}
+
+func TestNewKeyFileRejectsBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"a missing file", filepath.Join(dir, "missing.key")},
+		{"not base64", write("text.key", "not a key")},
+		{"a short key", write("short.key", base64.StdEncoding.EncodeToString(make([]byte, 16)))},
+		{"a long key", write("long.key", base64.StdEncoding.EncodeToString(make([]byte, 64)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyFile(tt.path); err == nil {
+				t.Fatal("key file was accepted")
+			}
+		})
+	}
+}
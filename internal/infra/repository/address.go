@@ -20,16 +20,21 @@ func NewAddressRepository(db *gorm.DB) repository.IAddressRepository {
 }
 func (a *AddressRepository) GetById(ctx context.Context, id uint) (*entity.Address, error) {
 	address := &entity.Address{}
-	if err := a.db.WithContext(ctx).Where("id = ?", id).First(&address).Error; err != nil {
+	if err := tenantDB(ctx, a.db).Where("id = ?", id).First(&address).Error; err != nil {
 		return address, err
 	}
 	return address, nil
 
 }
 func (a *AddressRepository) Create(ctx context.Context, entity *entity.Address) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	entity.TenantID = tenantID
 	return a.db.WithContext(ctx).Create(entity).Error
 }
 
 func (a *AddressRepository) Update(ctx context.Context, entity *entity.Address) error {
-	return a.db.WithContext(ctx).Save(entity).Error
+	return saveScoped(ctx, a.db, entity)
 }
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlobRepository struct {
+	db *gorm.DB
+}
+
+func NewBlobRepository(db *gorm.DB) repository.IBlobRepository {
+	return &BlobRepository{
+		db: db,
+	}
+}
+
+func (b *BlobRepository) Create(ctx context.Context, blob *entity.Blob) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	blob.TenantID = tenantID
+	// Returning the row tells a caller that lost the race to create it which
+	// key the blob really has
+	return b.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "hash"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("blobs.ref_count + 1")}),
+	}, clause.Returning{}).Create(blob).Error
+}
+
+func (b *BlobRepository) AddRef(ctx context.Context, hash string) (*entity.Blob, error) {
+	blob := &entity.Blob{}
+	result := tenantDB(ctx, b.db).Model(blob).Clauses(clause.Returning{}).
+		Where("hash = ? AND ref_count > 0", hash).
+		Update("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return blob, nil
+}
+
+func (b *BlobRepository) Release(ctx context.Context, hash string) (*entity.Blob, bool, error) {
+	blob := &entity.Blob{}
+	result := tenantDB(ctx, b.db).Model(blob).Clauses(clause.Returning{}).
+		Where("hash = ? AND ref_count > 0", hash).
+		Update("ref_count", gorm.Expr("ref_count - 1"))
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, gorm.ErrRecordNotFound
+	}
+	if blob.RefCount > 0 {
+		return blob, false, nil
+	}
+
+	// Only one caller wins the delete, and an upload that re-added a
+	// reference in between keeps the blob. An upload that comes after the
+	// delete puts the content under a new key, so deleting this blob's
+	// object can't take the new blob's with it.
+	deleted := tenantDB(ctx, b.db).Where("hash = ? AND ref_count = 0", hash).Delete(&entity.Blob{})
+	if deleted.Error != nil {
+		return nil, false, deleted.Error
+	}
+	return blob, deleted.RowsAffected == 1, nil
+}
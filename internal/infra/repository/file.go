@@ -4,7 +4,9 @@ import (
 	"context"
 	"project-api/internal/core/entity"
 	"project-api/internal/core/port/repository"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -22,25 +24,154 @@ func (f *FileRepository) BeginTransaction(ctx context.Context) *gorm.DB {
 	return f.db.WithContext(ctx).Begin()
 }
 func (f *FileRepository) Create(ctx context.Context, entity *entity.File) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	entity.TenantID = tenantID
 	return f.db.WithContext(ctx).Create(entity).Error
 }
 
 func (f *FileRepository) GetById(ctx context.Context, id uint) (*entity.File, error) {
 	file := &entity.File{}
-	if err := f.db.WithContext(ctx).Where("id = ?", id).First(file).Error; err != nil {
+	if err := tenantDB(ctx, f.db).Where("id = ?", id).First(file).Error; err != nil {
 		return file, err
 	}
 	return file, nil
 }
 
 func (f *FileRepository) FindByKey(ctx context.Context, key string, file *entity.File) error {
-	return f.db.WithContext(ctx).Where("file_path = ? AND is_deleted = ?", key, false).First(file).Error
+	return tenantDB(ctx, f.db).Where("file_path = ? AND is_deleted = ?", key, false).First(file).Error
+}
+
+func (f *FileRepository) FindByID(ctx context.Context, id string, file *entity.File) error {
+	return tenantDB(ctx, f.db).Where("id = ?", id).First(file).Error
 }
 
 func (f *FileRepository) FindByKeyForUpdate(ctx context.Context, key string, file *entity.File) error {
-	return f.db.WithContext(ctx).Where("id = ? AND is_deleted = ?", key, false).Clauses(clause.Locking{Strength: "UPDATE"}).First(file).Error
+	return tenantDB(ctx, f.db).Where("id = ? AND is_deleted = ?", key, false).Clauses(clause.Locking{Strength: "UPDATE"}).First(file).Error
 }
 
 func (f *FileRepository) Update(ctx context.Context, file *entity.File) error {
-	return f.db.WithContext(ctx).Save(file).Error
+	return saveScoped(ctx, f.db, file)
+}
+
+func (f *FileRepository) UpdateColumns(ctx context.Context, file *entity.File, columns ...string) error {
+	return updateColumns(ctx, f.db, file, columns...)
+}
+
+func (f *FileRepository) Restore(ctx context.Context, id string) (*entity.File, error) {
+	file := &entity.File{}
+	result := tenantDB(ctx, f.db).Model(file).Clauses(clause.Returning{}).
+		Where("id = ? AND is_deleted = ? AND trashed_at IS NOT NULL", id, true).
+		Updates(map[string]interface{}{
+			"is_deleted": false,
+			"trashed_at": nil,
+			"folder_id": gorm.Expr(`CASE WHEN EXISTS (
+				SELECT 1 FROM folders WHERE folders.id = files.folder_id AND NOT folders.is_deleted
+			) THEN folder_id END`),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return file, nil
+}
+
+func (f *FileRepository) PurgeTrashed(ctx context.Context, before time.Time, limit int) ([]entity.File, error) {
+	return f.purge(ctx, limit, "trashed_at", "is_deleted = ? AND trashed_at < ?", true, before)
+}
+
+func (f *FileRepository) PurgeExpired(ctx context.Context, now time.Time, limit int) ([]entity.File, error) {
+	return f.purge(ctx, limit, "expires_at", "expires_at <= ?", now)
+}
+
+func (f *FileRepository) ClaimUnscanned(ctx context.Context, staleBefore time.Time, limit int) ([]entity.File, error) {
+	var files []entity.File
+	err := f.db.WithContext(ctx).Raw(`UPDATE files SET scan_status = ?, scanned_at = ?
+		WHERE id IN (
+			SELECT id FROM files
+			WHERE deleted_at IS NULL AND (NOT is_deleted OR trashed_at IS NOT NULL)
+				AND (scan_status = ? OR scan_status = ? AND scanned_at < ?)
+			ORDER BY uploaded_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		entity.FileScanScanning, time.Now(),
+		entity.FileScanPending, entity.FileScanScanning, staleBefore,
+		limit).Scan(&files).Error
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (f *FileRepository) FinishScan(ctx context.Context, file *entity.File, status, result string) error {
+	updates := map[string]interface{}{
+		"scan_status": status,
+		"scan_result": result,
+		"scanned_at":  time.Now(),
+	}
+	if status == entity.FileScanInfected {
+		updates["is_deleted"] = true
+		updates["trashed_at"] = nil
+	}
+
+	query := f.db.WithContext(ctx).Model(&entity.File{}).
+		Where("id = ? AND file_path = ? AND scan_status = ?", file.ID, file.FilePath, entity.FileScanScanning).
+		Updates(updates)
+	if query.Error != nil {
+		return query.Error
+	}
+	if query.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (f *FileRepository) SetThumbnails(ctx context.Context, id uuid.UUID, version int, thumbs []entity.Thumbnail) error {
+	result := tenantDB(ctx, f.db).Model(&entity.File{}).
+		Where("id = ? AND version = ? AND is_deleted = ?", id, version, false).
+		Select("thumbnails").
+		Updates(&entity.File{Thumbnails: thumbs})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (f *FileRepository) SetTags(ctx context.Context, id uuid.UUID, tags []string) error {
+	return updateColumns(ctx, f.db, &entity.File{ID: id, Tags: tags}, "tags")
+}
+
+// purge hard-deletes up to limit files of any tenant matching the condition,
+// oldest by orderBy first, together with their grants
+func (f *FileRepository) purge(ctx context.Context, limit int, orderBy string, query string, args ...interface{}) ([]entity.File, error) {
+	var files []entity.File
+	err := f.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Clauses(clause.Returning{}).
+			Where("id IN (?)", tx.Model(&entity.File{}).Unscoped().Select("id").
+				Where(query, args...).
+				Order(orderBy).Limit(limit)).
+			Delete(&files).Error
+		if err != nil || len(files) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(files))
+		for i, file := range files {
+			ids[i] = file.ID
+		}
+		return tx.Where("file_id IN ?", ids).Delete(&entity.FilePermission{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
 }
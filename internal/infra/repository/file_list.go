@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"github.com/google/uuid"
+)
+
+// fileSortColumns maps the sort names of the API to columns
+var fileSortColumns = map[string]string{
+	repository.FileSortCreatedAt: "created_at",
+	repository.FileSortName:      "file_name",
+	repository.FileSortSize:      "file_size",
+}
+
+// fileListCursor is the position of the last file of a page: its value of
+// the sort column and its id, which breaks ties
+type fileListCursor struct {
+	Value json.RawMessage `json:"v"`
+	ID    string          `json:"i"`
+}
+
+// List pages through the files of a user, or shared with them, with keyset pagination on the sort
+// column, so deep pages cost as much as the first one
+func (f *FileRepository) List(ctx context.Context, query repository.FileListQuery) (*repository.FileListResult, error) {
+	column, ok := fileSortColumns[query.Sort]
+	if !ok {
+		return nil, repository.ErrInvalidSort
+	}
+
+	db := tenantDB(ctx, f.db).Where("is_deleted = ? AND (expires_at IS NULL OR expires_at > ?)", query.Trashed, time.Now())
+	if query.Trashed {
+		db = db.Where("trashed_at IS NOT NULL")
+	}
+	if query.SharedWith {
+		db = db.Where(`user_id <> ? AND EXISTS (
+			SELECT 1 FROM file_permissions p
+			WHERE p.file_id = files.id AND (p.grantee_type = ? OR (p.grantee_type = ? AND p.grantee_id = ?)))`,
+			query.UserID, entity.GranteeOrg, entity.GranteeUser, query.UserID)
+	} else {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.Folder != nil {
+		if *query.Folder == uuid.Nil {
+			db = db.Where("folder_id IS NULL")
+		} else {
+			db = db.Where("folder_id = ?", *query.Folder)
+		}
+	}
+	if query.Name != "" {
+		db = db.Where("file_name ILIKE ?", "%"+escapeLike(query.Name)+"%")
+	}
+	if strings.HasSuffix(query.Type, "/") {
+		db = db.Where("file_type LIKE ?", escapeLike(query.Type)+"%")
+	} else if query.Type != "" {
+		db = db.Where("file_type = ?", query.Type)
+	}
+	if query.Tag != "" {
+		tag, _ := json.Marshal([]string{query.Tag})
+		db = db.Where("tags @> ?::jsonb", string(tag))
+	}
+	if query.MinSize > 0 {
+		db = db.Where("file_size >= ?", query.MinSize)
+	}
+	if query.MaxSize > 0 {
+		db = db.Where("file_size <= ?", query.MaxSize)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("created_at < ?", query.To)
+	}
+
+	direction, compare := "ASC", ">"
+	if query.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if query.Cursor != "" {
+		value, id, err := decodeFileListCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("("+column+", id) "+compare+" (?, ?)", value, id)
+	}
+
+	var files []entity.File
+	if err := db.Order(column + " " + direction + ", id " + direction).Limit(query.Limit + 1).Find(&files).Error; err != nil {
+		return nil, err
+	}
+
+	result := &repository.FileListResult{Files: files}
+	if len(files) > query.Limit {
+		result.Files = files[:query.Limit]
+		result.NextCursor = encodeFileListCursor(result.Files[query.Limit-1], query.Sort)
+	}
+	return result, nil
+}
+
+func encodeFileListCursor(file entity.File, sort string) string {
+	var value interface{}
+	switch sort {
+	case repository.FileSortName:
+		value = file.FileName
+	case repository.FileSortSize:
+		value = file.FileSize
+	default:
+		value = file.CreatedAt
+	}
+	raw, _ := json.Marshal(value)
+	data, _ := json.Marshal(fileListCursor{Value: raw, ID: file.ID.String()})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeFileListCursor reads the position as the type of the sort column, so
+// a cursor from another sort is rejected
+func decodeFileListCursor(value, sort string) (interface{}, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, "", repository.ErrInvalidCursor
+	}
+	cursor := &fileListCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, "", repository.ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return nil, "", repository.ErrInvalidCursor
+	}
+
+	var position interface{}
+	switch sort {
+	case repository.FileSortName:
+		var name string
+		err = json.Unmarshal(cursor.Value, &name)
+		position = name
+	case repository.FileSortSize:
+		var size int64
+		err = json.Unmarshal(cursor.Value, &size)
+		position = size
+	default:
+		var createdAt time.Time
+		err = json.Unmarshal(cursor.Value, &createdAt)
+		position = createdAt
+	}
+	if err != nil {
+		return nil, "", repository.ErrInvalidCursor
+	}
+	return position, cursor.ID, nil
+}
+
+// escapeLike makes a user-supplied string match literally inside a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FilePermissionRepository struct {
+	db *gorm.DB
+}
+
+func NewFilePermissionRepository(db *gorm.DB) repository.IFilePermissionRepository {
+	return &FilePermissionRepository{
+		db: db,
+	}
+}
+
+func (p *FilePermissionRepository) Grant(ctx context.Context, permission *entity.FilePermission) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	permission.TenantID = tenantID
+	return p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_id"}, {Name: "grantee_type"}, {Name: "grantee_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"access", "granted_by", "updated_at"}),
+	}).Create(permission).Error
+}
+
+func (p *FilePermissionRepository) ListByFile(ctx context.Context, fileID string) ([]entity.FilePermission, error) {
+	var permissions []entity.FilePermission
+	if err := tenantDB(ctx, p.db).Where("file_id = ?", fileID).Order("id").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (p *FilePermissionRepository) Revoke(ctx context.Context, fileID string, id uint) error {
+	result := tenantDB(ctx, p.db).Where("id = ? AND file_id = ?", id, fileID).Delete(&entity.FilePermission{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *FilePermissionRepository) AccessFor(ctx context.Context, fileID string, userID uint) (string, error) {
+	var access []string
+	err := tenantDB(ctx, p.db).Model(&entity.FilePermission{}).
+		Where("file_id = ?", fileID).
+		Where("grantee_type = ? OR (grantee_type = ? AND grantee_id = ?)", entity.GranteeOrg, entity.GranteeUser, userID).
+		Pluck("access", &access).Error
+	if err != nil {
+		return "", err
+	}
+
+	widest := ""
+	for _, granted := range access {
+		if granted == entity.FileAccessWrite {
+			return granted, nil
+		}
+		widest = granted
+	}
+	return widest, nil
+}
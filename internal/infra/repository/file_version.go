@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FileVersionRepository struct {
+	db *gorm.DB
+}
+
+func NewFileVersionRepository(db *gorm.DB) repository.IFileVersionRepository {
+	return &FileVersionRepository{
+		db: db,
+	}
+}
+
+func (v *FileVersionRepository) Push(ctx context.Context, fileID uuid.UUID, next *entity.FileVersion, scanStatus string) (*entity.File, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var file entity.File
+	err = v.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ? AND is_deleted = ?", fileID, tenantID, false).
+			First(&file).Error
+		if err != nil {
+			return err
+		}
+		// Its verdict would otherwise land on the file's new content
+		if file.Quarantined() {
+			return repository.ErrContentUnscanned
+		}
+
+		previous := &entity.FileVersion{
+			TenantID:    tenantID,
+			FileID:      file.ID,
+			Version:     file.Version,
+			FilePath:    file.FilePath,
+			UrlPath:     file.UrlPath,
+			FileType:    file.FileType,
+			FileSize:    file.FileSize,
+			ContentHash: file.ContentHash,
+			Encryption:  file.Encryption,
+			CreatedAt:   file.UploadedAt,
+		}
+		if err := tx.Create(previous).Error; err != nil {
+			return err
+		}
+
+		file.Version++
+		file.FilePath = next.FilePath
+		file.UrlPath = next.UrlPath
+		file.FileType = next.FileType
+		file.FileSize = next.FileSize
+		file.ContentHash = next.ContentHash
+		file.Encryption = next.Encryption
+		file.UploadedAt = time.Now()
+		file.ScanStatus = scanStatus
+		file.ScanResult = ""
+		file.ScannedAt = nil
+		file.Thumbnails = []entity.Thumbnail{}
+		return tx.Model(&file).
+			Select("version", "file_path", "url_path", "file_type", "file_size", "content_hash", "encryption", "uploaded_at", "scan_status", "scan_result", "scanned_at", "thumbnails").
+			Updates(&file).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (v *FileVersionRepository) List(ctx context.Context, fileID string) ([]entity.FileVersion, error) {
+	var versions []entity.FileVersion
+	if err := tenantDB(ctx, v.db).Where("file_id = ?", fileID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (v *FileVersionRepository) Find(ctx context.Context, fileID string, version int) (*entity.FileVersion, error) {
+	found := &entity.FileVersion{}
+	if err := tenantDB(ctx, v.db).Where("file_id = ? AND version = ?", fileID, version).First(found).Error; err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (v *FileVersionRepository) DeleteByFile(ctx context.Context, fileID uuid.UUID) ([]entity.FileVersion, error) {
+	var versions []entity.FileVersion
+	err := tenantDB(ctx, v.db).Clauses(clause.Returning{}).Where("file_id = ?", fileID).Delete(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (v *FileVersionRepository) Prune(ctx context.Context, keep int, olderThan time.Time) ([]repository.PrunedVersion, error) {
+	var rules []string
+	var args []interface{}
+	if keep > 0 {
+		rules = append(rules, "r.position > ?")
+		args = append(args, keep)
+	}
+	if !olderThan.IsZero() {
+		rules = append(rules, "v.created_at < ?")
+		args = append(args, olderThan)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var pruned []repository.PrunedVersion
+	err := v.db.WithContext(ctx).Raw(`WITH ranked AS (
+			SELECT id, row_number() OVER (PARTITION BY file_id ORDER BY version DESC) AS position
+			FROM file_versions
+		)
+		DELETE FROM file_versions v
+		USING ranked r, files f
+		WHERE r.id = v.id AND f.id = v.file_id AND (`+strings.Join(rules, " OR ")+`)
+		RETURNING v.*, f.user_id AS owner_id`, args...).Scan(&pruned).Error
+	if err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
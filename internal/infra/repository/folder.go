@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxFolderDepth stops walks up the tree should two concurrent moves ever
+// leave a cycle behind
+const maxFolderDepth = 1000
+
+type FolderRepository struct {
+	db *gorm.DB
+}
+
+func NewFolderRepository(db *gorm.DB) repository.IFolderRepository {
+	return &FolderRepository{
+		db: db,
+	}
+}
+
+func (f *FolderRepository) Create(ctx context.Context, folder *entity.Folder) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	folder.TenantID = tenantID
+	return f.db.WithContext(ctx).Create(folder).Error
+}
+
+func (f *FolderRepository) Update(ctx context.Context, folder *entity.Folder) error {
+	return saveScoped(ctx, f.db, folder)
+}
+
+func (f *FolderRepository) UpdateColumns(ctx context.Context, folder *entity.Folder, columns ...string) error {
+	return updateColumns(ctx, f.db, folder, columns...)
+}
+
+func (f *FolderRepository) FindByID(ctx context.Context, id string) (*entity.Folder, error) {
+	folder := &entity.Folder{}
+	if err := tenantDB(ctx, f.db).Where("id = ? AND is_deleted = ?", id, false).First(folder).Error; err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+func (f *FolderRepository) FindChild(ctx context.Context, userID uint, parentID *uuid.UUID, name string) (*entity.Folder, error) {
+	folder := &entity.Folder{}
+	err := f.children(ctx, userID, parentID).Where("lower(name) = lower(?)", name).First(folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+func (f *FolderRepository) ListChildren(ctx context.Context, userID uint, parentID *uuid.UUID) ([]entity.Folder, error) {
+	var folders []entity.Folder
+	if err := f.children(ctx, userID, parentID).Order("lower(name)").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// children selects the live folders directly under parentID
+func (f *FolderRepository) children(ctx context.Context, userID uint, parentID *uuid.UUID) *gorm.DB {
+	db := tenantDB(ctx, f.db).Where("user_id = ? AND is_deleted = ?", userID, false)
+	if parentID == nil {
+		return db.Where("parent_id IS NULL")
+	}
+	return db.Where("parent_id = ?", *parentID)
+}
+
+func (f *FolderRepository) Ancestors(ctx context.Context, id uuid.UUID) ([]entity.Folder, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []entity.Folder
+	err = f.db.WithContext(ctx).Raw(`WITH RECURSIVE chain AS (
+			SELECT folders.*, 0 AS depth FROM folders WHERE id = ? AND tenant_id = ?
+			UNION ALL
+			SELECT folders.*, chain.depth + 1 FROM folders JOIN chain ON folders.id = chain.parent_id
+			WHERE folders.tenant_id = ? AND chain.depth < ?
+		)
+		SELECT id, tenant_id, user_id, parent_id, name, is_deleted, created_at, updated_at
+		FROM chain ORDER BY depth DESC`, id, tenantID, tenantID, maxFolderDepth).Scan(&folders).Error
+	if err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+func (f *FolderRepository) DeleteTree(ctx context.Context, id uuid.UUID) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return f.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		err := tx.Raw(`WITH RECURSIVE tree AS (
+				SELECT id FROM folders WHERE id = ? AND tenant_id = ? AND NOT is_deleted
+				UNION ALL
+				SELECT folders.id FROM folders JOIN tree ON folders.parent_id = tree.id
+				WHERE folders.tenant_id = ? AND NOT folders.is_deleted
+			)
+			SELECT id FROM tree`, id, tenantID, tenantID).Scan(&ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		now := time.Now()
+		err = tx.Model(&entity.Folder{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"is_deleted": true, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.File{}).
+			Where("tenant_id = ? AND folder_id IN ? AND is_deleted = ?", tenantID, ids, false).
+			Updates(map[string]interface{}{"is_deleted": true, "trashed_at": now}).Error
+	})
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+)
+
+type ImportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) repository.IImportJobRepository {
+	return &ImportJobRepository{
+		db: db,
+	}
+}
+
+func (i *ImportJobRepository) Create(ctx context.Context, job *entity.ImportJob) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	job.TenantID = tenantID
+	return i.db.WithContext(ctx).Create(job).Error
+}
+
+func (i *ImportJobRepository) GetById(ctx context.Context, id uint) (*entity.ImportJob, error) {
+	job := &entity.ImportJob{}
+	if err := tenantDB(ctx, i.db).Where("id = ?", id).First(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (i *ImportJobRepository) Update(ctx context.Context, job *entity.ImportJob) error {
+	return saveScoped(ctx, i.db, job)
+}
+
+func (i *ImportJobRepository) Transition(ctx context.Context, id uint, from, to string) error {
+	result := tenantDB(ctx, i.db).Model(&entity.ImportJob{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+
+	"gorm.io/gorm"
+)
+
+func TestImportJobTransitionClaimsOnce(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&entity.ImportJob{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := utils.WithTenantID(context.Background(), "a")
+	repo := NewImportJobRepository(db)
+	job := &entity.ImportJob{CreatedBy: 1, Status: entity.ImportStatusPending, Format: entity.ImportFormatCSV}
+	if err := repo.Create(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Transition(ctx, job.ID, entity.ImportStatusPending, entity.ImportStatusProcessing); err != nil {
+		t.Fatalf("pending job was not claimed: %v", err)
+	}
+	err := repo.Transition(ctx, job.ID, entity.ImportStatusPending, entity.ImportStatusProcessing)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("claimed job was claimed again: %v", err)
+	}
+	err = repo.Transition(utils.WithTenantID(context.Background(), "b"), job.ID, entity.ImportStatusProcessing, entity.ImportStatusCompleted)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("job moved from another tenant: %v", err)
+	}
+}
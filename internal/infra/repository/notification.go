@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) repository.INotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+func (n *NotificationRepository) Create(ctx context.Context, notification *entity.Notification) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	notification.TenantID = tenantID
+	return n.db.WithContext(ctx).Create(notification).Error
+}
+
+func (n *NotificationRepository) GetById(ctx context.Context, id uint) (*entity.Notification, error) {
+	notification := &entity.Notification{}
+	if err := tenantDB(ctx, n.db).Where("id = ?", id).First(notification).Error; err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+func (n *NotificationRepository) Update(ctx context.Context, notification *entity.Notification) error {
+	return saveScoped(ctx, n.db, notification)
+}
+
+// ListByUser returns the newest notifications first; beforeID pages through older ones
+func (n *NotificationRepository) ListByUser(ctx context.Context, userID uint, unreadOnly bool, beforeID uint, limit int) ([]entity.Notification, error) {
+	query := tenantDB(ctx, n.db).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	var notifications []entity.Notification
+	if err := query.Order("id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (n *NotificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := tenantDB(ctx, n.db).Model(&entity.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (n *NotificationRepository) MarkRead(ctx context.Context, userID uint, id uint) error {
+	result := tenantDB(ctx, n.db).Model(&entity.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (n *NotificationRepository) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	result := tenantDB(ctx, n.db).Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+type NotificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) repository.INotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{
+		db: db,
+	}
+}
+
+func (p *NotificationPreferenceRepository) ListByUser(ctx context.Context, userID uint) ([]entity.NotificationPreference, error) {
+	var preferences []entity.NotificationPreference
+	if err := tenantDB(ctx, p.db).Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+// Upsert creates or replaces the preference of (user, category)
+func (p *NotificationPreferenceRepository) Upsert(ctx context.Context, preference *entity.NotificationPreference) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	preference.TenantID = tenantID
+	return p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email", "in_app", "updated_at"}),
+	}).Create(preference).Error
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QuotaRepository struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepository(db *gorm.DB) repository.IQuotaRepository {
+	return &QuotaRepository{
+		db: db,
+	}
+}
+
+func (q *QuotaRepository) CreatePlan(ctx context.Context, plan *entity.QuotaPlan) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	plan.TenantID = tenantID
+	return q.db.WithContext(ctx).Create(plan).Error
+}
+
+func (q *QuotaRepository) GetPlan(ctx context.Context, id uint) (*entity.QuotaPlan, error) {
+	plan := &entity.QuotaPlan{}
+	if err := tenantDB(ctx, q.db).Where("id = ?", id).First(plan).Error; err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (q *QuotaRepository) ListPlans(ctx context.Context) ([]entity.QuotaPlan, error) {
+	var plans []entity.QuotaPlan
+	if err := tenantDB(ctx, q.db).Order("name").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (q *QuotaRepository) AssignPlan(ctx context.Context, userID uint, planID *uint) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	usage := &entity.StorageUsage{TenantID: tenantID, UserID: userID, PlanID: planID}
+	return q.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"plan_id", "updated_at"}),
+	}).Create(usage).Error
+}
+
+func (q *QuotaRepository) GetUsage(ctx context.Context, userID uint) (*entity.StorageUsage, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage := &entity.StorageUsage{}
+	err = tenantDB(ctx, q.db).Preload("Plan").Where("user_id = ?", userID).First(usage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &entity.StorageUsage{TenantID: tenantID, UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// Reserve locks the user and tenant rows, always in the same order, so
+// concurrent uploads of one tenant queue up instead of both passing the check
+func (q *QuotaRepository) Reserve(ctx context.Context, userID uint, bytes, files int64, userDefault entity.QuotaLimits) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows := []entity.StorageUsage{
+			{TenantID: tenantID, UserID: entity.OrgUsageUserID},
+			{TenantID: tenantID, UserID: userID},
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return err
+		}
+
+		var usages []entity.StorageUsage
+		err := tx.Scopes(TenantScope(ctx)).Preload("Plan").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id IN ?", []uint{entity.OrgUsageUserID, userID}).
+			Order("user_id").Find(&usages).Error
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(usages))
+		for i := range usages {
+			usage := &usages[i]
+			if !usage.Limits(userDefault).Allows(usage.Bytes+bytes, usage.Files+files) {
+				return repository.ErrQuotaExceeded
+			}
+			ids = append(ids, usage.ID)
+		}
+
+		return tx.Model(&entity.StorageUsage{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"bytes":      gorm.Expr("bytes + ?", bytes),
+			"files":      gorm.Expr("files + ?", files),
+			"updated_at": time.Now(),
+		}).Error
+	})
+}
+
+func (q *QuotaRepository) Release(ctx context.Context, userID uint, bytes, files int64) error {
+	return tenantDB(ctx, q.db).Model(&entity.StorageUsage{}).
+		Where("user_id IN ?", []uint{entity.OrgUsageUserID, userID}).
+		Updates(map[string]interface{}{
+			"bytes":      gorm.Expr("GREATEST(bytes - ?, 0)", bytes),
+			"files":      gorm.Expr("GREATEST(files - ?, 0)", files),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+func (q *QuotaRepository) Reconcile(ctx context.Context) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return q.reconcile(ctx, tenantID)
+}
+
+func (q *QuotaRepository) ReconcileAll(ctx context.Context) error {
+	return q.reconcile(ctx, "")
+}
+
+// reconcile overwrites the counters with the live and trashed files of
+// tenantID, or of every tenant when it is empty; earlier versions count their
+// bytes but not as files. An upload that reserved its quota but has not saved its row yet
+// is dropped from the count until the next run.
+func (q *QuotaRepository) reconcile(ctx context.Context, tenantID string) error {
+	filesFilter, usageFilter := "", ""
+	var args, stored []interface{}
+	if tenantID != "" {
+		filesFilter, usageFilter = "AND f.tenant_id = ?", "AND u.tenant_id = ?"
+		args = append(args, tenantID)
+		stored = append(stored, tenantID, tenantID)
+	}
+
+	return q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(fmt.Sprintf(`INSERT INTO storage_usages (tenant_id, user_id, bytes, files, updated_at)
+			SELECT tenant_id, COALESCE(user_id, %d), SUM(bytes), SUM(files), now()
+			FROM (
+				SELECT f.tenant_id, f.user_id, f.file_size AS bytes, 1 AS files
+				FROM files f
+				WHERE (NOT f.is_deleted OR f.trashed_at IS NOT NULL) AND f.deleted_at IS NULL %[2]s
+				UNION ALL
+				SELECT f.tenant_id, f.user_id, v.file_size, 0
+				FROM file_versions v JOIN files f ON f.id = v.file_id
+				WHERE (NOT f.is_deleted OR f.trashed_at IS NOT NULL) AND f.deleted_at IS NULL %[2]s
+			) stored
+			GROUP BY GROUPING SETS ((tenant_id, user_id), (tenant_id))
+			ON CONFLICT (tenant_id, user_id) DO UPDATE
+			SET bytes = EXCLUDED.bytes, files = EXCLUDED.files, updated_at = EXCLUDED.updated_at`,
+			entity.OrgUsageUserID, filesFilter), stored...).Error
+		if err != nil {
+			return err
+		}
+
+		// Users and tenants whose last file is gone have no group above
+		return tx.Exec(fmt.Sprintf(`UPDATE storage_usages u SET bytes = 0, files = 0, updated_at = now()
+			WHERE (u.bytes <> 0 OR u.files <> 0) %s
+			AND NOT EXISTS (
+				SELECT 1 FROM files f
+				WHERE f.tenant_id = u.tenant_id AND (u.user_id = %d OR f.user_id = u.user_id)
+				AND (NOT f.is_deleted OR f.trashed_at IS NOT NULL) AND f.deleted_at IS NULL
+			)`, usageFilter, entity.OrgUsageUserID), args...).Error
+	})
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+)
+
+type ShareLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewShareLinkRepository(db *gorm.DB) repository.IShareLinkRepository {
+	return &ShareLinkRepository{
+		db: db,
+	}
+}
+
+func (s *ShareLinkRepository) Create(ctx context.Context, link *entity.ShareLink) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	link.TenantID = tenantID
+	return s.db.WithContext(ctx).Create(link).Error
+}
+
+func (s *ShareLinkRepository) GetById(ctx context.Context, id uint) (*entity.ShareLink, error) {
+	link := &entity.ShareLink{}
+	if err := tenantDB(ctx, s.db).Where("id = ?", id).First(link).Error; err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *ShareLinkRepository) Update(ctx context.Context, link *entity.ShareLink) error {
+	return saveScoped(ctx, s.db, link)
+}
+
+func (s *ShareLinkRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entity.ShareLink, error) {
+	link := &entity.ShareLink{}
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(link).Error; err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *ShareLinkRepository) ListByFile(ctx context.Context, fileID string) ([]entity.ShareLink, error) {
+	var links []entity.ShareLink
+	if err := tenantDB(ctx, s.db).Where("file_id = ?", fileID).Order("id DESC").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (s *ShareLinkRepository) Revoke(ctx context.Context, fileID string, id uint) error {
+	result := tenantDB(ctx, s.db).Model(&entity.ShareLink{}).
+		Where("id = ? AND file_id = ?", id, fileID).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *ShareLinkRepository) ClaimDownload(ctx context.Context, id uint) error {
+	result := tenantDB(ctx, s.db).Model(&entity.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Where("max_downloads = 0 OR download_count < max_downloads").
+		Update("download_count", gorm.Expr("download_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *ShareLinkRepository) LogAccess(ctx context.Context, access *entity.ShareLinkAccess) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	access.TenantID = tenantID
+	return s.db.WithContext(ctx).Create(access).Error
+}
+
+func (s *ShareLinkRepository) ListAccess(ctx context.Context, id uint, limit int) ([]entity.ShareLinkAccess, error) {
+	var accesses []entity.ShareLinkAccess
+	err := tenantDB(ctx, s.db).Where("share_link_id = ?", id).Order("id DESC").Limit(limit).Find(&accesses).Error
+	if err != nil {
+		return nil, err
+	}
+	return accesses, nil
+}
+
+func (s *ShareLinkRepository) CountAccess(ctx context.Context, id uint, outcome string, since time.Time) (int64, error) {
+	var count int64
+	err := tenantDB(ctx, s.db).Model(&entity.ShareLinkAccess{}).
+		Where("share_link_id = ? AND outcome = ? AND created_at >= ?", id, outcome, since).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+)
+
+type StorageRefRepository struct {
+	db *gorm.DB
+}
+
+func NewStorageRefRepository(db *gorm.DB) repository.IStorageRefRepository {
+	return &StorageRefRepository{
+		db: db,
+	}
+}
+
+// EachRef streams the references row by row rather than loading them, since
+// there is one per stored object. Files that still hold content are the ones
+// ClaimUnscanned would scan: not deleted, or deleted into the trash.
+func (r *StorageRefRepository) EachRef(ctx context.Context, fn func(repository.StorageRef) error) error {
+	rows, err := r.db.WithContext(ctx).Raw(`
+		SELECT key, ?::text, tenant_id, hash FROM blobs
+		UNION ALL
+		SELECT file_path, ?::text, tenant_id, id::text FROM files
+		WHERE deleted_at IS NULL AND (NOT is_deleted OR trashed_at IS NOT NULL)
+		UNION ALL
+		SELECT thumb->>'key', ?::text, files.tenant_id, files.id::text
+		FROM files, jsonb_array_elements(files.thumbnails) AS thumb
+		WHERE deleted_at IS NULL AND (NOT is_deleted OR trashed_at IS NOT NULL)
+		UNION ALL
+		SELECT file_path, ?::text, tenant_id, file_id::text || '/' || version FROM file_versions
+		UNION ALL
+		SELECT unnest(ARRAY[key, key || '.part']), ?::text, tenant_id, id::text FROM uploads
+		WHERE deleted_at IS NULL AND status IN ?`,
+		repository.StorageRefBlob,
+		repository.StorageRefFile,
+		repository.StorageRefThumbnail,
+		repository.StorageRefVersion,
+		repository.StorageRefUpload,
+		[]string{entity.UploadStatusPending, entity.UploadStatusVerifying, entity.UploadStatusReceiving},
+	).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ref repository.StorageRef
+		if err := rows.Scan(&ref.Key, &ref.Kind, &ref.TenantID, &ref.ID); err != nil {
+			return err
+		}
+		if err := fn(ref); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
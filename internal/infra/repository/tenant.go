@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"project-api/internal/core/common/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTenantRequired = errors.New("tenant is required for this query")
+
+// TenantScope restricts a query to the tenant carried by ctx. A query issued
+// without a tenant fails instead of silently reading across tenants.
+func TenantScope(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		tenantID, ok := utils.GetTenantIDFromContext(ctx)
+		if !ok {
+			db.AddError(ErrTenantRequired)
+			return db
+		}
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"},
+			Value:  tenantID,
+		})
+	}
+}
+
+// tenantDB returns a session bound to ctx and scoped to its tenant
+func tenantDB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db.WithContext(ctx).Scopes(TenantScope(ctx))
+}
+
+// tenantFromContext returns the tenant in ctx or ErrTenantRequired
+func tenantFromContext(ctx context.Context) (string, error) {
+	tenantID, ok := utils.GetTenantIDFromContext(ctx)
+	if !ok {
+		return "", ErrTenantRequired
+	}
+	return tenantID, nil
+}
+
+// saveScoped updates every column of value inside the tenant of ctx. Unlike
+// gorm's Save it never falls back to an upsert, so a row owned by another
+// tenant can't be overwritten through a guessed primary key.
+func saveScoped(ctx context.Context, db *gorm.DB, value interface{}) error {
+	result := tenantDB(ctx, db).Model(value).Select("*").Omit("tenant_id").Updates(value)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// updateColumns writes only the named columns of value, found by its primary
+// key inside the tenant of ctx, so what others wrote to the rest of the row
+// since it was read is kept
+func updateColumns(ctx context.Context, db *gorm.DB, value interface{}, columns ...string) error {
+	result := tenantDB(ctx, db).Model(value).Select(columns).Updates(value)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// filesSchema is the files table in SQLite's dialect; the Postgres defaults
+// of the entity, gen_random_uuid() among them, don't migrate there
+const filesSchema = `CREATE TABLE files (
+	id TEXT PRIMARY KEY,
+	tenant_id TEXT NOT NULL DEFAULT 'default',
+	user_id INTEGER NOT NULL,
+	file_name TEXT NOT NULL,
+	file_path TEXT NOT NULL,
+	url_path TEXT NOT NULL,
+	file_type TEXT NOT NULL,
+	file_size INTEGER NOT NULL,
+	folder_id TEXT,
+	content_hash TEXT,
+	encryption TEXT,
+	version INTEGER NOT NULL DEFAULT 1,
+	tags TEXT NOT NULL DEFAULT '[]',
+	thumbnails TEXT NOT NULL DEFAULT '[]',
+	uploaded_at DATETIME,
+	is_deleted BOOLEAN DEFAULT false,
+	trashed_at DATETIME,
+	expires_at DATETIME,
+	scan_status TEXT NOT NULL DEFAULT 'clean',
+	scan_result TEXT,
+	scanned_at DATETIME,
+	created_at DATETIME,
+	deleted_at DATETIME
+)`
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(filesSchema).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestFile(name string) *entity.File {
+	return &entity.File{
+		UserID:   1,
+		FileName: name,
+		FilePath: "file/" + name,
+		UrlPath:  "memory://file/" + name,
+		FileType: "text/plain",
+		FileSize: 4,
+		Tags:     []string{},
+	}
+}
+
+func TestFileOfAnotherTenantIsInvisible(t *testing.T) {
+	repo := NewFileRepository(newTestDB(t))
+	tenantA := utils.WithTenantID(context.Background(), "a")
+	tenantB := utils.WithTenantID(context.Background(), "b")
+
+	file := newTestFile("a.txt")
+	if err := repo.Create(tenantA, file); err != nil {
+		t.Fatal(err)
+	}
+
+	var found entity.File
+	if err := repo.FindByID(tenantB, file.ID.String(), &found); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("tenant b read tenant a's file: %v", err)
+	}
+
+	// An update through a known primary key must not reach the row either
+	stolen := *file
+	stolen.FileName = "stolen.txt"
+	if err := repo.Update(tenantB, &stolen); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("tenant b updated tenant a's file: %v", err)
+	}
+	if err := repo.FindByID(tenantA, file.ID.String(), &found); err != nil {
+		t.Fatal(err)
+	}
+	if found.FileName != "a.txt" || found.TenantID != "a" {
+		t.Fatalf("tenant a's file changed: %+v", found)
+	}
+}
+
+func TestCreateStampsTenantFromContext(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFileRepository(db)
+
+	file := newTestFile("a.txt")
+	// Whatever the caller set is replaced by the tenant of the context
+	file.TenantID = "b"
+	if err := repo.Create(utils.WithTenantID(context.Background(), "a"), file); err != nil {
+		t.Fatal(err)
+	}
+
+	var tenantID string
+	if err := db.Raw("SELECT tenant_id FROM files WHERE id = ?", file.ID).Scan(&tenantID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if tenantID != "a" {
+		t.Fatalf("file stored for tenant %q, expected a", tenantID)
+	}
+}
+
+func TestQueriesWithoutTenantFail(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFileRepository(db)
+	file := newTestFile("a.txt")
+	if err := repo.Create(utils.WithTenantID(context.Background(), "a"), file); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := repo.Create(ctx, newTestFile("b.txt")); !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("create without a tenant: %v", err)
+	}
+	var found entity.File
+	if err := repo.FindByID(ctx, file.ID.String(), &found); !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("read without a tenant: %v", err)
+	}
+	if err := repo.Update(ctx, file); !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("update without a tenant: %v", err)
+	}
+
+	var count int64
+	if err := db.Model(&entity.File{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected only the first file to be stored, found %d", count)
+	}
+}
+
+func TestTenantKeyPrefixesTenant(t *testing.T) {
+	key, err := utils.TenantKey(utils.WithTenantID(context.Background(), "a"), "blob/ab/abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "tenants/a/blob/ab/abcd" {
+		t.Fatalf("unexpected key %q", key)
+	}
+	if _, err := utils.TenantKey(context.Background(), "blob/ab/abcd"); err == nil {
+		t.Fatal("a key was built without a tenant")
+	}
+}
+
+func TestSetTagsKeepsConcurrentWrites(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFileRepository(db)
+	ctx := utils.WithTenantID(context.Background(), "a")
+	file := newTestFile("a.txt")
+	if err := repo.Create(ctx, file); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new version lands between reading the file and saving its tags
+	if err := db.Exec("UPDATE files SET version = 2, file_path = 'file/v2', scan_status = 'pending' WHERE id = ?", file.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetTags(ctx, file.ID, []string{"report"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var found entity.File
+	if err := repo.FindByID(ctx, file.ID.String(), &found); err != nil {
+		t.Fatal(err)
+	}
+	if found.Version != 2 || found.FilePath != "file/v2" || found.ScanStatus != "pending" {
+		t.Fatalf("setting tags reverted the new version: %+v", found)
+	}
+	if len(found.Tags) != 1 || found.Tags[0] != "report" {
+		t.Fatalf("tags not saved: %v", found.Tags)
+	}
+	if err := repo.SetTags(utils.WithTenantID(context.Background(), "b"), file.ID, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("tenant b set tenant a's tags: %v", err)
+	}
+}
+
+func TestUpdateColumnsWritesOnlyThoseColumns(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFileRepository(db)
+	ctx := utils.WithTenantID(context.Background(), "a")
+	file := newTestFile("a.txt")
+	if err := repo.Create(ctx, file); err != nil {
+		t.Fatal(err)
+	}
+
+	// A scan verdict lands between reading the file and renaming it
+	if err := db.Exec("UPDATE files SET scan_status = 'infected', is_deleted = true WHERE id = ?", file.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	file.FileName = "b.txt"
+	if err := repo.UpdateColumns(ctx, file, "file_name"); err != nil {
+		t.Fatal(err)
+	}
+
+	var found entity.File
+	if err := repo.FindByID(ctx, file.ID.String(), &found); err != nil {
+		t.Fatal(err)
+	}
+	if found.FileName != "b.txt" {
+		t.Fatalf("file not renamed: %q", found.FileName)
+	}
+	if found.ScanStatus != "infected" || !found.IsDeleted {
+		t.Fatalf("renaming reverted the scan verdict: %+v", found)
+	}
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+
+	"gorm.io/gorm"
+)
+
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) repository.IUploadRepository {
+	return &UploadRepository{
+		db: db,
+	}
+}
+
+func (u *UploadRepository) Create(ctx context.Context, upload *entity.Upload) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	upload.TenantID = tenantID
+	return u.db.WithContext(ctx).Create(upload).Error
+}
+
+func (u *UploadRepository) GetById(ctx context.Context, id uint) (*entity.Upload, error) {
+	upload := &entity.Upload{}
+	if err := tenantDB(ctx, u.db).Where("id = ?", id).First(upload).Error; err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (u *UploadRepository) Update(ctx context.Context, upload *entity.Upload) error {
+	return saveScoped(ctx, u.db, upload)
+}
+
+func (u *UploadRepository) Transition(ctx context.Context, id uint, from, to string) error {
+	result := tenantDB(ctx, u.db).Model(&entity.Upload{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (u *UploadRepository) Claim(ctx context.Context, id uint, to string, staleBefore time.Time) error {
+	// Update also sets updated_at, which starts the new writer's lease
+	result := tenantDB(ctx, u.db).Model(&entity.Upload{}).
+		Where("id = ? AND (status = ? OR status = ? AND updated_at < ?)",
+			id, entity.UploadStatusPending, entity.UploadStatusReceiving, staleBefore).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (u *UploadRepository) TerminateExpired(ctx context.Context, now, staleBefore time.Time, limit int) ([]entity.Upload, error) {
+	var uploads []entity.Upload
+	err := u.db.WithContext(ctx).Raw(`UPDATE uploads SET status = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM uploads
+			WHERE deleted_at IS NULL AND method = ? AND expires_at < ?
+				AND (status = ? OR status = ? AND updated_at < ?)
+			ORDER BY expires_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		entity.UploadStatusTerminated, now,
+		entity.UploadMethodTus, now,
+		entity.UploadStatusPending, entity.UploadStatusReceiving, staleBefore,
+		limit).Scan(&uploads).Error
+	if err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
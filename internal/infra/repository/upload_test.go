@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"project-api/internal/core/common/utils"
+	"project-api/internal/core/entity"
+
+	"gorm.io/gorm"
+)
+
+func newTestUpload(t *testing.T, db *gorm.DB, ctx context.Context, status string) *entity.Upload {
+	t.Helper()
+	if err := db.AutoMigrate(&entity.Upload{}); err != nil {
+		t.Fatal(err)
+	}
+	upload := &entity.Upload{
+		UserID:      1,
+		Method:      entity.UploadMethodTus,
+		Status:      status,
+		FileName:    "a.txt",
+		ContentType: "text/plain",
+		Size:        4,
+		Key:         "upload/a",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	if err := NewUploadRepository(db).Create(ctx, upload); err != nil {
+		t.Fatal(err)
+	}
+	return upload
+}
+
+func TestClaimTakesOverStaleReceiving(t *testing.T) {
+	db := newTestDB(t)
+	ctx := utils.WithTenantID(context.Background(), "a")
+	repo := NewUploadRepository(db)
+	upload := newTestUpload(t, db, ctx, entity.UploadStatusPending)
+
+	if err := repo.Claim(ctx, upload.ID, entity.UploadStatusReceiving, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("pending upload was not claimed: %v", err)
+	}
+	err := repo.Claim(ctx, upload.ID, entity.UploadStatusReceiving, time.Now().Add(-time.Minute))
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("upload claimed by a live writer was claimed again: %v", err)
+	}
+
+	// The writer saved nothing since a moment ago, which a later
+	// staleBefore takes for gone
+	if err := repo.Claim(ctx, upload.ID, entity.UploadStatusTerminated, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("stale upload was not claimed: %v", err)
+	}
+	claimed, err := repo.GetById(ctx, upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed.Status != entity.UploadStatusTerminated {
+		t.Fatalf("status = %q, want %q", claimed.Status, entity.UploadStatusTerminated)
+	}
+}
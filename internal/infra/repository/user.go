@@ -23,12 +23,17 @@ func NewUserRepository(db *gorm.DB) repository.IUserRepository {
 }
 
 func (u *UserRepository) Create(ctx context.Context, entity *entity.User) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	entity.TenantID = tenantID
 	return u.db.WithContext(ctx).Create(entity).Error
 }
 
 func (u *UserRepository) GetById(ctx context.Context, id uint) (*entity.User, error) {
 	user := &entity.User{}
-	if err := u.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
+	if err := tenantDB(ctx, u.db).Where("id = ?", id).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -36,7 +41,7 @@ func (u *UserRepository) GetById(ctx context.Context, id uint) (*entity.User, er
 
 func (u *UserRepository) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
 	user := &entity.User{}
-	if err := u.db.WithContext(ctx).Where("email = ?", strings.ToLower(email)).Or("email = ?", email).First(&user).Error; err != nil {
+	if err := tenantDB(ctx, u.db).Where("email IN ?", []string{strings.ToLower(email), email}).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -44,7 +49,7 @@ func (u *UserRepository) GetUserByEmail(ctx context.Context, email string) (*ent
 
 func (u *UserRepository) GetUserByName(ctx context.Context, name string) (*entity.User, error) {
 	user := &entity.User{}
-	if err := u.db.WithContext(ctx).Where("user_name = ? AND is_active = true", name).First(&user).Error; err != nil {
+	if err := tenantDB(ctx, u.db).Where("user_name = ? AND is_active = true", name).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -52,7 +57,7 @@ func (u *UserRepository) GetUserByName(ctx context.Context, name string) (*entit
 
 func (u *UserRepository) FindByToken(ctx context.Context, token string) (*entity.User, error) {
 	var user entity.User
-	err := u.db.WithContext(ctx).Where("confirm_token = ?", token).First(&user).Error
+	err := tenantDB(ctx, u.db).Where("confirm_token = ?", token).First(&user).Error
 	if err != nil {
 		logger.Error("Failed to find user by token", zap.String("token", token), zap.Error(err))
 		return nil, err
@@ -65,15 +70,23 @@ func (u *UserRepository) FindByToken(ctx context.Context, token string) (*entity
 }
 
 func (u *UserRepository) Update(ctx context.Context, entity *entity.User) error {
-	return u.db.WithContext(ctx).Save(entity).Error
+	return saveScoped(ctx, u.db, entity)
 }
 
 func (u *UserRepository) FindByResetToken(ctx context.Context, token string) (*entity.User, error) {
 	var user entity.User
-	err := u.db.WithContext(ctx).Where("reset_password_token = ?", token).First(&user).Error
+	err := tenantDB(ctx, u.db).Where("reset_password_token = ?", token).First(&user).Error
 	if err != nil {
 		logger.Error("Failed to find user by reset token", zap.String("token", token), zap.Error(err))
 		return nil, err
 	}
 	return &user, nil
 }
+
+// FindInBatches walks every user of the tenant in id order, batchSize rows at a time
+func (u *UserRepository) FindInBatches(ctx context.Context, batchSize int, fn func(users []entity.User) error) error {
+	var users []entity.User
+	return tenantDB(ctx, u.db).Order("id").FindInBatches(&users, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(users)
+	}).Error
+}
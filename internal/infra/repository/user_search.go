@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"project-api/internal/core/entity"
+	"project-api/internal/core/port/repository"
+)
+
+const (
+	// userSearchDocument matches the expression of idx_user_search_fts
+	userSearchDocument = `to_tsvector('simple', user_name || ' ' || first_name || ' ' || last_name || ' ' || email)`
+	// userDirectoryDocument matches the expression of idx_user_directory_fts
+	userDirectoryDocument = `to_tsvector('simple', user_name || ' ' || first_name || ' ' || last_name)`
+	// userFullName matches the expression of idx_user_full_name_trgm
+	userFullName = `(first_name || ' ' || last_name)`
+
+	userSearchScore = `GREATEST(word_similarity(@q, user_name), word_similarity(@q, email), word_similarity(@q, ` + userFullName + `)) * 0.7 + ` +
+		`ts_rank(` + userSearchDocument + `, plainto_tsquery('simple', @q)) * 0.3`
+	userSearchFilter = `(@q <% user_name OR @q <% email OR @q <% ` + userFullName + ` OR ` +
+		userSearchDocument + ` @@ plainto_tsquery('simple', @q))`
+
+	// The directory leaves the email out, so it can't be probed for addresses
+	userDirectoryScore = `GREATEST(word_similarity(@q, user_name), word_similarity(@q, ` + userFullName + `)) * 0.7 + ` +
+		`ts_rank(` + userDirectoryDocument + `, plainto_tsquery('simple', @q)) * 0.3`
+	userDirectoryFilter = `(@q <% user_name OR @q <% ` + userFullName + ` OR ` +
+		userDirectoryDocument + ` @@ plainto_tsquery('simple', @q))`
+)
+
+// userSearchCursor is the position of the last hit of a page in (score DESC, id ASC) order
+type userSearchCursor struct {
+	Score float64 `json:"s"`
+	ID    uint    `json:"i"`
+}
+
+type userSearchRow struct {
+	entity.User
+	Score float64
+}
+
+// Search ranks users of the tenant by trigram word similarity on user name,
+// full name and, when asked, email combined with a full-text match,
+// tolerating typos
+func (u *UserRepository) Search(ctx context.Context, query repository.UserSearchQuery) (*repository.UserSearchResult, error) {
+	args := map[string]interface{}{"q": query.Query}
+
+	score, filter := userDirectoryScore, userDirectoryFilter
+	if query.MatchEmail {
+		score, filter = userSearchScore, userSearchFilter
+	}
+	ranked := tenantDB(ctx, u.db).
+		Model(&entity.User{}).
+		Select(`"user".*, `+score+` AS score`, args).
+		Where(filter, args)
+	if query.ActiveOnly {
+		ranked = ranked.Where("is_active = ?", true)
+	}
+
+	page := u.db.WithContext(ctx).Table("(?) AS ranked", ranked)
+	if query.Cursor != "" {
+		cursor, err := decodeUserSearchCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		page = page.Where("(score < ? OR (score = ? AND id > ?))", cursor.Score, cursor.Score, cursor.ID)
+	}
+
+	var rows []userSearchRow
+	if err := page.Order("score DESC, id ASC").Limit(query.Limit + 1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := &repository.UserSearchResult{}
+	if len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+		last := rows[len(rows)-1]
+		result.NextCursor = encodeUserSearchCursor(userSearchCursor{Score: last.Score, ID: last.ID})
+	}
+	for _, row := range rows {
+		result.Hits = append(result.Hits, repository.UserSearchHit{User: row.User, Score: row.Score})
+	}
+	return result, nil
+}
+
+func encodeUserSearchCursor(cursor userSearchCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUserSearchCursor(value string) (*userSearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, repository.ErrInvalidCursor
+	}
+	cursor := &userSearchCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, repository.ErrInvalidCursor
+	}
+	return cursor, nil
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"project-api/internal/infra/storage/storagetest"
+)
+
+func TestFileSystem(t *testing.T) {
+	repo, err := NewFileSystem(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storagetest.TestStorage(context.Background(), repo); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,458 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-api/internal/core/port/repository"
+
+	"github.com/google/uuid"
+)
+
+// headerSize is the room at the start of every object file for its metadata,
+// written once the content is in so that one rename publishes both
+const headerSize = 4 << 10
+
+// objectHeader is the metadata stored in front of an object's content
+type objectHeader struct {
+	Key            string `json:"key"`
+	ContentType    string `json:"content_type"`
+	ChecksumSHA256 string `json:"checksum_sha256"`
+}
+
+// FileSystem stores objects as files under a root directory. Objects are
+// sharded into directories by the hash of their key, so keys never become
+// paths, and every write goes to a temporary file renamed into place.
+type FileSystem struct {
+	root string
+}
+
+// NewFileSystem returns a backend storing objects under root, creating it
+// if needed
+func NewFileSystem(root string) (repository.IS3Repository, error) {
+	for _, dir := range []string{"objects", "uploads", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %v", err)
+		}
+	}
+	return &FileSystem{root: root}, nil
+}
+
+// objectPath is objects/ab/cd/abcd… where abcd… is the SHA-256 of the key
+func (s *FileSystem) objectPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(s.root, "objects", name[:2], name[2:4], name)
+}
+
+func (s *FileSystem) UploadFile(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if err := s.writeObject(ctx, key, contentType, body, size); err != nil {
+		return "", err
+	}
+	return s.FileURL(key), nil
+}
+
+// writeObject streams body to a temporary file behind an empty header, fills
+// the header in and renames the file over the object
+func (s *FileSystem) writeObject(ctx context.Context, key, contentType string, body io.Reader, size int64) error {
+	tmp, err := os.CreateTemp(filepath.Join(s.root, "tmp"), "object-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Seek(headerSize, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to write object: %v", err)
+	}
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), contextReader{ctx: ctx, r: body})
+	if err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("body is %d bytes, expected %d", written, size)
+	}
+
+	header, err := encodeHeader(objectHeader{
+		Key:            key,
+		ContentType:    contentType,
+		ChecksumSHA256: base64.StdEncoding.EncodeToString(hash.Sum(nil)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteAt(header, 0); err != nil {
+		return fmt.Errorf("failed to write object: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to write object: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %v", err)
+	}
+
+	path := s.objectPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write object: %v", err)
+	}
+	committed = true
+	return nil
+}
+
+// openObject opens an object positioned at the start of its content
+func (s *FileSystem) openObject(key string) (*os.File, *repository.ObjectInfo, error) {
+	f, err := os.Open(s.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, repository.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open object: %v", err)
+	}
+	info, err := readHeader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.Key != key {
+		// Two keys with the same hash; not going to happen with SHA-256,
+		// but a wrong object must never be served
+		f.Close()
+		return nil, nil, repository.ErrObjectNotFound
+	}
+	return f, info, nil
+}
+
+// readHeader reads the header of an object file and describes the object
+func readHeader(f *os.File) (*repository.ObjectInfo, error) {
+	buf := make([]byte, headerSize)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return nil, fmt.Errorf("failed to read object header: %v", err)
+	}
+	var header objectHeader
+	if err := json.Unmarshal(bytes.TrimRight(buf, " "), &header); err != nil {
+		return nil, fmt.Errorf("failed to read object header: %v", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %v", err)
+	}
+	return &repository.ObjectInfo{
+		Key:            header.Key,
+		Size:           stat.Size() - headerSize,
+		ContentType:    header.ContentType,
+		ETag:           etag(header.ChecksumSHA256),
+		ChecksumSHA256: header.ChecksumSHA256,
+		LastModified:   stat.ModTime().UTC(),
+	}, nil
+}
+
+func encodeHeader(header objectHeader) ([]byte, error) {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode object header: %v", err)
+	}
+	if len(data) > headerSize {
+		return nil, fmt.Errorf("object metadata is over %d bytes", headerSize)
+	}
+	return append(data, bytes.Repeat([]byte(" "), headerSize-len(data))...), nil
+}
+
+func (s *FileSystem) DownloadFile(ctx context.Context, key string) (io.ReadCloser, *repository.ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if err := validateKey(key); err != nil {
+		return nil, nil, err
+	}
+	return s.openObject(key)
+}
+
+func (s *FileSystem) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	f, info, err := s.openObject(key)
+	if err != nil {
+		return nil, err
+	}
+	length, err = clampRange(offset, length, info.Size)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return readCloser{Reader: io.NewSectionReader(f, headerSize+offset, length), Closer: f}, nil
+}
+
+func (s *FileSystem) DeleteFile(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	// Deleting a missing object succeeds, as it does on S3
+	if err := os.Remove(s.objectPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %v", err)
+	}
+	return nil
+}
+
+func (s *FileSystem) CopyFile(ctx context.Context, srcKey, dstKey string) error {
+	if err := validateKey(dstKey); err != nil {
+		return err
+	}
+	body, info, err := s.DownloadFile(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return s.writeObject(ctx, dstKey, info.ContentType, body, info.Size)
+}
+
+func (s *FileSystem) StatFile(ctx context.Context, key string) (*repository.ObjectInfo, error) {
+	body, info, err := s.DownloadFile(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	body.Close()
+	return info, nil
+}
+
+// FileURL points at the object file; it is only meaningful on this host
+func (s *FileSystem) FileURL(key string) string {
+	path, err := filepath.Abs(s.objectPath(key))
+	if err != nil {
+		path = s.objectPath(key)
+	}
+	return "file://" + filepath.ToSlash(path)
+}
+
+// ListObjects walks the object directories and reads every header, since
+// keys are only stored there
+func (s *FileSystem) ListObjects(ctx context.Context, prefix string, fn func(repository.ObjectInfo) error) error {
+	return filepath.WalkDir(filepath.Join(s.root, "objects"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to list objects: %v", err)
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Deleted since the directory was read
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open object: %v", err)
+		}
+		info, err := readHeader(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(info.Key, prefix) {
+			return nil
+		}
+		return fn(*info)
+	})
+}
+
+func (s *FileSystem) PresignPut(ctx context.Context, input repository.PresignUploadInput) (*repository.PresignedRequest, error) {
+	return nil, repository.ErrPresignUnsupported
+}
+
+func (s *FileSystem) PresignPost(ctx context.Context, input repository.PresignUploadInput) (*repository.PresignedRequest, error) {
+	return nil, repository.ErrPresignUnsupported
+}
+
+func (s *FileSystem) PresignGet(ctx context.Context, key, fileName string, ttl time.Duration) (*repository.PresignedRequest, error) {
+	return nil, repository.ErrPresignUnsupported
+}
+
+// uploadHeader is what a multipart upload remembers about its object
+type uploadHeader struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+}
+
+// uploadDir holds the parts of a multipart upload, one file per part number,
+// next to a file describing the object they make up
+func (s *FileSystem) uploadDir(uploadID string) (string, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", ErrUploadNotFound
+	}
+	return filepath.Join(s.root, "uploads", uploadID), nil
+}
+
+func (s *FileSystem) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	uploadID := uuid.NewString()
+	dir, _ := s.uploadDir(uploadID)
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	data, err := json.Marshal(uploadHeader{Key: key, ContentType: contentType})
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "upload.json"), data, 0o644)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	return uploadID, nil
+}
+
+// loadUpload reads an upload's description and checks it is for key
+func (s *FileSystem) loadUpload(key, uploadID string) (string, *uploadHeader, error) {
+	dir, err := s.uploadDir(uploadID)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "upload.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read multipart upload: %v", err)
+	}
+	var header uploadHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return "", nil, fmt.Errorf("failed to read multipart upload: %v", err)
+	}
+	if header.Key != key {
+		return "", nil, ErrUploadNotFound
+	}
+	return dir, &header, nil
+}
+
+func (s *FileSystem) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.ReadSeeker, size int64) error {
+	if err := validatePart(partNumber); err != nil {
+		return err
+	}
+	dir, _, err := s.loadUpload(key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "part-*")
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	defer os.Remove(tmp.Name())
+	written, err := io.Copy(tmp, contextReader{ctx: ctx, r: body})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+	if written != size {
+		return fmt.Errorf("failed to upload part %d: body is %d bytes, expected %d", partNumber, written, size)
+	}
+	// A part uploaded again replaces the previous one
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, strconv.Itoa(int(partNumber)))); err != nil {
+		return fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return nil
+}
+
+func (s *FileSystem) CompleteMultipartUpload(ctx context.Context, key, uploadID string) error {
+	dir, header, err := s.loadUpload(key, uploadID)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list uploaded parts: %v", err)
+	}
+
+	var numbers []int
+	sizes := make(map[int]int64)
+	for _, entry := range entries {
+		number, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to list uploaded parts: %v", err)
+		}
+		numbers = append(numbers, number)
+		sizes[number] = info.Size()
+	}
+	sort.Ints(numbers)
+	partSizes := make([]int64, len(numbers))
+	for i, number := range numbers {
+		partSizes[i] = sizes[number]
+	}
+	if err := checkParts(partSizes); err != nil {
+		return err
+	}
+
+	readers := make([]io.Reader, 0, len(numbers))
+	var total int64
+	for _, number := range numbers {
+		f, err := os.Open(filepath.Join(dir, strconv.Itoa(number)))
+		if err != nil {
+			return fmt.Errorf("failed to open part %d: %v", number, err)
+		}
+		defer f.Close()
+		readers = append(readers, f)
+		total += sizes[number]
+	}
+	if err := s.writeObject(ctx, key, header.ContentType, io.MultiReader(readers...), total); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clean up multipart upload: %v", err)
+	}
+	return nil
+}
+
+func (s *FileSystem) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	dir, _, err := s.loadUpload(key, uploadID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"project-api/internal/core/port/repository"
+
+	"github.com/google/uuid"
+)
+
+// memoryObject is never changed once stored, so readers need no lock
+type memoryObject struct {
+	data           []byte
+	contentType    string
+	checksumSHA256 string
+	lastModified   time.Time
+}
+
+type memoryUpload struct {
+	key         string
+	contentType string
+	parts       map[int32][]byte
+}
+
+// Memory keeps objects in the process. It is meant for development and
+// tests: everything is lost on restart and nothing is shared with the worker.
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]*memoryObject
+	uploads map[string]*memoryUpload
+}
+
+// NewMemory returns an empty in-memory backend
+func NewMemory() repository.IS3Repository {
+	return &Memory{
+		objects: make(map[string]*memoryObject),
+		uploads: make(map[string]*memoryUpload),
+	}
+}
+
+func (s *Memory) UploadFile(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(contextReader{ctx: ctx, r: body})
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return "", fmt.Errorf("body is %d bytes, expected %d", len(data), size)
+	}
+	s.put(key, contentType, data)
+	return s.FileURL(key), nil
+}
+
+func (s *Memory) put(key, contentType string, data []byte) {
+	sum := sha256.Sum256(data)
+	object := &memoryObject{
+		data:           data,
+		contentType:    contentType,
+		checksumSHA256: base64.StdEncoding.EncodeToString(sum[:]),
+		lastModified:   time.Now().UTC(),
+	}
+	s.mu.Lock()
+	s.objects[key] = object
+	s.mu.Unlock()
+}
+
+func (s *Memory) get(ctx context.Context, key string) (*memoryObject, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	object, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, repository.ErrObjectNotFound
+	}
+	return object, nil
+}
+
+func (object *memoryObject) info(key string) *repository.ObjectInfo {
+	return &repository.ObjectInfo{
+		Key:            key,
+		Size:           int64(len(object.data)),
+		ContentType:    object.contentType,
+		ETag:           etag(object.checksumSHA256),
+		ChecksumSHA256: object.checksumSHA256,
+		LastModified:   object.lastModified,
+	}
+}
+
+func (s *Memory) DownloadFile(ctx context.Context, key string) (io.ReadCloser, *repository.ObjectInfo, error) {
+	object, err := s.get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return io.NopCloser(bytes.NewReader(object.data)), object.info(key), nil
+}
+
+func (s *Memory) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	object, err := s.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	length, err = clampRange(offset, length, int64(len(object.data)))
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(object.data[offset : offset+length])), nil
+}
+
+func (s *Memory) DeleteFile(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Memory) CopyFile(ctx context.Context, srcKey, dstKey string) error {
+	if err := validateKey(dstKey); err != nil {
+		return err
+	}
+	object, err := s.get(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	s.put(dstKey, object.contentType, object.data)
+	return nil
+}
+
+func (s *Memory) StatFile(ctx context.Context, key string) (*repository.ObjectInfo, error) {
+	object, err := s.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return object.info(key), nil
+}
+
+func (s *Memory) FileURL(key string) string {
+	return "memory://" + key
+}
+
+// ListObjects lists a snapshot taken when it starts, so fn may change the
+// backend
+func (s *Memory) ListObjects(ctx context.Context, prefix string, fn func(repository.ObjectInfo) error) error {
+	var infos []*repository.ObjectInfo
+	s.mu.RLock()
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, object.info(key))
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(*info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Memory) PresignPut(ctx context.Context, input repository.PresignUploadInput) (*repository.PresignedRequest, error) {
+	return nil, repository.ErrPresignUnsupported
+}
+
+func (s *Memory) PresignPost(ctx context.Context, input repository.PresignUploadInput) (*repository.PresignedRequest, error) {
+	return nil, repository.ErrPresignUnsupported
+}
+
+func (s *Memory) PresignGet(ctx context.Context, key, fileName string, ttl time.Duration) (*repository.PresignedRequest, error) {
+	return nil, repository.ErrPresignUnsupported
+}
+
+func (s *Memory) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	uploadID := uuid.NewString()
+	s.mu.Lock()
+	s.uploads[uploadID] = &memoryUpload{
+		key:         key,
+		contentType: contentType,
+		parts:       make(map[int32][]byte),
+	}
+	s.mu.Unlock()
+	return uploadID, nil
+}
+
+func (s *Memory) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.ReadSeeker, size int64) error {
+	if err := validatePart(partNumber); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(contextReader{ctx: ctx, r: body})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("failed to upload part %d: body is %d bytes, expected %d", partNumber, len(data), size)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.key != key {
+		return ErrUploadNotFound
+	}
+	upload.parts[partNumber] = data
+	return nil
+}
+
+func (s *Memory) CompleteMultipartUpload(ctx context.Context, key, uploadID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.key != key {
+		s.mu.Unlock()
+		return ErrUploadNotFound
+	}
+	numbers := make([]int32, 0, len(upload.parts))
+	for number := range upload.parts {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	sizes := make([]int64, len(numbers))
+	for i, number := range numbers {
+		sizes[i] = int64(len(upload.parts[number]))
+	}
+	if err := checkParts(sizes); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	delete(s.uploads, uploadID)
+	s.mu.Unlock()
+
+	var data bytes.Buffer
+	for _, number := range numbers {
+		data.Write(upload.parts[number])
+	}
+	s.put(key, upload.contentType, data.Bytes())
+	return nil
+}
+
+func (s *Memory) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.key != key {
+		return ErrUploadNotFound
+	}
+	delete(s.uploads, uploadID)
+	return nil
+}
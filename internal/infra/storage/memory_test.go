@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"project-api/internal/infra/storage/storagetest"
+)
+
+func TestMemory(t *testing.T) {
+	if err := storagetest.TestStorage(context.Background(), NewMemory()); err != nil {
+		t.Fatal(err)
+	}
+}
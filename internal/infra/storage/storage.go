@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"project-api/internal/core/port/repository"
+	"project-api/internal/infra/aws"
+	"project-api/internal/infra/config"
+
+	"github.com/gofiber/storage/s3/v2"
+)
+
+const maxKeyLength = 1024
+
+var (
+	ErrInvalidKey     = errors.New("invalid object key")
+	ErrUploadNotFound = errors.New("multipart upload not found")
+	ErrPartNumber     = errors.New("part number must be between 1 and 10000")
+	ErrPartTooSmall   = errors.New("every part but the last must be at least 5MB")
+	ErrNoParts        = errors.New("multipart upload has no parts")
+	ErrInvalidRange   = errors.New("range does not overlap the object")
+)
+
+// New returns the storage backend picked by configuration
+func New() (repository.IS3Repository, error) {
+	switch backend := config.Config.GetStorageBackend(); backend {
+	case config.StorageS3:
+		s3Config := config.Config.GetS3Config()
+		return aws.New(s3.Config{
+			Bucket:      s3Config.Bucket,
+			Region:      s3Config.Region,
+			Endpoint:    s3Config.Endpoint,
+			Credentials: config.Config.GetCredentials(),
+		}), nil
+	case config.StorageLocal:
+		return NewFileSystem(config.Config.GetStorageLocalRoot())
+	case config.StorageMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// validateKey holds keys to the rules of S3 plus what keeps them from being
+// read as paths: no empty, absolute, backslashed or dot segments
+func validateKey(key string) error {
+	if key == "" || len(key) > maxKeyLength || strings.ContainsAny(key, "\x00\\") || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+func validatePart(partNumber int32) error {
+	if partNumber < 1 || partNumber > 10000 {
+		return ErrPartNumber
+	}
+	return nil
+}
+
+// checkParts applies the part size rule S3 enforces on completion, so an
+// upload that works here works there too
+func checkParts(sizes []int64) error {
+	if len(sizes) == 0 {
+		return ErrNoParts
+	}
+	for _, size := range sizes[:len(sizes)-1] {
+		if size < repository.MinPartSize {
+			return ErrPartTooSmall
+		}
+	}
+	return nil
+}
+
+// clampRange checks a range against an object of size bytes and returns its
+// length, cut at the end of the object like S3 does
+func clampRange(offset, length, size int64) (int64, error) {
+	if offset < 0 || length <= 0 || offset >= size {
+		return 0, ErrInvalidRange
+	}
+	return min(length, size-offset), nil
+}
+
+// etag quotes a checksum the way S3 quotes its ETags
+func etag(checksum string) string {
+	return `"` + checksum + `"`
+}
+
+// contextReader stops a copy once its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,410 @@
+// Package storagetest checks that a storage backend behaves the way the
+// services expect of S3, so backends can be swapped without surprises.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"project-api/internal/core/port/repository"
+
+	"github.com/google/uuid"
+)
+
+// TestStorage runs the conformance checks against repo and returns every
+// failure joined, or nil. The objects it writes live under a random prefix
+// and are deleted afterwards, so it can run against a real bucket. Call it
+// from a test with:
+//
+//	if err := storagetest.TestStorage(ctx, repo); err != nil {
+//		t.Fatal(err)
+//	}
+func TestStorage(ctx context.Context, repo repository.IS3Repository) error {
+	c := &checker{
+		ctx:    ctx,
+		repo:   repo,
+		prefix: "storagetest/" + uuid.NewString() + "/",
+	}
+	defer c.cleanup()
+
+	c.roundTrip()
+	c.overwrite()
+	c.missing()
+	c.ranges()
+	c.copy()
+	c.delete()
+	c.list()
+	c.multipart()
+	c.abort()
+	c.concurrentWrites()
+	return errors.Join(c.errs...)
+}
+
+type checker struct {
+	ctx    context.Context
+	repo   repository.IS3Repository
+	prefix string
+	keys   []string
+	errs   []error
+}
+
+func (c *checker) errorf(format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf(format, args...))
+}
+
+// key returns a key under the run's prefix and remembers it for cleanup
+func (c *checker) key(name string) string {
+	key := c.prefix + name
+	c.keys = append(c.keys, key)
+	return key
+}
+
+func (c *checker) cleanup() {
+	for _, key := range c.keys {
+		c.repo.DeleteFile(context.Background(), key)
+	}
+}
+
+func (c *checker) upload(key string, data []byte, contentType string) bool {
+	if _, err := c.repo.UploadFile(c.ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		c.errorf("UploadFile(%s): %v", key, err)
+		return false
+	}
+	return true
+}
+
+// expectContent downloads key and compares it, and what it says about
+// itself, with data
+func (c *checker) expectContent(op, key string, data []byte, contentType string) {
+	body, info, err := c.repo.DownloadFile(c.ctx, key)
+	if err != nil {
+		c.errorf("%s: DownloadFile(%s): %v", op, key, err)
+		return
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		c.errorf("%s: reading %s: %v", op, key, err)
+		return
+	}
+	if !bytes.Equal(got, data) {
+		c.errorf("%s: %s holds %d bytes that differ from the %d written", op, key, len(got), len(data))
+	}
+	if info.Size != int64(len(data)) {
+		c.errorf("%s: DownloadFile(%s) reports %d bytes, want %d", op, key, info.Size, len(data))
+	}
+	if contentType != "" && info.ContentType != contentType {
+		c.errorf("%s: DownloadFile(%s) reports content type %q, want %q", op, key, info.ContentType, contentType)
+	}
+}
+
+func (c *checker) roundTrip() {
+	data := pattern(1<<20 + 7)
+	key := c.key("nested/dir/round trip.bin")
+	if !c.upload(key, data, "application/octet-stream") {
+		return
+	}
+	c.expectContent("round trip", key, data, "application/octet-stream")
+
+	info, err := c.repo.StatFile(c.ctx, key)
+	if err != nil {
+		c.errorf("StatFile(%s): %v", key, err)
+		return
+	}
+	if info.Size != int64(len(data)) {
+		c.errorf("StatFile(%s) reports %d bytes, want %d", key, info.Size, len(data))
+	}
+	if info.ContentType != "application/octet-stream" {
+		c.errorf("StatFile(%s) reports content type %q", key, info.ContentType)
+	}
+	if info.ETag == "" {
+		c.errorf("StatFile(%s) reports no ETag", key)
+	}
+	if info.LastModified.IsZero() {
+		c.errorf("StatFile(%s) reports no modification time", key)
+	}
+	// The checksum is optional, but when there is one it has to be right
+	if info.ChecksumSHA256 != "" && info.ChecksumSHA256 != checksum(data) {
+		c.errorf("StatFile(%s) reports checksum %s, want %s", key, info.ChecksumSHA256, checksum(data))
+	}
+
+	empty := c.key("empty")
+	if c.upload(empty, nil, "text/plain") {
+		c.expectContent("empty object", empty, nil, "text/plain")
+	}
+}
+
+func (c *checker) overwrite() {
+	key := c.key("overwrite")
+	if !c.upload(key, []byte("first version, longer than the second"), "text/plain") {
+		return
+	}
+	if !c.upload(key, []byte("second"), "text/csv") {
+		return
+	}
+	c.expectContent("overwrite", key, []byte("second"), "text/csv")
+}
+
+func (c *checker) missing() {
+	key := c.key("missing")
+	if _, _, err := c.repo.DownloadFile(c.ctx, key); !errors.Is(err, repository.ErrObjectNotFound) {
+		c.errorf("DownloadFile of a missing object: got %v, want ErrObjectNotFound", err)
+	}
+	if _, err := c.repo.StatFile(c.ctx, key); !errors.Is(err, repository.ErrObjectNotFound) {
+		c.errorf("StatFile of a missing object: got %v, want ErrObjectNotFound", err)
+	}
+	if _, err := c.repo.DownloadRange(c.ctx, key, 0, 1); !errors.Is(err, repository.ErrObjectNotFound) {
+		c.errorf("DownloadRange of a missing object: got %v, want ErrObjectNotFound", err)
+	}
+}
+
+func (c *checker) ranges() {
+	data := pattern(10000)
+	key := c.key("ranges")
+	if !c.upload(key, data, "application/octet-stream") {
+		return
+	}
+
+	for _, r := range []struct {
+		offset, length int64
+		want           []byte
+	}{
+		{0, 1, data[:1]},
+		{1234, 4321, data[1234:5555]},
+		{9999, 1, data[9999:]},
+		// A range running past the end stops at the end
+		{9000, 5000, data[9000:]},
+	} {
+		body, err := c.repo.DownloadRange(c.ctx, key, r.offset, r.length)
+		if err != nil {
+			c.errorf("DownloadRange(%d, %d): %v", r.offset, r.length, err)
+			continue
+		}
+		got, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			c.errorf("DownloadRange(%d, %d): reading: %v", r.offset, r.length, err)
+			continue
+		}
+		if !bytes.Equal(got, r.want) {
+			c.errorf("DownloadRange(%d, %d) returned %d bytes that differ from the %d expected", r.offset, r.length, len(got), len(r.want))
+		}
+	}
+
+	if body, err := c.repo.DownloadRange(c.ctx, key, int64(len(data)), 1); err == nil {
+		body.Close()
+		c.errorf("DownloadRange past the end of the object succeeded")
+	}
+}
+
+func (c *checker) copy() {
+	data := pattern(4096)
+	src, dst := c.key("copy/source"), c.key("copy/destination")
+	if !c.upload(src, data, "image/png") {
+		return
+	}
+	if err := c.repo.CopyFile(c.ctx, src, dst); err != nil {
+		c.errorf("CopyFile: %v", err)
+		return
+	}
+	c.expectContent("copy destination", dst, data, "image/png")
+	c.expectContent("copy source", src, data, "image/png")
+
+	if err := c.repo.CopyFile(c.ctx, c.key("copy/missing"), c.key("copy/nowhere")); err == nil {
+		c.errorf("CopyFile of a missing object succeeded")
+	}
+}
+
+func (c *checker) delete() {
+	key := c.key("delete")
+	if !c.upload(key, []byte("delete me"), "text/plain") {
+		return
+	}
+	if err := c.repo.DeleteFile(c.ctx, key); err != nil {
+		c.errorf("DeleteFile: %v", err)
+		return
+	}
+	if _, err := c.repo.StatFile(c.ctx, key); !errors.Is(err, repository.ErrObjectNotFound) {
+		c.errorf("StatFile after DeleteFile: got %v, want ErrObjectNotFound", err)
+	}
+	// Deleting what is already gone is not an error, as on S3
+	if err := c.repo.DeleteFile(c.ctx, key); err != nil {
+		c.errorf("DeleteFile of a missing object: %v", err)
+	}
+}
+
+func (c *checker) list() {
+	want := map[string]int64{
+		c.key("list/a"):         1,
+		c.key("list/b/c"):       22,
+		c.key("list/b/d/e.txt"): 333,
+	}
+	for key, size := range want {
+		if !c.upload(key, pattern(int(size)), "text/plain") {
+			return
+		}
+	}
+	if !c.upload(c.key("not-listed"), []byte("x"), "text/plain") {
+		return
+	}
+
+	got := make(map[string]int64)
+	err := c.repo.ListObjects(c.ctx, c.prefix+"list/", func(info repository.ObjectInfo) error {
+		got[info.Key] = info.Size
+		if info.LastModified.IsZero() {
+			c.errorf("ListObjects reports no modification time for %s", info.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		c.errorf("ListObjects: %v", err)
+		return
+	}
+	for key, size := range want {
+		if listed, ok := got[key]; !ok {
+			c.errorf("ListObjects missed %s", key)
+		} else if listed != size {
+			c.errorf("ListObjects reports %d bytes for %s, want %d", listed, key, size)
+		}
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			c.errorf("ListObjects returned %s, outside the prefix", key)
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = c.repo.ListObjects(c.ctx, c.prefix+"list/", func(repository.ObjectInfo) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		c.errorf("ListObjects didn't stop at the first error: got %v after %d calls", err, calls)
+	}
+}
+
+func (c *checker) uploadPart(key, uploadID string, number int32, data []byte) bool {
+	if err := c.repo.UploadPart(c.ctx, key, uploadID, number, bytes.NewReader(data), int64(len(data))); err != nil {
+		c.errorf("UploadPart(%d): %v", number, err)
+		return false
+	}
+	return true
+}
+
+func (c *checker) multipart() {
+	key := c.key("multipart")
+	uploadID, err := c.repo.CreateMultipartUpload(c.ctx, key, "video/mp4")
+	if err != nil {
+		c.errorf("CreateMultipartUpload: %v", err)
+		return
+	}
+
+	first := pattern(repository.MinPartSize)
+	last := []byte("the last part may be small")
+	// Parts may arrive out of order, and one sent again replaces the first try
+	if !c.uploadPart(key, uploadID, 2, []byte("a first try")) ||
+		!c.uploadPart(key, uploadID, 1, first) ||
+		!c.uploadPart(key, uploadID, 2, last) {
+		c.repo.AbortMultipartUpload(c.ctx, key, uploadID)
+		return
+	}
+	if err := c.repo.CompleteMultipartUpload(c.ctx, key, uploadID); err != nil {
+		c.errorf("CompleteMultipartUpload: %v", err)
+		c.repo.AbortMultipartUpload(c.ctx, key, uploadID)
+		return
+	}
+	c.expectContent("multipart", key, append(first, last...), "video/mp4")
+
+	if err := c.repo.CompleteMultipartUpload(c.ctx, key, uploadID); err == nil {
+		c.errorf("CompleteMultipartUpload of a completed upload succeeded")
+	}
+}
+
+func (c *checker) abort() {
+	key := c.key("aborted")
+	uploadID, err := c.repo.CreateMultipartUpload(c.ctx, key, "text/plain")
+	if err != nil {
+		c.errorf("CreateMultipartUpload: %v", err)
+		return
+	}
+	if !c.uploadPart(key, uploadID, 1, []byte("never completed")) {
+		c.repo.AbortMultipartUpload(c.ctx, key, uploadID)
+		return
+	}
+	if err := c.repo.AbortMultipartUpload(c.ctx, key, uploadID); err != nil {
+		c.errorf("AbortMultipartUpload: %v", err)
+		return
+	}
+	if err := c.repo.CompleteMultipartUpload(c.ctx, key, uploadID); err == nil {
+		c.errorf("CompleteMultipartUpload of an aborted upload succeeded")
+	}
+	if _, err := c.repo.StatFile(c.ctx, key); !errors.Is(err, repository.ErrObjectNotFound) {
+		c.errorf("StatFile after an aborted upload: got %v, want ErrObjectNotFound", err)
+	}
+}
+
+// concurrentWrites checks that writes to one key don't mix: whoever wins,
+// the object is one writer's content whole
+func (c *checker) concurrentWrites() {
+	const writers = 8
+	key := c.key("concurrent")
+	contents := make([][]byte, writers)
+	for i := range contents {
+		contents[i] = bytes.Repeat([]byte{byte('a' + i)}, 256<<10)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := range contents {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = c.repo.UploadFile(c.ctx, key, bytes.NewReader(contents[i]), int64(len(contents[i])), "text/plain")
+		}(i)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		c.errorf("concurrent UploadFile: %v", err)
+		return
+	}
+
+	body, _, err := c.repo.DownloadFile(c.ctx, key)
+	if err != nil {
+		c.errorf("DownloadFile after concurrent writes: %v", err)
+		return
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		c.errorf("reading after concurrent writes: %v", err)
+		return
+	}
+	for _, content := range contents {
+		if bytes.Equal(got, content) {
+			return
+		}
+	}
+	c.errorf("concurrent writes left %d bytes that match none of the writers", len(got))
+}
+
+// pattern returns n bytes that don't repeat with a short period, so a part
+// or range in the wrong place shows
+func pattern(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*7 + i/251)
+	}
+	return data
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -4,4 +4,8 @@ type EmailData struct {
 	Name  string
 	Token string
 	Host  string
+	// Tenant goes into links so they resolve in the recipient's tenant
+	Tenant string
+	Title  string
+	Body   string
 }
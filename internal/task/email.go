@@ -4,10 +4,18 @@ import (
 	"project-api/internal/infra/aws"
 )
 
-func TaskSendConfirmationEmail(toEmail string, token string, name string, host string) error {
-	return aws.SendConfirmationEmail(toEmail, token, name, host)
+func TaskSendConfirmationEmail(toEmail string, token string, name string, host string, tenant string) error {
+	return aws.SendConfirmationEmail(toEmail, token, name, host, tenant)
 }
 
-func TaskSendResetPasswordEmail(toEmail string, token string, name string, host string) error {
-	return aws.SendResetPasswordEmail(toEmail, token, name, host)
+func TaskSendResetPasswordEmail(toEmail string, token string, name string, host string, tenant string) error {
+	return aws.SendResetPasswordEmail(toEmail, token, name, host, tenant)
+}
+
+func TaskSendInvitationEmail(toEmail string, token string, name string, host string, tenant string) error {
+	return aws.SendInvitationEmail(toEmail, token, name, host, tenant)
+}
+
+func TaskSendNotificationEmail(toEmail string, title string, name string, body string) error {
+	return aws.SendNotificationEmail(toEmail, title, name, body)
 }
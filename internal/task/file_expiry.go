@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// DeleteExpiredFiles is the periodic task that removes expired uploads
+const DeleteExpiredFiles = "delete_expired_files"
+
+// ExpiryTask deletes files once the expiry they were uploaded with has passed
+type ExpiryTask struct {
+	service InS.IS3Service
+}
+
+func NewExpiryTask(service InS.IS3Service) *ExpiryTask {
+	return &ExpiryTask{service: service}
+}
+
+// DeleteExpired deletes the expired files of every tenant
+func (t *ExpiryTask) DeleteExpired() error {
+	return t.service.DeleteExpiredFiles(context.Background())
+}
@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// PruneFileVersions is the periodic task that applies version retention
+const PruneFileVersions = "prune_file_versions"
+
+// VersionTask removes the file versions the retention policy no longer keeps
+type VersionTask struct {
+	service InS.IS3Service
+}
+
+func NewVersionTask(service InS.IS3Service) *VersionTask {
+	return &VersionTask{service: service}
+}
+
+// PruneVersions prunes the version history of every tenant
+func (t *VersionTask) PruneVersions() error {
+	return t.service.PruneVersions(context.Background())
+}
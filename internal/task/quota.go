@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// ReconcileStorageUsage is the periodic task that recomputes storage usage
+const ReconcileStorageUsage = "reconcile_storage_usage"
+
+// QuotaTask repairs the usage counters that quotas are enforced against
+type QuotaTask struct {
+	service InS.IQuotaService
+}
+
+func NewQuotaTask(service InS.IQuotaService) *QuotaTask {
+	return &QuotaTask{service: service}
+}
+
+// ReconcileUsage recomputes the usage of every tenant from its files
+func (t *QuotaTask) ReconcileUsage() error {
+	return t.service.ReconcileAll(context.Background())
+}
@@ -0,0 +1,25 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// ReconcileStorageObjects is the periodic task that checks storage against the database
+const ReconcileStorageObjects = "reconcile_storage_objects"
+
+// ReconcileTask finds objects no row points at and rows whose objects are gone
+type ReconcileTask struct {
+	service InS.IReconcileService
+}
+
+func NewReconcileTask(service InS.IReconcileService) *ReconcileTask {
+	return &ReconcileTask{service: service}
+}
+
+// ReconcileObjects reports, and repairs when configured to, every tenant's
+// disagreements between storage and the database
+func (t *ReconcileTask) ReconcileObjects() error {
+	return t.service.ReconcileScheduled(context.Background())
+}
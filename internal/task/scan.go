@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// ScanUploads is the periodic task that scans quarantined uploads
+const ScanUploads = "scan_uploads"
+
+// ScanTask releases uploads from quarantine once a virus scan clears them
+type ScanTask struct {
+	service InS.IScanService
+}
+
+func NewScanTask(service InS.IScanService) *ScanTask {
+	return &ScanTask{service: service}
+}
+
+// ScanUploads scans the quarantined uploads of every tenant
+func (t *ScanTask) ScanUploads() error {
+	return t.service.ScanPending(context.Background())
+}
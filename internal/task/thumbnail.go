@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// GenerateThumbnails is the task the API queues after an image upload
+const GenerateThumbnails = "generate_thumbnails"
+
+// ThumbnailTask makes the thumbnails of image uploads
+type ThumbnailTask struct {
+	service InS.IS3Service
+}
+
+func NewThumbnailTask(service InS.IS3Service) *ThumbnailTask {
+	return &ThumbnailTask{service: service}
+}
+
+// GenerateThumbnails makes the thumbnails of one version of a file
+func (t *ThumbnailTask) GenerateThumbnails(tenantID, fileID string, version int) error {
+	return t.service.GenerateThumbnails(context.Background(), tenantID, fileID, version)
+}
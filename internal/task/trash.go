@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// PurgeTrash is the periodic task that empties expired trash
+const PurgeTrash = "purge_trash"
+
+// TrashTask removes deleted files once they can no longer be restored
+type TrashTask struct {
+	service InS.IS3Service
+}
+
+func NewTrashTask(service InS.IS3Service) *TrashTask {
+	return &TrashTask{service: service}
+}
+
+// PurgeTrash purges the expired trash of every tenant
+func (t *TrashTask) PurgeTrash() error {
+	return t.service.PurgeTrash(context.Background())
+}
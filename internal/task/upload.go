@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+
+	InS "project-api/internal/core/port/service"
+)
+
+// PurgeExpiredUploads is the periodic task that frees abandoned tus uploads
+const PurgeExpiredUploads = "purge_expired_uploads"
+
+// UploadTask frees the storage of tus uploads that expired unfinished
+type UploadTask struct {
+	service InS.ITusService
+}
+
+func NewUploadTask(service InS.ITusService) *UploadTask {
+	return &UploadTask{service: service}
+}
+
+// PurgeExpired purges the expired tus uploads of every tenant
+func (t *UploadTask) PurgeExpired() error {
+	return t.service.PurgeExpiredUploads(context.Background())
+}
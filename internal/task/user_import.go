@@ -0,0 +1,23 @@
+package task
+
+import (
+	"context"
+
+	"project-api/internal/core/common/utils"
+	InS "project-api/internal/core/port/service"
+)
+
+// UserImportTask runs the bulk user imports queued by the admin API
+type UserImportTask struct {
+	service InS.IUserImportService
+}
+
+func NewUserImportTask(service InS.IUserImportService) *UserImportTask {
+	return &UserImportTask{service: service}
+}
+
+// ImportUsers processes import job jobID inside tenantID
+func (t *UserImportTask) ImportUsers(tenantID string, jobID uint) error {
+	ctx := utils.WithTenantID(context.Background(), tenantID)
+	return t.service.ProcessImportJob(ctx, jobID)
+}